@@ -0,0 +1,785 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	maxExecInspectRetries = 50
+	execInspectInterval   = 100 * time.Millisecond
+)
+
+// Backend is the way the functions of this package talk to the Docker engine.
+type Backend string
+
+const (
+	// BackendCLI runs the docker CLI. This is the default.
+	BackendCLI Backend = "cli"
+
+	// BackendAPI calls the Docker Engine API directly, which is faster with many parallel tests, does not depend on the
+	// output format of the CLI, and works where only the socket is available. Like the docker CLI, it honors the
+	// DOCKER_HOST, DOCKER_API_VERSION, DOCKER_CERT_PATH and DOCKER_TLS_VERIFY environment variables.
+	BackendAPI Backend = "api"
+)
+
+// BackendEnvVar is the environment variable that selects the backend of the options that do not set one, and of the
+// functions that do not take options, such as Inspect and ListImages. Set it to "api" to use the Engine API.
+const BackendEnvVar = "TERRATEST_DOCKER_BACKEND"
+
+// resolveBackend returns the given backend, or the one selected with BackendEnvVar if it is empty.
+func resolveBackend(backend Backend) Backend {
+	if backend != "" {
+		return backend
+	}
+	if Backend(strings.ToLower(os.Getenv(BackendEnvVar))) == BackendAPI {
+		return BackendAPI
+	}
+	return BackendCLI
+}
+
+// apiClientEnvVars are the environment variables that configure Docker Engine API clients.
+var apiClientEnvVars = []string{"DOCKER_HOST", "DOCKER_API_VERSION", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"}
+
+var (
+	apiClientsMutex sync.Mutex
+	apiClients      = map[string]*client.Client{}
+)
+
+// newAPIClientE returns a Docker Engine API client configured from the environment, negotiating the API version with
+// the engine. The clients are shared by all the calls with the same environment, so that their connections are reused
+// and the API version is only negotiated once. They are safe for concurrent use, and must not be closed.
+func newAPIClientE() (*client.Client, error) {
+	env := []string{}
+	for _, name := range apiClientEnvVars {
+		env = append(env, name+"="+os.Getenv(name))
+	}
+	key := strings.Join(env, "\n")
+
+	apiClientsMutex.Lock()
+	defer apiClientsMutex.Unlock()
+	if cli, ok := apiClients[key]; ok {
+		return cli, nil
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	apiClients[key] = cli
+	return cli, nil
+}
+
+// runWithAPIE implements 'docker run' with the Engine API, returning the stdout and the combined stdout and stderr of
+// the container. For detached containers, the stdout is the ID of the container.
+func runWithAPIE(t testing.TestingT, image string, options *RunOptions) (string, string, error) {
+	if len(options.OtherOptions) > 0 {
+		return "", "", UnsupportedAPIOptionError{Option: "OtherOptions"}
+	}
+
+	cli, err := newAPIClientE()
+	if err != nil {
+		return "", "", err
+	}
+	ctx := context.Background()
+
+	config, hostConfig, networkingConfig, err := formatAPIContainerConfig(image, options)
+	if err != nil {
+		return "", "", err
+	}
+	created, err := cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, options.Name)
+	if client.IsErrNotFound(err) {
+		options.Logger.Logf(t, "Pulling image '%s'", image)
+		if err := pullImageWithAPIE(ctx, cli, image); err != nil {
+			return "", "", err
+		}
+		created, err = cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, options.Name)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	for _, warning := range created.Warnings {
+		options.Logger.Logf(t, "WARNING: %s", warning)
+	}
+
+	if options.Detach {
+		if err := cli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); err != nil {
+			return "", "", err
+		}
+		return created.ID, created.ID, nil
+	}
+
+	// Wait for the next exit before starting the container, so that the exit cannot be missed
+	waitCh, waitErrCh := cli.ContainerWait(ctx, created.ID, container.WaitConditionNextExit)
+	if err := cli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); err != nil {
+		return "", "", err
+	}
+	var exitCode int64
+	select {
+	case result := <-waitCh:
+		if result.Error != nil {
+			return "", "", errors.New(result.Error.Message)
+		}
+		exitCode = result.StatusCode
+	case err := <-waitErrCh:
+		return "", "", err
+	}
+
+	stdout, combined, err := getContainerLogsWithAPIE(ctx, cli, created.ID, types.ContainerLogsOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	logOutputLines(t, options.Logger, combined)
+
+	if options.Remove {
+		if err := cli.ContainerRemove(ctx, created.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return stdout, combined, err
+		}
+	}
+	if exitCode != 0 {
+		return stdout, combined, ContainerExitError{ID: created.ID, ExitCode: int(exitCode)}
+	}
+	return stdout, combined, nil
+}
+
+// formatAPIContainerConfig converts the run options to the configuration of a container for the Engine API.
+func formatAPIContainerConfig(image string, options *RunOptions) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	exposedPorts, portBindings, err := nat.ParsePortSpecs(options.Ports)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	config := &container.Config{
+		Image:        image,
+		Cmd:          strslice.StrSlice(options.Command),
+		Env:          options.EnvironmentVariables,
+		ExposedPorts: exposedPorts,
+		Tty:          options.Tty,
+		User:         options.User,
+		Volumes:      map[string]struct{}{},
+	}
+	if options.Entrypoint != "" {
+		config.Entrypoint = strslice.StrSlice{options.Entrypoint}
+	}
+
+	hostConfig := &container.HostConfig{
+		NetworkMode:  container.NetworkMode(options.Network),
+		PortBindings: portBindings,
+		Privileged:   options.Privileged,
+		// Only detached containers are removed by the engine: the others are removed once their logs are read
+		AutoRemove: options.Remove && options.Detach,
+	}
+	if options.Init {
+		hostConfig.Init = &options.Init
+	}
+	for _, volumeSpec := range options.Volumes {
+		if strings.Contains(volumeSpec, ":") {
+			hostConfig.Binds = append(hostConfig.Binds, volumeSpec)
+		} else {
+			config.Volumes[volumeSpec] = struct{}{}
+		}
+	}
+
+	networkingConfig := &network.NetworkingConfig{}
+	if options.Network != "" {
+		networkingConfig.EndpointsConfig = map[string]*network.EndpointSettings{
+			options.Network: {Aliases: options.NetworkAliases},
+		}
+	}
+
+	return config, hostConfig, networkingConfig, nil
+}
+
+// pullImageWithAPIE pulls the given image, waiting for the pull to complete.
+func pullImageWithAPIE(ctx context.Context, cli *client.Client, image string) error {
+	out, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = readAPIProgressE(out)
+	return err
+}
+
+// apiProgressMessage is a message of the JSON stream returned by the pull and build endpoints of the Engine API.
+type apiProgressMessage struct {
+	Stream      string
+	Status      string
+	Error       string
+	ErrorDetail struct {
+		Message string
+	}
+}
+
+// readAPIProgressE reads a JSON progress stream of the Engine API until the end, and returns the text of the stream
+// messages (e.g. the output of the build steps), or the first error message.
+func readAPIProgressE(reader io.Reader) (string, error) {
+	var output strings.Builder
+	decoder := json.NewDecoder(reader)
+	for {
+		var message apiProgressMessage
+		if err := decoder.Decode(&message); err == io.EOF {
+			return output.String(), nil
+		} else if err != nil {
+			return output.String(), err
+		}
+		if message.Error != "" || message.ErrorDetail.Message != "" {
+			if message.ErrorDetail.Message != "" {
+				return output.String(), errors.New(message.ErrorDetail.Message)
+			}
+			return output.String(), errors.New(message.Error)
+		}
+		output.WriteString(message.Stream)
+	}
+}
+
+// stopWithAPIE implements 'docker stop' with the Engine API, returning the stopped containers, one per line, like the
+// docker CLI.
+func stopWithAPIE(containers []string, options *StopOptions) (string, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return "", err
+	}
+
+	stopOptions := container.StopOptions{}
+	if options.Time != 0 {
+		stopOptions.Timeout = &options.Time
+	}
+	for _, id := range containers {
+		if err := cli.ContainerStop(context.Background(), id, stopOptions); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(containers, "\n"), nil
+}
+
+// inspectWithAPIE implements 'docker container inspect' with the Engine API. The inspect endpoint returns the same JSON
+// document as the docker CLI, so it is converted the same way.
+func inspectWithAPIE(t testing.TestingT, id string) (*ContainerInspect, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return nil, err
+	}
+
+	inspect, err := cli.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(inspect)
+	if err != nil {
+		return nil, err
+	}
+	var container inspectOutput
+	if err := json.Unmarshal(out, &container); err != nil {
+		return nil, err
+	}
+	return transformContainer(t, container)
+}
+
+// listImagesWithAPIE implements 'docker images' with the Engine API, formatting the images the way the docker CLI does.
+func listImagesWithAPIE() ([]Image, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := cli.ImageList(context.Background(), types.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	images := []Image{}
+	for _, summary := range summaries {
+		id := strings.TrimPrefix(summary.ID, "sha256:")
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		created := time.Unix(summary.Created, 0)
+		image := Image{
+			ID:           id,
+			CreatedAt:    created.Format("2006-01-02 15:04:05 -0700 MST"),
+			CreatedSince: units.HumanDuration(time.Since(created)) + " ago",
+			SharedSize:   "N/A",
+			UniqueSize:   "N/A",
+			VirtualSize:  units.HumanSizeWithPrecision(float64(summary.Size), 3),
+			Containers:   "N/A",
+			Digest:       "<none>",
+		}
+		repoTags := summary.RepoTags
+		if len(repoTags) == 0 {
+			repoTags = []string{"<none>:<none>"}
+		}
+		for _, repoTag := range repoTags {
+			separator := strings.LastIndex(repoTag, ":")
+			image.Repository, image.Tag = repoTag[:separator], repoTag[separator+1:]
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// buildWithAPIE implements 'docker build' with the Engine API, using the legacy builder. The build context is the
+// whole directory at the given path, .dockerignore files are not supported.
+func buildWithAPIE(t testing.TestingT, path string, options *BuildOptions) error {
+	if len(options.Architectures) > 0 {
+		return UnsupportedAPIOptionError{Option: "Architectures"}
+	}
+	if len(options.OtherOptions) > 0 {
+		return UnsupportedAPIOptionError{Option: "OtherOptions"}
+	}
+	if options.EnableBuildKit {
+		return UnsupportedAPIOptionError{Option: "EnableBuildKit"}
+	}
+	if len(options.CacheFrom) > 0 || len(options.CacheTo) > 0 {
+		return UnsupportedAPIOptionError{Option: "CacheFrom and CacheTo"}
+	}
+
+	buildContext, err := tarPathE(path, "")
+	if err != nil {
+		return err
+	}
+
+	cli, err := newAPIClientE()
+	if err != nil {
+		return err
+	}
+
+	buildArgs := map[string]*string{}
+	for _, arg := range options.BuildArgs {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue {
+			// Like the docker CLI, take the value from the environment if it is not set
+			envValue, ok := os.LookupEnv(name)
+			if !ok {
+				buildArgs[name] = nil
+				continue
+			}
+			value = envValue
+		}
+		buildArgs[name] = &value
+	}
+
+	resp, err := cli.ImageBuild(context.Background(), buildContext, types.ImageBuildOptions{
+		Tags:      options.Tags,
+		BuildArgs: buildArgs,
+		Target:    options.Target,
+		Remove:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	output, err := readAPIProgressE(resp.Body)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		options.Logger.Logf(t, "%s", scanner.Text())
+	}
+	return err
+}
+
+// tarPathE returns a tar archive of the file or directory at the given path. The root entry of the archive is named
+// after the given name, or the content of the directory is at the root of the archive if the name is empty.
+func tarPathE(srcPath string, name string) (io.Reader, error) {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	err := filepath.Walk(srcPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcPath, file)
+		if err != nil {
+			return err
+		}
+		entryName := path.Join(name, filepath.ToSlash(relPath))
+		if entryName == "." {
+			return nil
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = entryName
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(writer, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// untarE extracts the given tar archive into destDir, renaming its root entry from rootName to name. Devices and other
+// special files are skipped. As the archive may come from an untrusted container, nothing is written through the
+// symbolic links it contains, so that they cannot be used to write outside of destDir.
+func untarE(reader io.Reader, destDir string, rootName string, name string) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		entryName := path.Clean(header.Name)
+		if entryName != rootName && !strings.HasPrefix(entryName, rootName+"/") {
+			return fmt.Errorf("unexpected entry %s in the archive of %s", header.Name, rootName)
+		}
+		target := filepath.Join(destDir, name, filepath.FromSlash(strings.TrimPrefix(entryName, rootName)))
+		if err := checkNoSymlinkInPathE(destDir, target); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			// Replace a symbolic link extracted before rather than writing to its target
+			if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(target); err != nil {
+					return err
+				}
+			}
+			if err := writeFileE(target, tarReader, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkNoSymlinkInPathE returns an error if any of the parent directories of target below root is a symbolic link.
+func checkNoSymlinkInPathE(root string, target string) error {
+	relPath, err := filepath.Rel(root, target)
+	if err != nil {
+		return err
+	}
+	current := root
+	parents := strings.Split(filepath.Dir(relPath), string(filepath.Separator))
+	for _, parent := range parents {
+		if parent == "." {
+			continue
+		}
+		current = filepath.Join(current, parent)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("cannot extract %s through the symbolic link %s", target, current)
+		}
+	}
+	return nil
+}
+
+// writeFileE writes the content of the reader to the file at the given path, creating or truncating it.
+func writeFileE(filePath string, reader io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// getContainerLogsWithAPIE returns the stdout and the combined stdout and stderr of the given container, with the
+// Engine API. Like the output of the docker CLI captured by the shell package, they do not end with a newline.
+func getContainerLogsWithAPIE(ctx context.Context, cli *client.Client, id string, options types.ContainerLogsOptions) (string, string, error) {
+	var stdout, combined bytes.Buffer
+	err := copyContainerLogsWithAPIE(ctx, cli, id, options, io.MultiWriter(&stdout, &combined), &combined)
+	return strings.TrimSuffix(stdout.String(), "\n"), strings.TrimSuffix(combined.String(), "\n"), err
+}
+
+// copyContainerLogsWithAPIE copies the stdout and stderr of the given container to the given writers, with the Engine
+// API.
+func copyContainerLogsWithAPIE(ctx context.Context, cli *client.Client, id string, options types.ContainerLogsOptions, stdout io.Writer, stderr io.Writer) error {
+	inspect, err := cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	options.ShowStdout = true
+	options.ShowStderr = true
+	logs, err := cli.ContainerLogs(ctx, id, options)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	if inspect.Config != nil && inspect.Config.Tty {
+		// With a TTY, the output is not multiplexed, and stdout and stderr cannot be told apart
+		_, err = io.Copy(stdout, logs)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, logs)
+	}
+	return err
+}
+
+// formatAPILogsOptions converts the logs options to the options of the logs endpoint of the Engine API.
+func formatAPILogsOptions(options *LogsOptions) types.ContainerLogsOptions {
+	apiOptions := types.ContainerLogsOptions{Timestamps: options.Timestamps}
+	if !options.Since.IsZero() {
+		apiOptions.Since = options.Since.Format(time.RFC3339Nano)
+	}
+	if !options.Until.IsZero() {
+		apiOptions.Until = options.Until.Format(time.RFC3339Nano)
+	}
+	return apiOptions
+}
+
+// logsWithAPIE implements 'docker logs' with the Engine API, returning the stdout and stderr of the container,
+// interleaved.
+func logsWithAPIE(ctx context.Context, id string, options *LogsOptions) (string, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return "", err
+	}
+
+	_, combined, err := getContainerLogsWithAPIE(ctx, cli, id, formatAPILogsOptions(options))
+	return combined, err
+}
+
+// followLogsWithAPIE implements 'docker logs --follow' with the Engine API. It returns a reader of the stdout and
+// stderr of the container, interleaved, which is closed when the container stops or the context is done.
+func followLogsWithAPIE(ctx context.Context, id string, options *LogsOptions) (io.Reader, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return nil, err
+	}
+
+	apiOptions := formatAPILogsOptions(options)
+	apiOptions.Follow = true
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(copyContainerLogsWithAPIE(ctx, cli, id, apiOptions, writer, writer))
+	}()
+	return reader, nil
+}
+
+// execWithAPIE implements 'docker exec' with the Engine API.
+func execWithAPIE(t testing.TestingT, id string, command []string, options *ExecOptions) (*ExecOutput, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	created, err := cli.ContainerExecCreate(ctx, id, types.ExecConfig{
+		User:         options.User,
+		WorkingDir:   options.WorkingDir,
+		Env:          options.EnvironmentVariables,
+		Cmd:          command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cli.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return nil, err
+	}
+	logOutputLines(t, options.Logger, stdout.String())
+	logOutputLines(t, options.Logger, stderr.String())
+
+	// The command may still be reported as running for a short while after its output is closed
+	for i := 0; i < maxExecInspectRetries; i++ {
+		inspect, err := cli.ContainerExecInspect(ctx, created.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !inspect.Running {
+			return &ExecOutput{
+				Stdout:   strings.TrimSuffix(stdout.String(), "\n"),
+				Stderr:   strings.TrimSuffix(stderr.String(), "\n"),
+				ExitCode: inspect.ExitCode,
+			}, nil
+		}
+		time.Sleep(execInspectInterval)
+	}
+	return nil, fmt.Errorf("command %v in container %s is still running after its output was closed", command, id)
+}
+
+// copyToContainerWithAPIE implements 'docker cp' from the host to a container with the Engine API.
+func copyToContainerWithAPIE(id string, srcPath string, destPath string) error {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	// Like docker cp, copy into destPath if it is an existing directory, or to destPath otherwise
+	destDir, name := destPath, filepath.Base(srcPath)
+	stat, err := cli.ContainerStatPath(ctx, id, destPath)
+	if client.IsErrNotFound(err) || (err == nil && !stat.Mode.IsDir()) {
+		destDir, name = path.Dir(destPath), path.Base(destPath)
+	} else if err != nil {
+		return err
+	}
+
+	content, err := tarPathE(srcPath, name)
+	if err != nil {
+		return err
+	}
+	return cli.CopyToContainer(ctx, id, destDir, content, types.CopyToContainerOptions{})
+}
+
+// copyFromContainerWithAPIE implements 'docker cp' from a container to the host with the Engine API.
+func copyFromContainerWithAPIE(id string, srcPath string, destPath string) error {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return err
+	}
+
+	content, stat, err := cli.CopyFromContainer(context.Background(), id, srcPath)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	// Like docker cp, copy into destPath if it is an existing directory, or to destPath otherwise
+	destDir, name := destPath, stat.Name
+	if info, err := os.Stat(destPath); os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+		destDir, name = filepath.Dir(destPath), filepath.Base(destPath)
+	} else if err != nil {
+		return err
+	}
+	return untarE(content, destDir, stat.Name, name)
+}
+
+// createNetworkWithAPIE implements 'docker network create' with the Engine API, returning the ID of the network.
+func createNetworkWithAPIE(name string, options *NetworkOptions) (string, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return "", err
+	}
+
+	created, err := cli.NetworkCreate(context.Background(), name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         options.Driver,
+		Internal:       options.Internal,
+		Labels:         options.Labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// removeNetworkWithAPIE implements 'docker network rm' with the Engine API.
+func removeNetworkWithAPIE(name string) error {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return err
+	}
+	return cli.NetworkRemove(context.Background(), name)
+}
+
+// createVolumeWithAPIE implements 'docker volume create' with the Engine API, returning the name of the volume.
+func createVolumeWithAPIE(name string, options *VolumeOptions) (string, error) {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return "", err
+	}
+
+	created, err := cli.VolumeCreate(context.Background(), volume.CreateOptions{
+		Name:   name,
+		Driver: options.Driver,
+		Labels: options.Labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+// removeVolumeWithAPIE implements 'docker volume rm' with the Engine API.
+func removeVolumeWithAPIE(name string) error {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return err
+	}
+	return cli.VolumeRemove(context.Background(), name, false)
+}
+
+// logOutputLines logs each line of the given output, if any.
+func logOutputLines(t testing.TestingT, l *logger.Logger, output string) {
+	if output == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		l.Logf(t, "%s", line)
+	}
+}
+
+// removeContainerWithAPIE implements 'docker container rm --force --volumes' with the Engine API.
+func removeContainerWithAPIE(id string) error {
+	cli, err := newAPIClientE()
+	if err != nil {
+		return err
+	}
+	return cli.ContainerRemove(context.Background(), id, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+}
@@ -0,0 +1,195 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithAPIBackend(t *testing.T) {
+	t.Parallel()
+
+	options := &RunOptions{
+		Command:              []string{"-c", `echo "Hello, $NAME!"`},
+		Entrypoint:           "sh",
+		EnvironmentVariables: []string{"NAME=World"},
+		Remove:               true,
+		Backend:              BackendAPI,
+	}
+	out := Run(t, "alpine:3.19", options)
+	assert.Equal(t, "Hello, World!", out)
+
+	options.Command = []string{"-c", "exit 3"}
+	_, err := RunE(t, "alpine:3.19", options)
+	var exitErr ContainerExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode)
+
+	id := RunAndGetID(t, dockerInspectTestImage, &RunOptions{Detach: true, Backend: BackendAPI})
+	defer removeContainer(t, id)
+	c, err := inspectE(t, BackendAPI, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, c.ID)
+	assert.True(t, c.Running)
+
+	Stop(t, []string{id}, &StopOptions{Time: 1, Backend: BackendAPI})
+	c, err = inspectE(t, BackendAPI, id)
+	require.NoError(t, err)
+	assert.False(t, c.Running)
+}
+
+func TestResolveBackend(t *testing.T) {
+	t.Setenv(BackendEnvVar, "")
+	assert.Equal(t, BackendCLI, resolveBackend(""))
+	assert.Equal(t, BackendAPI, resolveBackend(BackendAPI))
+
+	t.Setenv(BackendEnvVar, "API")
+	assert.Equal(t, BackendAPI, resolveBackend(""))
+	assert.Equal(t, BackendCLI, resolveBackend(BackendCLI))
+}
+
+func TestFormatAPIContainerConfig(t *testing.T) {
+	t.Parallel()
+
+	config, hostConfig, networkingConfig, err := formatAPIContainerConfig("alpine:3.19", &RunOptions{
+		Command:        []string{"-c", "true"},
+		Entrypoint:     "sh",
+		Init:           true,
+		Network:        "test-network",
+		NetworkAliases: []string{"db"},
+		Ports:          []string{"127.0.0.1:8080:80", "53/udp"},
+		Remove:         true,
+		Volumes:        []string{"/tmp:/data:ro", "/cache"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, strslice.StrSlice{"sh"}, config.Entrypoint)
+	assert.Equal(t, strslice.StrSlice{"-c", "true"}, config.Cmd)
+	assert.Equal(t, map[string]struct{}{"/cache": {}}, config.Volumes)
+	assert.Equal(t, []string{"/tmp:/data:ro"}, hostConfig.Binds)
+	assert.True(t, *hostConfig.Init)
+	// Containers run in the foreground are removed once their logs are read
+	assert.False(t, hostConfig.AutoRemove)
+
+	assert.Equal(t, nat.PortSet{"80/tcp": {}, "53/udp": {}}, config.ExposedPorts)
+	assert.Equal(t, nat.PortMap{
+		"80/tcp": {{HostIP: "127.0.0.1", HostPort: "8080"}},
+		"53/udp": {{}},
+	}, hostConfig.PortBindings)
+	assert.Equal(t, container.NetworkMode("test-network"), hostConfig.NetworkMode)
+	assert.Equal(t, []string{"db"}, networkingConfig.EndpointsConfig["test-network"].Aliases)
+}
+
+func TestReadAPIProgress(t *testing.T) {
+	t.Parallel()
+
+	out, err := readAPIProgressE(strings.NewReader(`{"stream":"Step 1/2 : FROM alpine\n"}
+{"status":"Pulling fs layer","id":"abc"}
+{"stream":"Successfully built 0123456789ab\n"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Step 1/2 : FROM alpine\nSuccessfully built 0123456789ab\n", out)
+
+	out, err = readAPIProgressE(strings.NewReader(`{"stream":"Step 1/2 : RUN exit 1\n"}
+{"errorDetail":{"code":1,"message":"The command '/bin/sh -c exit 1' returned a non-zero code: 1"},"error":"The command '/bin/sh -c exit 1' returned a non-zero code: 1"}`))
+	assert.EqualError(t, err, "The command '/bin/sh -c exit 1' returned a non-zero code: 1")
+	assert.Equal(t, "Step 1/2 : RUN exit 1\n", out)
+}
+
+func TestTarPath(t *testing.T) {
+	t.Parallel()
+
+	buildContext, err := tarPathE("../../test/fixtures/docker", "")
+	require.NoError(t, err)
+
+	reader := tar.NewReader(buildContext)
+	header, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "Dockerfile", header.Name)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "FROM alpine")
+}
+
+func TestUntarRenamesRootEntry(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "nested", "file.txt"), []byte("content"), 0600))
+
+	archive, err := tarPathE(srcDir, "app")
+	require.NoError(t, err)
+	destDir := t.TempDir()
+	require.NoError(t, untarE(archive, destDir, "app", "renamed"))
+
+	content, err := os.ReadFile(filepath.Join(destDir, "renamed", "nested", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	info, err := os.Stat(filepath.Join(destDir, "renamed", "nested", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Entries outside of the root entry are rejected
+	archive, err = tarPathE(srcDir, "other")
+	require.NoError(t, err)
+	assert.Error(t, untarE(archive, destDir, "app", "renamed"))
+}
+
+func TestUntarDoesNotWriteThroughSymlinks(t *testing.T) {
+	t.Parallel()
+
+	outsideDir := t.TempDir()
+	for _, entries := range [][]*tar.Header{
+		// A file written under a symbolic link to a directory outside of the destination
+		{
+			{Name: "app", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "app/escape", Typeflag: tar.TypeSymlink, Linkname: outsideDir},
+			{Name: "app/escape/file.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 7},
+		},
+		// A file written over a symbolic link to a file outside of the destination
+		{
+			{Name: "app", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "app/file.txt", Typeflag: tar.TypeSymlink, Linkname: filepath.Join(outsideDir, "file.txt")},
+			{Name: "app/file.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 7},
+		},
+	} {
+		var archive bytes.Buffer
+		writer := tar.NewWriter(&archive)
+		for _, header := range entries {
+			require.NoError(t, writer.WriteHeader(header))
+			if header.Typeflag == tar.TypeReg {
+				_, err := writer.Write([]byte("content"))
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, writer.Close())
+
+		_ = untarE(&archive, t.TempDir(), "app", "app")
+		assert.NoFileExists(t, filepath.Join(outsideDir, "file.txt"))
+	}
+}
+
+func TestNewAPIClientIsShared(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	cli, err := newAPIClientE()
+	require.NoError(t, err)
+	sameCli, err := newAPIClientE()
+	require.NoError(t, err)
+	assert.Same(t, cli, sameCli)
+
+	// Clients are not shared across engines
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2376")
+	otherCli, err := newAPIClientE()
+	require.NoError(t, err)
+	assert.NotSame(t, cli, otherCli)
+	assert.Equal(t, "tcp://127.0.0.1:2376", otherCli.DaemonHost())
+}
@@ -44,6 +44,15 @@ type BuildOptions struct {
 	// included in the Architectures list.
 	Load bool
 
+	// External cache sources to import layers from, e.g. type=registry,ref=my-registry/app:cache or
+	// type=local,src=/tmp/cache. See https://docs.docker.com/build/cache/backends/ for more info.
+	CacheFrom []string
+
+	// Cache destinations to export layers to, e.g. type=registry,ref=my-registry/app:cache,mode=max or
+	// type=local,dest=/tmp/cache, so that later builds can import them with CacheFrom. Most cache backends require a
+	// builder with the docker-container driver.
+	CacheTo []string
+
 	// Custom CLI options that will be passed as-is to the 'docker build' command. This is an "escape hatch" that allows
 	// Terratest to not have to support every single command-line option offered by the 'docker build' command, and
 	// solely focus on the most important ones.
@@ -55,6 +64,10 @@ type BuildOptions struct {
 	// Additional environment variables to pass in when running docker build command.
 	Env map[string]string
 
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
 	// Set a logger that should be used. See the logger package for more info.
 	Logger *logger.Logger
 }
@@ -69,26 +82,37 @@ func Build(t testing.TestingT, path string, options *BuildOptions) {
 func BuildE(t testing.TestingT, path string, options *BuildOptions) error {
 	options.Logger.Logf(t, "Running 'docker build' in %s", path)
 
-	env := make(map[string]string)
-	if options.Env != nil {
-		env = options.Env
-	}
+	if resolveBackend(options.Backend) == BackendAPI {
+		if err := buildWithAPIE(t, path, options); err != nil {
+			return err
+		}
+	} else {
+		env := make(map[string]string)
+		if options.Env != nil {
+			env = options.Env
+		}
 
-	if options.EnableBuildKit {
-		env["DOCKER_BUILDKIT"] = "1"
-	}
+		if options.EnableBuildKit {
+			env["DOCKER_BUILDKIT"] = "1"
+		}
 
-	cmd := shell.Command{
-		Command: "docker",
-		Args:    formatDockerBuildArgs(path, options),
-		Logger:  options.Logger,
-		Env:     env,
-	}
+		cmd := shell.Command{
+			Command: "docker",
+			Args:    formatDockerBuildArgs(path, options),
+			Logger:  options.Logger,
+			Env:     env,
+		}
 
-	if err := shell.RunCommandE(t, cmd); err != nil {
-		return err
+		if err := shell.RunCommandE(t, cmd); err != nil {
+			return err
+		}
 	}
 
+	return pushOrLoadBuiltImageE(t, path, options)
+}
+
+// pushOrLoadBuiltImageE pushes or loads the image built with the given options, if requested.
+func pushOrLoadBuiltImageE(t testing.TestingT, path string, options *BuildOptions) error {
 	// For non multiarch images, we need to call docker push for each tag since build does not have a push option like
 	// buildx.
 	if len(options.Architectures) == 0 && options.Push {
@@ -215,6 +239,14 @@ func formatDockerBuildBaseArgs(path string, options *BuildOptions) []string {
 		args = append(args, "--target", options.Target)
 	}
 
+	for _, cacheFrom := range options.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+
+	for _, cacheTo := range options.CacheTo {
+		args = append(args, "--cache-to", cacheTo)
+	}
+
 	args = append(args, options.OtherOptions...)
 
 	args = append(args, path)
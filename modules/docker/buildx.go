@@ -0,0 +1,336 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// BuildMetadata describes the result of a build, as recorded by 'docker buildx' in its metadata file.
+type BuildMetadata struct {
+	// Digest of the image. For multi-platform images, this is the digest of the image index (manifest list).
+	Digest string
+
+	// Digest of the configuration of the image, which is the image ID for single-platform images
+	ConfigDigest string
+
+	// Media type of the image, e.g. application/vnd.oci.image.index.v1+json for multi-platform images
+	MediaType string
+
+	// Names the image was tagged or pushed as
+	ImageNames []string
+
+	// Digests of the image manifests of each platform, e.g. linux/amd64, excluding attestations. Only set for
+	// multi-platform images that were pushed, as they are read from the registry.
+	PlatformDigests map[string]string
+
+	// Provenance attestation of the build (SLSA predicate), if buildx recorded it
+	Provenance map[string]interface{}
+
+	// Reference of the build in buildx, e.g. to look it up with 'docker buildx history'
+	BuildRef string
+}
+
+// buildxMetadataOutput defines the content of the metadata file written by 'docker buildx build --metadata-file'.
+// Not all options are included here, only the ones that we might need
+type buildxMetadataOutput struct {
+	ContainerImageDigest       string                 `json:"containerimage.digest"`
+	ContainerImageConfigDigest string                 `json:"containerimage.config.digest"`
+	ImageName                  string                 `json:"image.name"`
+	BuildRef                   string                 `json:"buildx.build.ref"`
+	Provenance                 map[string]interface{} `json:"buildx.build.provenance"`
+	ContainerImageDescriptor   struct {
+		MediaType string `json:"mediaType"`
+	} `json:"containerimage.descriptor"`
+}
+
+// imageIndexOutput defines the content of an image index (manifest list), as returned by
+// 'docker buildx imagetools inspect --raw'.
+type imageIndexOutput struct {
+	Manifests []struct {
+		Digest   string
+		Platform struct {
+			Architecture string
+			OS           string
+			Variant      string
+		}
+		Annotations map[string]string
+	}
+}
+
+// BuildAndGetMetadata runs the 'docker buildx build' command at the given path with the given options and returns
+// the metadata of the built image. This method fails the test if there are any errors.
+func BuildAndGetMetadata(t testing.TestingT, path string, options *BuildOptions) *BuildMetadata {
+	metadata, err := BuildAndGetMetadataE(t, path, options)
+	require.NoError(t, err)
+	return metadata
+}
+
+// BuildAndGetMetadataE runs the 'docker buildx build' command at the given path with the given options and returns
+// the metadata of the built image, or any error. Single-platform images are loaded into the docker daemon. The build
+// always uses the docker buildx CLI, whatever the backend of the options.
+func BuildAndGetMetadataE(t testing.TestingT, path string, options *BuildOptions) (*BuildMetadata, error) {
+	options.Logger.Logf(t, "Running 'docker buildx build' in %s", path)
+
+	metadataDir, err := os.MkdirTemp("", "terratest-buildx-metadata")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(metadataDir)
+	metadataFile := filepath.Join(metadataDir, "metadata.json")
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    formatDockerBuildxMetadataArgs(path, options, metadataFile),
+		Logger:  options.Logger,
+		Env:     options.Env,
+	}
+	if err := shell.RunCommandE(t, cmd); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(metadataFile)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := parseBuildxMetadata(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(options.Architectures) > 0 && options.Push {
+		if metadata.PlatformDigests, err = getPlatformDigestsE(t, metadata, options.Logger); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := pushOrLoadBuiltImageE(t, path, options); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// formatDockerBuildxMetadataArgs formats the arguments for the 'docker buildx build' command, writing the metadata of
+// the build to the given file.
+func formatDockerBuildxMetadataArgs(path string, options *BuildOptions, metadataFile string) []string {
+	args := []string{"buildx", "build", "--metadata-file", metadataFile}
+
+	if len(options.Architectures) > 0 {
+		args = append(args, "--platform", strings.Join(options.Architectures, ","))
+		if options.Push {
+			args = append(args, "--push")
+		}
+	} else {
+		// Unlike 'docker build', buildx does not load the image into the daemon with every builder
+		args = append(args, "--load")
+	}
+
+	return append(args, formatDockerBuildBaseArgs(path, options)...)
+}
+
+// parseBuildxMetadata converts the metadata of a build, as written by buildx, into a more friendly and testable format.
+func parseBuildxMetadata(content []byte) (*BuildMetadata, error) {
+	var output buildxMetadataOutput
+	if err := json.Unmarshal(content, &output); err != nil {
+		return nil, err
+	}
+	return transformBuildxMetadata(output), nil
+}
+
+// transformBuildxMetadata converts the metadata of a build into a more friendly and testable format.
+func transformBuildxMetadata(output buildxMetadataOutput) *BuildMetadata {
+	imageNames := []string{}
+	for _, name := range strings.Split(output.ImageName, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			imageNames = append(imageNames, name)
+		}
+	}
+
+	return &BuildMetadata{
+		Digest:          output.ContainerImageDigest,
+		ConfigDigest:    output.ContainerImageConfigDigest,
+		MediaType:       output.ContainerImageDescriptor.MediaType,
+		ImageNames:      imageNames,
+		PlatformDigests: map[string]string{},
+		Provenance:      output.Provenance,
+		BuildRef:        output.BuildRef,
+	}
+}
+
+// getPlatformDigestsE reads the image index of the given pushed image from the registry, and returns the digests of
+// the image manifests by platform.
+func getPlatformDigestsE(t testing.TestingT, metadata *BuildMetadata, logger *logger.Logger) (map[string]string, error) {
+	if len(metadata.ImageNames) == 0 || metadata.Digest == "" {
+		return map[string]string{}, nil
+	}
+
+	// Reference the image by digest, in case the tag was pushed again since
+	repository := metadata.ImageNames[0]
+	if separator := strings.LastIndex(repository, ":"); separator > strings.LastIndex(repository, "/") {
+		repository = repository[:separator]
+	}
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"buildx", "imagetools", "inspect", "--raw", repository + "@" + metadata.Digest},
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlatformDigests([]byte(out))
+}
+
+// parsePlatformDigests returns the digests of the image manifests of the given image index by platform, skipping the
+// attestation manifests.
+func parsePlatformDigests(content []byte) (map[string]string, error) {
+	var index imageIndexOutput
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, err
+	}
+
+	digests := map[string]string{}
+	for _, manifest := range index.Manifests {
+		if manifest.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+			continue
+		}
+		platform := manifest.Platform.OS + "/" + manifest.Platform.Architecture
+		if manifest.Platform.Variant != "" {
+			platform += "/" + manifest.Platform.Variant
+		}
+		digests[platform] = manifest.Digest
+	}
+	return digests, nil
+}
+
+// BakeOptions defines options that can be passed to the 'docker buildx bake' command.
+type BakeOptions struct {
+	// Bake files to read the targets from. Defaults to the files buildx looks for, such as docker-bake.hcl and
+	// docker-compose.yml, in the working directory.
+	Files []string
+
+	// Targets or groups of targets to build. Defaults to the default group.
+	Targets []string
+
+	// Overrides of the target definitions, e.g. *.platform=linux/amd64 or app.args.VERSION=1.0
+	Set []string
+
+	// Cache sources and destinations of all the targets. See BuildOptions for more info.
+	CacheFrom []string
+	CacheTo   []string
+
+	// Whether or not to push the images to the registry, or load them into the docker daemon
+	Push bool
+	Load bool
+
+	// Custom CLI options that will be passed as-is to the 'docker buildx bake' command
+	OtherOptions []string
+
+	// Directory to run bake in, which the paths of the bake files are relative to
+	WorkingDir string
+
+	// Additional environment variables to pass in when running the bake command, e.g. to set the variables of the bake
+	// files.
+	Env map[string]string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// Bake runs the 'docker buildx bake' command with the given options and returns the metadata of the built images, by
+// target. This method fails the test if there are any errors.
+func Bake(t testing.TestingT, options *BakeOptions) map[string]*BuildMetadata {
+	metadata, err := BakeE(t, options)
+	require.NoError(t, err)
+	return metadata
+}
+
+// BakeE runs the 'docker buildx bake' command with the given options and returns the metadata of the built images, by
+// target, or any error.
+func BakeE(t testing.TestingT, options *BakeOptions) (map[string]*BuildMetadata, error) {
+	options.Logger.Logf(t, "Running 'docker buildx bake' on targets %v", options.Targets)
+
+	metadataDir, err := os.MkdirTemp("", "terratest-buildx-metadata")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(metadataDir)
+	metadataFile := filepath.Join(metadataDir, "metadata.json")
+
+	cmd := shell.Command{
+		Command:    "docker",
+		Args:       formatDockerBakeArgs(options, metadataFile),
+		WorkingDir: options.WorkingDir,
+		Env:        options.Env,
+		Logger:     options.Logger,
+	}
+	if err := shell.RunCommandE(t, cmd); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(metadataFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseBakeMetadata(content)
+}
+
+// formatDockerBakeArgs formats the arguments for the 'docker buildx bake' command, writing the metadata of the builds
+// to the given file.
+func formatDockerBakeArgs(options *BakeOptions, metadataFile string) []string {
+	args := []string{"buildx", "bake", "--metadata-file", metadataFile}
+
+	for _, file := range options.Files {
+		args = append(args, "--file", file)
+	}
+
+	for _, set := range options.Set {
+		args = append(args, "--set", set)
+	}
+
+	for _, cacheFrom := range options.CacheFrom {
+		args = append(args, "--set", "*.cache-from="+cacheFrom)
+	}
+
+	for _, cacheTo := range options.CacheTo {
+		args = append(args, "--set", "*.cache-to="+cacheTo)
+	}
+
+	if options.Push {
+		args = append(args, "--push")
+	}
+
+	if options.Load {
+		args = append(args, "--load")
+	}
+
+	args = append(args, options.OtherOptions...)
+
+	return append(args, options.Targets...)
+}
+
+// parseBakeMetadata converts the metadata of a bake, which holds the metadata of the build of each target, into a more
+// friendly and testable format. Entries that are not targets, such as the build ref of the bake, are skipped.
+func parseBakeMetadata(content []byte) (map[string]*BuildMetadata, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]*BuildMetadata{}
+	for target, entry := range entries {
+		var output buildxMetadataOutput
+		if err := json.Unmarshal(entry, &output); err != nil {
+			// Not the metadata of a target
+			continue
+		}
+		metadata[target] = transformBuildxMetadata(output)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAndGetMetadata(t *testing.T) {
+	t.Parallel()
+
+	tag := fmt.Sprintf("gruntwork-io/test-image-metadata:%s", strings.ToLower(random.UniqueId()))
+	text := "Hello, World!"
+
+	metadata := BuildAndGetMetadata(t, "../../test/fixtures/docker", &BuildOptions{
+		Tags:      []string{tag},
+		BuildArgs: []string{fmt.Sprintf("text=%s", text)},
+		CacheTo:   []string{"type=inline"},
+	})
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", metadata.Digest)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", metadata.ConfigDigest)
+	assert.Contains(t, metadata.ImageNames[0], tag)
+
+	out := Run(t, tag, &RunOptions{Remove: true})
+	assert.Contains(t, out, text)
+}
+
+func TestBake(t *testing.T) {
+	t.Parallel()
+
+	tag := strings.ToLower(random.UniqueId())
+	metadata := Bake(t, &BakeOptions{
+		WorkingDir: "../../test/fixtures/docker-bake",
+		Env:        map[string]string{"TAG": tag},
+		Load:       true,
+	})
+	require.Len(t, metadata, 2)
+	assert.Contains(t, metadata["hello"].ImageNames[0], "gruntwork-io/test-bake-hello:"+tag)
+
+	out := Run(t, "gruntwork-io/test-bake-step1:"+tag, &RunOptions{Remove: true})
+	assert.Contains(t, out, "Hello from bake step 1!")
+}
+
+func TestParseBuildxMetadata(t *testing.T) {
+	t.Parallel()
+
+	metadata, err := parseBuildxMetadata([]byte(`{
+  "buildx.build.provenance": {"buildType": "https://mobyproject.org/buildkit@v1"},
+  "buildx.build.ref": "default/default/abc",
+  "containerimage.config.digest": "sha256:1111",
+  "containerimage.descriptor": {"mediaType": "application/vnd.oci.image.index.v1+json", "digest": "sha256:2222", "size": 856},
+  "containerimage.digest": "sha256:2222",
+  "image.name": "my-registry/app:v1,my-registry/app:latest"
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, &BuildMetadata{
+		Digest:          "sha256:2222",
+		ConfigDigest:    "sha256:1111",
+		MediaType:       "application/vnd.oci.image.index.v1+json",
+		ImageNames:      []string{"my-registry/app:v1", "my-registry/app:latest"},
+		PlatformDigests: map[string]string{},
+		Provenance:      map[string]interface{}{"buildType": "https://mobyproject.org/buildkit@v1"},
+		BuildRef:        "default/default/abc",
+	}, metadata)
+}
+
+func TestParsePlatformDigests(t *testing.T) {
+	t.Parallel()
+
+	digests, err := parsePlatformDigests([]byte(`{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"digest": "sha256:amd64", "platform": {"architecture": "amd64", "os": "linux"}},
+    {"digest": "sha256:arm64", "platform": {"architecture": "arm64", "os": "linux", "variant": "v8"}},
+    {"digest": "sha256:attestation", "platform": {"architecture": "unknown", "os": "unknown"},
+     "annotations": {"vnd.docker.reference.digest": "sha256:amd64", "vnd.docker.reference.type": "attestation-manifest"}}
+  ]
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"linux/amd64": "sha256:amd64", "linux/arm64/v8": "sha256:arm64"}, digests)
+}
+
+func TestParseBakeMetadata(t *testing.T) {
+	t.Parallel()
+
+	metadata, err := parseBakeMetadata([]byte(`{
+  "app": {"containerimage.digest": "sha256:1111", "image.name": "app:v1"},
+  "buildx.build.warnings": [{"vertex": "sha256:3333", "level": 1}]
+}`))
+	require.NoError(t, err)
+	require.Len(t, metadata, 1)
+	assert.Equal(t, "sha256:1111", metadata["app"].Digest)
+	assert.Equal(t, []string{"app:v1"}, metadata["app"].ImageNames)
+}
+
+func TestFormatDockerBakeArgs(t *testing.T) {
+	t.Parallel()
+
+	args := formatDockerBakeArgs(&BakeOptions{
+		Files:     []string{"docker-bake.hcl"},
+		Targets:   []string{"app"},
+		Set:       []string{"*.platform=linux/amd64"},
+		CacheFrom: []string{"type=local,src=/tmp/cache"},
+		CacheTo:   []string{"type=local,dest=/tmp/cache"},
+		Push:      true,
+	}, "metadata.json")
+	assert.Equal(t, []string{
+		"buildx", "bake", "--metadata-file", "metadata.json",
+		"--file", "docker-bake.hcl",
+		"--set", "*.platform=linux/amd64",
+		"--set", "*.cache-from=type=local,src=/tmp/cache",
+		"--set", "*.cache-to=type=local,dest=/tmp/cache",
+		"--push",
+		"app",
+	}, args)
+}
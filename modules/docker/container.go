@@ -0,0 +1,324 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	defaultContainerWaitTimeout  = time.Minute
+	defaultContainerWaitInterval = time.Second
+)
+
+// ContainerOptions defines the options to run a container fixture with RunContainer.
+type ContainerOptions struct {
+	// Options of the 'docker run' command. The container is always run in the background. Ports published without a
+	// host port, e.g. 80 or 53/udp, are mapped to random host ports: use Container.GetHostPort to get them.
+	RunOptions
+
+	// Strategies to wait on, in order, before the container is considered ready
+	WaitFor []WaitStrategy
+
+	// How long to wait for each strategy, and how long to sleep between checks. Default to 1 minute and 1 second.
+	WaitTimeout  time.Duration
+	WaitInterval time.Duration
+}
+
+// Container represents a running container fixture.
+type Container struct {
+	// ID of the container
+	ID string
+
+	// Image the container was created from
+	Image string
+
+	// Ports published by the container, as returned by 'docker inspect' once the container started
+	Ports []Port
+
+	logger  *logger.Logger
+	backend Backend
+}
+
+// GetHostPort returns the host port to which the given container port is published. Returns 0 if the port is not
+// published.
+func (container *Container) GetHostPort(containerPort uint16) uint16 {
+	return ContainerInspect{Ports: container.Ports}.GetExposedHostPort(containerPort)
+}
+
+// GetAddress returns the host:port address at which the given container port can be reached from the host running the
+// tests, using GetDockerHost as the host.
+func (container *Container) GetAddress(containerPort uint16) string {
+	return net.JoinHostPort(GetDockerHost(), strconv.Itoa(int(container.GetHostPort(containerPort))))
+}
+
+// Remove stops and removes the container, along with its anonymous volumes. This will fail the test if there is an
+// error.
+func (container *Container) Remove(t testing.TestingT) {
+	require.NoError(t, container.RemoveE(t))
+}
+
+// RemoveE stops and removes the container, along with its anonymous volumes.
+func (container *Container) RemoveE(t testing.TestingT) error {
+	if resolveBackend(container.backend) == BackendAPI {
+		return removeContainerWithAPIE(container.ID)
+	}
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"container", "rm", "--force", "--volumes", container.ID},
+		Logger:  container.logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// WaitStrategy is a condition to wait on before a container fixture is considered ready.
+type WaitStrategy interface {
+	// CheckReadyE returns nil if the container is ready, or an error explaining why it is not ready yet.
+	CheckReadyE(t testing.TestingT, container *Container) error
+
+	// String describes what the strategy waits for.
+	String() string
+}
+
+// RunContainer runs a container fixture from the given image in the background, publishes the requested ports and
+// waits until all the wait strategies are satisfied. If the test supports it (as *testing.T does), the container is
+// removed when the test completes; otherwise, the caller must call Container.Remove. This will fail the test if there
+// is an error.
+func RunContainer(t testing.TestingT, image string, options *ContainerOptions) *Container {
+	container, err := RunContainerE(t, image, options)
+	require.NoError(t, err)
+	return container
+}
+
+// RunContainerE runs a container fixture from the given image in the background, publishes the requested ports and
+// waits until all the wait strategies are satisfied. If the test supports it (as *testing.T does), the container is
+// removed when the test completes; otherwise, the caller must call Container.Remove. If the container is not ready in
+// time, its logs are printed and the error is returned.
+func RunContainerE(t testing.TestingT, image string, options *ContainerOptions) (*Container, error) {
+	runOptions := options.RunOptions
+	runOptions.Detach = true
+
+	id, err := RunAndGetIDE(t, image, &runOptions)
+	if err != nil {
+		return nil, err
+	}
+	container := &Container{ID: strings.TrimSpace(id), Image: image, logger: options.Logger, backend: options.Backend}
+	cleaner, registerCleanup := t.(interface{ Cleanup(func()) })
+	if registerCleanup {
+		cleaner.Cleanup(func() {
+			if err := container.RemoveE(t); err != nil {
+				options.Logger.Logf(t, "Failed to remove container %s: %v", container.ID, err)
+			}
+		})
+	}
+
+	if err := waitUntilContainerReadyE(t, container, options); err != nil {
+		if logs, logsErr := LogsE(t, container.ID, &LogsOptions{Backend: container.backend, Logger: logger.Discard}); logsErr == nil {
+			options.Logger.Logf(t, "Container %s of image %s is not ready, logs:\n%s", container.ID, image, logs)
+		}
+		if !registerCleanup {
+			if removeErr := container.RemoveE(t); removeErr != nil {
+				options.Logger.Logf(t, "Failed to remove container %s: %v", container.ID, removeErr)
+			}
+		}
+		return nil, err
+	}
+
+	return container, nil
+}
+
+// waitUntilContainerReadyE records the ports published by the container, and then waits on each of the wait strategies
+// of the options in turn. It stops early if the container exits.
+func waitUntilContainerReadyE(t testing.TestingT, container *Container, options *ContainerOptions) error {
+	timeout := options.WaitTimeout
+	if timeout <= 0 {
+		timeout = defaultContainerWaitTimeout
+	}
+	interval := options.WaitInterval
+	if interval <= 0 {
+		interval = defaultContainerWaitInterval
+	}
+	maxRetries := int(timeout / interval)
+
+	// Port mappings are known as soon as the container started, and the strategies may need them
+	inspect, err := inspectE(t, container.backend, container.ID)
+	if err != nil {
+		return err
+	}
+	container.Ports = inspect.Ports
+
+	for _, strategy := range options.WaitFor {
+		description := fmt.Sprintf("Wait for container %s to be ready: %s", container.ID, strategy)
+		_, err := retry.DoWithRetryE(t, description, maxRetries, interval, func() (string, error) {
+			inspect, err := inspectE(t, container.backend, container.ID)
+			if err != nil {
+				return "", err
+			}
+			if !inspect.Running {
+				return "", retry.FatalError{Underlying: ContainerNotRunningError{ID: container.ID, Status: inspect.Status, ExitCode: inspect.ExitCode}}
+			}
+			return "", strategy.CheckReadyE(t, container)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForLog returns a strategy that waits until the logs of the container match the given regular expression.
+func WaitForLog(pattern string) WaitStrategy {
+	return logWaitStrategy{pattern: regexp.MustCompile(pattern)}
+}
+
+type logWaitStrategy struct {
+	pattern *regexp.Regexp
+}
+
+func (strategy logWaitStrategy) CheckReadyE(t testing.TestingT, container *Container) error {
+	logs, err := LogsE(t, container.ID, &LogsOptions{Backend: container.backend, Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	if !strategy.pattern.MatchString(logs) {
+		return fmt.Errorf("logs do not match %q yet", strategy.pattern)
+	}
+	return nil
+}
+
+func (strategy logWaitStrategy) String() string {
+	return fmt.Sprintf("logs matching %q", strategy.pattern)
+}
+
+// WaitForHealthy returns a strategy that waits until the healthcheck of the container reports it as healthy.
+func WaitForHealthy() WaitStrategy {
+	return healthyWaitStrategy{}
+}
+
+type healthyWaitStrategy struct{}
+
+func (healthyWaitStrategy) CheckReadyE(t testing.TestingT, container *Container) error {
+	inspect, err := inspectE(t, container.backend, container.ID)
+	if err != nil {
+		return err
+	}
+	switch inspect.Health.Status {
+	case "healthy":
+		return nil
+	case "":
+		return retry.FatalError{Underlying: fmt.Errorf("container %s has no healthcheck", container.ID)}
+	default:
+		return fmt.Errorf("container is %s", inspect.Health.Status)
+	}
+}
+
+func (healthyWaitStrategy) String() string {
+	return "healthy status"
+}
+
+// WaitForPort returns a strategy that waits until the given published TCP port of the container accepts connections.
+// As the docker userland proxy accepts connections on published ports before the process in the container listens,
+// a connection only counts if it is not closed right away.
+func WaitForPort(containerPort uint16) WaitStrategy {
+	return portWaitStrategy{containerPort: containerPort}
+}
+
+type portWaitStrategy struct {
+	containerPort uint16
+}
+
+func (strategy portWaitStrategy) CheckReadyE(t testing.TestingT, container *Container) error {
+	if container.GetHostPort(strategy.containerPort) == 0 {
+		return retry.FatalError{Underlying: fmt.Errorf("port %d of container %s is not published", strategy.containerPort, container.ID)}
+	}
+	conn, err := net.DialTimeout("tcp", container.GetAddress(strategy.containerPort), 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return checkConnectionOpenE(conn, portWaitReadTimeout)
+}
+
+// How long a connection to a published port must stay open, without the server sending anything, to count as ready
+const portWaitReadTimeout = 500 * time.Millisecond
+
+// checkConnectionOpenE returns an error if the given connection is closed by the other end within the given timeout.
+// This is the case of connections accepted by the docker userland proxy while nothing listens in the container.
+func checkConnectionOpenE(conn net.Conn, timeout time.Duration) error {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	_, err := conn.Read(make([]byte, 1))
+	var netErr net.Error
+	if err == nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+		// The server either spoke first or waits for the client to do so: either way, it is listening
+		return nil
+	}
+	return fmt.Errorf("connection to %s was closed: %w", conn.RemoteAddr(), err)
+}
+
+func (strategy portWaitStrategy) String() string {
+	return fmt.Sprintf("port %d listening", strategy.containerPort)
+}
+
+// WaitForHTTP returns a strategy that waits until an HTTP GET on the given path of the given published port of the
+// container returns a 200 OK.
+func WaitForHTTP(containerPort uint16, path string) WaitStrategy {
+	return httpWaitStrategy{containerPort: containerPort, path: path}
+}
+
+type httpWaitStrategy struct {
+	containerPort uint16
+	path          string
+}
+
+func (strategy httpWaitStrategy) CheckReadyE(t testing.TestingT, container *Container) error {
+	if container.GetHostPort(strategy.containerPort) == 0 {
+		return retry.FatalError{Underlying: fmt.Errorf("port %d of container %s is not published", strategy.containerPort, container.ID)}
+	}
+	client := http.Client{Timeout: 5 * time.Second}
+	url := fmt.Sprintf("http://%s/%s", container.GetAddress(strategy.containerPort), strings.TrimPrefix(strategy.path, "/"))
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (strategy httpWaitStrategy) String() string {
+	return fmt.Sprintf("HTTP 200 on port %d at path %s", strategy.containerPort, strategy.path)
+}
+
+// WaitForExec returns a strategy that waits until the given command, run in the container with 'docker exec', exits
+// with code 0.
+func WaitForExec(command ...string) WaitStrategy {
+	return execWaitStrategy{command: command}
+}
+
+type execWaitStrategy struct {
+	command []string
+}
+
+func (strategy execWaitStrategy) CheckReadyE(t testing.TestingT, container *Container) error {
+	_, err := ExecE(t, container.ID, strategy.command, &ExecOptions{Backend: container.backend, Logger: logger.Discard})
+	return err
+}
+
+func (strategy execWaitStrategy) String() string {
+	return fmt.Sprintf("command %v succeeding", strategy.command)
+}
@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunContainerWaitsUntilReady(t *testing.T) {
+	t.Parallel()
+
+	container := RunContainer(t, dockerInspectTestImage, &ContainerOptions{
+		RunOptions: RunOptions{
+			OtherOptions: []string{"--health-cmd=wget -q -O /dev/null http://localhost/", "--health-interval=1s"},
+			Ports:        []string{"80"},
+		},
+		WaitFor: []WaitStrategy{
+			WaitForLog("start worker process"),
+			WaitForPort(80),
+			WaitForHTTP(80, "/index.html"),
+			WaitForExec("test", "-f", "/etc/nginx/nginx.conf"),
+			WaitForHealthy(),
+		},
+		WaitTimeout: 30 * time.Second,
+	})
+
+	hostPort := container.GetHostPort(80)
+	require.NotZero(t, hostPort)
+	assert.Equal(t, fmt.Sprintf("%s:%d", GetDockerHost(), hostPort), container.GetAddress(80))
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/", container.GetAddress(80)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRunContainerFailsFastWhenContainerExits(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	_, err := RunContainerE(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{Command: []string{"sh", "-c", "echo failed to start; exit 3"}},
+		WaitFor:    []WaitStrategy{WaitForLog("started")},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit code 3")
+	assert.Less(t, time.Since(start), defaultContainerWaitTimeout)
+}
+
+func TestCheckConnectionOpen(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// Emulate the userland proxy of docker, which closes the connections when nothing listens in the container
+			if i == 0 {
+				conn.Close()
+				continue
+			}
+			defer conn.Close()
+		}
+	}()
+
+	closedConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer closedConn.Close()
+	assert.Error(t, checkConnectionOpenE(closedConn, 5*time.Second))
+
+	openConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer openConn.Close()
+	assert.NoError(t, checkConnectionOpenE(openConn, 100*time.Millisecond))
+}
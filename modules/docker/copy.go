@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// CopyOptions defines options that can be passed to the 'docker cp' command.
+type CopyOptions struct {
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// CopyToContainer runs the 'docker cp' command to copy the file or directory at srcPath on the host to destPath in the
+// given container. This method fails the test if there are any errors.
+func CopyToContainer(t testing.TestingT, container string, srcPath string, destPath string, options *CopyOptions) {
+	require.NoError(t, CopyToContainerE(t, container, srcPath, destPath, options))
+}
+
+// CopyToContainerE runs the 'docker cp' command to copy the file or directory at srcPath on the host to destPath in the
+// given container. As with 'docker cp', if destPath is an existing directory, the file or directory is copied into it;
+// otherwise, it is copied to destPath, whose parent directory must exist.
+func CopyToContainerE(t testing.TestingT, container string, srcPath string, destPath string, options *CopyOptions) error {
+	options.Logger.Logf(t, "Copying %s to %s in container '%s'", srcPath, destPath, container)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return copyToContainerWithAPIE(container, srcPath, destPath)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"cp", srcPath, container + ":" + destPath},
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// CopyFromContainer runs the 'docker cp' command to copy the file or directory at srcPath in the given container to
+// destPath on the host. This method fails the test if there are any errors.
+func CopyFromContainer(t testing.TestingT, container string, srcPath string, destPath string, options *CopyOptions) {
+	require.NoError(t, CopyFromContainerE(t, container, srcPath, destPath, options))
+}
+
+// CopyFromContainerE runs the 'docker cp' command to copy the file or directory at srcPath in the given container to
+// destPath on the host. As with 'docker cp', if destPath is an existing directory, the file or directory is copied
+// into it; otherwise, it is copied to destPath, whose parent directory must exist.
+func CopyFromContainerE(t testing.TestingT, container string, srcPath string, destPath string, options *CopyOptions) error {
+	options.Logger.Logf(t, "Copying %s in container '%s' to %s", srcPath, container, destPath)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return copyFromContainerWithAPIE(container, srcPath, destPath)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"cp", container + ":" + srcPath, destPath},
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
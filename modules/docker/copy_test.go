@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyToAndFromContainer(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range []Backend{BackendCLI, BackendAPI} {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			t.Parallel()
+
+			id := RunAndGetID(t, "alpine:3.19", &RunOptions{Command: []string{"sleep", "60"}, Detach: true})
+			defer removeContainer(t, id)
+			options := &CopyOptions{Backend: backend}
+
+			srcDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(srcDir, "config.txt"), []byte("key=value\n"), 0644))
+
+			// Copy a file to a new path, and a directory into an existing directory
+			CopyToContainer(t, id, filepath.Join(srcDir, "config.txt"), "/tmp/renamed.txt", options)
+			CopyToContainer(t, id, srcDir, "/opt", options)
+			out := Exec(t, id, []string{"cat", "/tmp/renamed.txt", filepath.Join("/opt", filepath.Base(srcDir), "config.txt")}, &ExecOptions{})
+			assert.Equal(t, "key=value\nkey=value", out.Stdout)
+
+			// Copy a file into an existing directory, and a directory to a new path
+			destDir := t.TempDir()
+			CopyFromContainer(t, id, "/etc/alpine-release", destDir, options)
+			CopyFromContainer(t, id, "/opt/"+filepath.Base(srcDir), filepath.Join(destDir, "copied"), options)
+			release, err := os.ReadFile(filepath.Join(destDir, "alpine-release"))
+			require.NoError(t, err)
+			assert.Regexp(t, `^3\.19\.`, string(release))
+			config, err := os.ReadFile(filepath.Join(destDir, "copied", "config.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "key=value\n", string(config))
+		})
+	}
+}
@@ -46,8 +46,18 @@ func RunDockerComposeE(t testing.TestingT, options *Options, args ...string) (st
 }
 
 func runDockerComposeE(t testing.TestingT, stdout bool, options *Options, args ...string) (string, error) {
-	var cmd shell.Command
+	cmd := formatDockerComposeCommand(t, options, args...)
 
+	if stdout {
+		return shell.RunCommandAndGetStdOut(t, cmd), nil
+	}
+
+	return shell.RunCommandAndGetOutputE(t, cmd)
+}
+
+// formatDockerComposeCommand returns the command to run docker compose with the given arguments and options, using
+// the 'docker compose' plugin if available, or the standalone docker-compose binary otherwise.
+func formatDockerComposeCommand(t testing.TestingT, options *Options, args ...string) shell.Command {
 	projectName := options.ProjectName
 	if len(projectName) <= 0 {
 		projectName = strings.ToLower(t.Name())
@@ -66,30 +76,24 @@ func runDockerComposeE(t testing.TestingT, stdout bool, options *Options, args .
 	}
 
 	if result.ExitCode == 0 {
-		cmd = shell.Command{
+		return shell.Command{
 			Command:    "docker",
 			Args:       append([]string{"compose", "--project-name", generateValidDockerComposeProjectName(projectName)}, args...),
 			WorkingDir: options.WorkingDir,
 			Env:        options.EnvVars,
 			Logger:     options.Logger,
 		}
-	} else {
-		cmd = shell.Command{
-			Command: "docker-compose",
-			// We append --project-name to ensure containers from multiple different tests using Docker Compose don't end
-			// up in the same project and end up conflicting with each other.
-			Args:       append([]string{"--project-name", generateValidDockerComposeProjectName(projectName)}, args...),
-			WorkingDir: options.WorkingDir,
-			Env:        options.EnvVars,
-			Logger:     options.Logger,
-		}
 	}
 
-	if stdout {
-		return shell.RunCommandAndGetStdOut(t, cmd), nil
+	return shell.Command{
+		Command: "docker-compose",
+		// We append --project-name to ensure containers from multiple different tests using Docker Compose don't end
+		// up in the same project and end up conflicting with each other.
+		Args:       append([]string{"--project-name", generateValidDockerComposeProjectName(projectName)}, args...),
+		WorkingDir: options.WorkingDir,
+		Env:        options.EnvVars,
+		Logger:     options.Logger,
 	}
-
-	return shell.RunCommandAndGetOutputE(t, cmd)
 }
 
 // Note: docker-compose command doesn't like lower case or special characters, other than -.
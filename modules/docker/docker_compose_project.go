@@ -0,0 +1,258 @@
+package docker
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ComposeContainer represents a container of a docker compose project, as listed by 'docker compose ps'
+type ComposeContainer struct {
+	// ID and name of the container
+	ID   string
+	Name string
+
+	// Service the container belongs to
+	Service string
+
+	// Image the container was created from
+	Image string
+
+	// State of the container, e.g. running or exited
+	State string
+
+	// Health of the container, e.g. healthy, unhealthy or starting. Empty if the service has no healthcheck.
+	Health string
+
+	// Exit code of the container, if it exited
+	ExitCode int
+
+	// Ports published by the container
+	Ports []ComposePublishedPort
+}
+
+// ComposePublishedPort represents a port published by a container of a docker compose project
+type ComposePublishedPort struct {
+	// Host address the port is published on, e.g. 0.0.0.0
+	URL string
+
+	TargetPort    uint16
+	PublishedPort uint16
+	Protocol      string
+}
+
+// GetPublishedPort returns the host port to which the given container port is published. Returns 0 if the port is not
+// published.
+func (container ComposeContainer) GetPublishedPort(targetPort uint16) uint16 {
+	for _, port := range container.Ports {
+		if port.TargetPort == targetPort {
+			return port.PublishedPort
+		}
+	}
+	return 0
+}
+
+// composePsOutput defines a container returned by 'docker compose ps --format json'.
+// Not all options are included here, only the ones that we might need
+type composePsOutput struct {
+	ID         string
+	Name       string
+	Service    string
+	Image      string
+	State      string
+	Health     string
+	ExitCode   int
+	Publishers []ComposePublishedPort
+}
+
+// ComposeUp runs 'docker compose up --detach --wait' to start the given services of the project, or all of them if
+// none is given, and waits for them to be running and healthy. This method fails the test if there are any errors.
+func ComposeUp(t testing.TestingT, options *Options, services ...string) string {
+	out, err := ComposeUpE(t, options, services...)
+	require.NoError(t, err)
+	return out
+}
+
+// ComposeUpE runs 'docker compose up --detach --wait' to start the given services of the project, or all of them if
+// none is given, and waits for them to be running and healthy. It returns the output of docker compose, or any error.
+// This requires Docker Compose v2.1.1 or later.
+func ComposeUpE(t testing.TestingT, options *Options, services ...string) (string, error) {
+	args := append([]string{"up", "--detach", "--wait"}, services...)
+	return RunDockerComposeE(t, options, args...)
+}
+
+// ComposeDown runs 'docker compose down --volumes' to stop and remove the containers, networks and volumes of the
+// project. This method fails the test if there are any errors.
+func ComposeDown(t testing.TestingT, options *Options) string {
+	out, err := ComposeDownE(t, options)
+	require.NoError(t, err)
+	return out
+}
+
+// ComposeDownE runs 'docker compose down --volumes' to stop and remove the containers, networks and volumes of the
+// project, including the containers of services that are no longer defined. It returns the output of docker compose,
+// or any error.
+func ComposeDownE(t testing.TestingT, options *Options) (string, error) {
+	return RunDockerComposeE(t, options, "down", "--volumes", "--remove-orphans")
+}
+
+// ComposePs runs 'docker compose ps' and returns the containers of the project, including the stopped ones. This
+// method fails the test if there are any errors.
+func ComposePs(t testing.TestingT, options *Options) []ComposeContainer {
+	containers, err := ComposePsE(t, options)
+	require.NoError(t, err)
+	return containers
+}
+
+// ComposePsE runs 'docker compose ps' and returns the containers of the project, including the stopped ones, or any
+// error. This requires Docker Compose v2.
+func ComposePsE(t testing.TestingT, options *Options) ([]ComposeContainer, error) {
+	cmd := formatDockerComposeCommand(t, options, "ps", "--all", "--format", "json")
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseComposePsOutput(out)
+}
+
+// parseComposePsOutput converts the output of 'docker compose ps --format json', which is a JSON array up to Docker
+// Compose v2.21 and one JSON object per line since, into containers.
+func parseComposePsOutput(out string) ([]ComposeContainer, error) {
+	outputs := []composePsOutput{}
+	out = strings.TrimSpace(out)
+	if strings.HasPrefix(out, "[") {
+		if err := json.Unmarshal([]byte(out), &outputs); err != nil {
+			return nil, err
+		}
+	} else {
+		scanner := bufio.NewScanner(strings.NewReader(out))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var output composePsOutput
+			if err := json.Unmarshal([]byte(line), &output); err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, output)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	containers := []ComposeContainer{}
+	for _, output := range outputs {
+		container := ComposeContainer{
+			ID:       output.ID,
+			Name:     output.Name,
+			Service:  output.Service,
+			Image:    output.Image,
+			State:    output.State,
+			Health:   output.Health,
+			ExitCode: output.ExitCode,
+			Ports:    []ComposePublishedPort{},
+		}
+		for _, port := range output.Publishers {
+			// Ports that are exposed but not published are listed too
+			if port.PublishedPort != 0 {
+				container.Ports = append(container.Ports, port)
+			}
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+// ComposePort runs 'docker compose port' and returns the host port to which the given port of the given service is
+// published. This method fails the test if there are any errors.
+func ComposePort(t testing.TestingT, options *Options, service string, port uint16) uint16 {
+	hostPort, err := ComposePortE(t, options, service, port)
+	require.NoError(t, err)
+	return hostPort
+}
+
+// ComposePortE runs 'docker compose port' and returns the host port to which the given TCP port of the given service is
+// published, or a ServicePortNotPublishedError if it is not published.
+func ComposePortE(t testing.TestingT, options *Options, service string, port uint16) (uint16, error) {
+	cmd := formatDockerComposeCommand(t, options, "port", service, strconv.Itoa(int(port)))
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		var errWithOutput *shell.ErrWithCmdOutput
+		if errors.As(err, &errWithOutput) && strings.Contains(errWithOutput.Output.Stderr(), "no port") {
+			return 0, ServicePortNotPublishedError{Service: service, Port: port}
+		}
+		return 0, err
+	}
+
+	// The port may be published on several addresses, e.g. IPv4 and IPv6, on the same host port
+	address := strings.TrimSpace(strings.Split(strings.TrimSpace(out), "\n")[0])
+	_, hostPort, err := net.SplitHostPort(address)
+	if err != nil || hostPort == "0" {
+		return 0, ServicePortNotPublishedError{Service: service, Port: port}
+	}
+	parsedPort, err := strconv.ParseUint(hostPort, 10, 16)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(parsedPort), nil
+}
+
+// ComposeLogs runs 'docker compose logs' and returns the logs of the given services, or of all the services if none is
+// given. This method fails the test if there are any errors.
+func ComposeLogs(t testing.TestingT, options *Options, services ...string) string {
+	out, err := ComposeLogsE(t, options, services...)
+	require.NoError(t, err)
+	return out
+}
+
+// ComposeLogsE runs 'docker compose logs' and returns the logs of the given services, or of all the services if none
+// is given, or any error. The lines are prefixed with the name of their container, unless a single service is given.
+func ComposeLogsE(t testing.TestingT, options *Options, services ...string) (string, error) {
+	args := []string{"logs", "--no-color"}
+	if len(services) == 1 {
+		args = append(args, "--no-log-prefix")
+	}
+	return RunDockerComposeE(t, options, append(args, services...)...)
+}
+
+// ComposeExec runs 'docker compose exec' to run the given command in the container of the given service, and returns
+// its stdout, stderr and exit code. This method fails the test if there are any errors, including if the command exits
+// with a non-zero code.
+func ComposeExec(t testing.TestingT, options *Options, service string, command ...string) *ExecOutput {
+	out, err := ComposeExecE(t, options, service, command...)
+	require.NoError(t, err)
+	return out
+}
+
+// ComposeExecE runs 'docker compose exec' to run the given command in the container of the given service, and returns
+// its stdout, stderr and exit code. If the command exits with a non-zero code, the output is returned along with an
+// ExecExitError.
+func ComposeExecE(t testing.TestingT, options *Options, service string, command ...string) (*ExecOutput, error) {
+	// Do not allocate a TTY, so that stdout and stderr are kept apart
+	args := append([]string{"exec", "-T", service}, command...)
+	cmd := formatDockerComposeCommand(t, options, args...)
+	stdout, stderr, runErr := shell.RunCommandAndGetStdOutErrE(t, cmd)
+	exitCode, err := shell.GetExitCodeForRunCommandError(runErr)
+	if err != nil {
+		return nil, err
+	}
+	if runErr != nil && exitCode == 0 {
+		// The command could not be run at all
+		return nil, runErr
+	}
+
+	out := &ExecOutput{Stdout: stdout, Stderr: stderr, ExitCode: exitCode}
+	if exitCode != 0 {
+		return out, ExecExitError{Container: service, Command: command, ExitCode: exitCode, Stderr: stderr}
+	}
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeProject(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		WorkingDir:  "../../test/fixtures/docker-compose-services",
+		ProjectName: "terratest-" + strings.ToLower(random.UniqueId()),
+	}
+	defer ComposeDown(t, options)
+	ComposeUp(t, options)
+
+	containers := ComposePs(t, options)
+	require.Len(t, containers, 2)
+	services := map[string]ComposeContainer{}
+	for _, container := range containers {
+		assert.Equal(t, "running", container.State)
+		services[container.Service] = container
+	}
+	assert.Equal(t, "healthy", services["web"].Health)
+	assert.Empty(t, services["worker"].Health)
+	assert.Empty(t, services["worker"].Ports)
+
+	hostPort := ComposePort(t, options, "web", 80)
+	assert.NotZero(t, hostPort)
+	assert.Equal(t, hostPort, services["web"].GetPublishedPort(80))
+	_, err := ComposePortE(t, options, "web", 8080)
+	assert.ErrorAs(t, err, &ServicePortNotPublishedError{})
+
+	assert.Equal(t, "worker started", ComposeLogs(t, options, "worker"))
+
+	out := ComposeExec(t, options, "worker", "cat", "/data/greeting.txt")
+	assert.Equal(t, "hello", out.Stdout)
+	out, err = ComposeExecE(t, options, "worker", "sh", "-c", "echo failed >&2; exit 2")
+	assert.ErrorAs(t, err, &ExecExitError{})
+	assert.Equal(t, 2, out.ExitCode)
+	assert.Equal(t, "failed", out.Stderr)
+}
+
+func TestParseComposePsOutput(t *testing.T) {
+	t.Parallel()
+
+	expected := []ComposeContainer{
+		{
+			ID:      "0123456789ab",
+			Name:    "project-web-1",
+			Service: "web",
+			Image:   "nginx:1.17-alpine",
+			State:   "running",
+			Health:  "healthy",
+			Ports:   []ComposePublishedPort{{URL: "0.0.0.0", TargetPort: 80, PublishedPort: 32768, Protocol: "tcp"}},
+		},
+		{
+			ID:       "ba9876543210",
+			Name:     "project-job-1",
+			Service:  "job",
+			Image:    "alpine:3.19",
+			State:    "exited",
+			ExitCode: 1,
+			Ports:    []ComposePublishedPort{},
+		},
+	}
+	web := `{"ID":"0123456789ab","Name":"project-web-1","Service":"web","Image":"nginx:1.17-alpine","State":"running","Health":"healthy","ExitCode":0,"Publishers":[{"URL":"0.0.0.0","TargetPort":80,"PublishedPort":32768,"Protocol":"tcp"},{"URL":"","TargetPort":443,"PublishedPort":0,"Protocol":"tcp"}]}`
+	job := `{"ID":"ba9876543210","Name":"project-job-1","Service":"job","Image":"alpine:3.19","State":"exited","Health":"","ExitCode":1,"Publishers":null}`
+
+	// Docker Compose up to v2.21 outputs a JSON array
+	containers, err := parseComposePsOutput("[" + web + "," + job + "]\n")
+	require.NoError(t, err)
+	assert.Equal(t, expected, containers)
+
+	// Later versions output one JSON object per line
+	containers, err = parseComposePsOutput(web + "\n" + job + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, expected, containers)
+}
@@ -0,0 +1,112 @@
+package docker
+
+import "fmt"
+
+// ImageNotFoundError is returned when an image cannot be found in the docker daemon.
+type ImageNotFoundError struct {
+	Image string
+}
+
+func (err ImageNotFoundError) Error() string {
+	return fmt.Sprintf("no image found with name or ID %s", err.Image)
+}
+
+// ImageMetadataMismatchError is returned when a field of the configuration of an image does not have the expected value.
+type ImageMetadataMismatchError struct {
+	Image    string
+	Field    string
+	Expected interface{}
+	Actual   interface{}
+}
+
+func (err ImageMetadataMismatchError) Error() string {
+	return fmt.Sprintf("image %s: expected %s to be %v, but got %v", err.Image, err.Field, err.Expected, err.Actual)
+}
+
+// ImageRunsAsRootError is returned when an image is expected not to run as root, but does.
+type ImageRunsAsRootError struct {
+	Image string
+	User  string
+}
+
+func (err ImageRunsAsRootError) Error() string {
+	return fmt.Sprintf("image %s runs as root (user %q)", err.Image, err.User)
+}
+
+// FileMismatchError is returned when a file of an image is missing or does not have the expected type, mode or owner.
+type FileMismatchError struct {
+	Image  string
+	Path   string
+	Reason string
+}
+
+func (err FileMismatchError) Error() string {
+	return fmt.Sprintf("file %s of image %s: %s", err.Path, err.Image, err.Reason)
+}
+
+// CommandOutputMismatchError is returned when the output or exit code of a command run in an image is not the expected
+// one.
+type CommandOutputMismatchError struct {
+	Image   string
+	Command []string
+	Reason  string
+	Output  string
+}
+
+func (err CommandOutputMismatchError) Error() string {
+	return fmt.Sprintf("command %v in image %s: %s. Output:\n%s", err.Command, err.Image, err.Reason, err.Output)
+}
+
+// ContainerNotRunningError is returned when waiting for a container that is no longer running.
+type ContainerNotRunningError struct {
+	ID       string
+	Status   string
+	ExitCode uint8
+}
+
+func (err ContainerNotRunningError) Error() string {
+	return fmt.Sprintf("container %s is not running (status %s, exit code %d)", err.ID, err.Status, err.ExitCode)
+}
+
+// UnsupportedAPIOptionError is returned when an option that only the docker CLI supports is used with the Engine API
+// backend.
+type UnsupportedAPIOptionError struct {
+	Option string
+}
+
+func (err UnsupportedAPIOptionError) Error() string {
+	return fmt.Sprintf("%s is not supported by the Docker Engine API backend, use the CLI backend instead", err.Option)
+}
+
+// ContainerExitError is returned when a container run in the foreground with the Engine API backend exits with a
+// non-zero code.
+type ContainerExitError struct {
+	ID       string
+	ExitCode int
+}
+
+func (err ContainerExitError) Error() string {
+	return fmt.Sprintf("container %s exited with code %d", err.ID, err.ExitCode)
+}
+
+// ExecExitError is returned when a command run in a container with Exec exits with a non-zero code.
+type ExecExitError struct {
+	Container string
+	Command   []string
+	ExitCode  int
+	Stderr    string
+}
+
+func (err ExecExitError) Error() string {
+	return fmt.Sprintf("command %v in container %s exited with code %d: %s", err.Command, err.Container, err.ExitCode, err.Stderr)
+}
+
+// ServicePortNotPublishedError is returned when a port of a docker compose service is not published to the host.
+type ServicePortNotPublishedError struct {
+	Service string
+	Port    uint16
+}
+
+func (err ServicePortNotPublishedError) Error() string {
+	return fmt.Sprintf("port %d of service %s is not published", err.Port, err.Service)
+}
@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ExecOptions defines options that can be passed to the 'docker exec' command.
+type ExecOptions struct {
+	// Username or UID (format: <name|uid>[:<group|gid>]) to run the command as
+	User string
+
+	// Working directory inside the container to run the command in
+	WorkingDir string
+
+	// Set environment variables, e.g. FOO=bar
+	EnvironmentVariables []string
+
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// ExecOutput is the result of a command run in a container with Exec.
+type ExecOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Exec runs the 'docker exec' command to run the given command in the given running container, and returns its stdout,
+// stderr and exit code. This method fails the test if there are any errors, including if the command exits with a
+// non-zero code.
+func Exec(t testing.TestingT, container string, command []string, options *ExecOptions) *ExecOutput {
+	out, err := ExecE(t, container, command, options)
+	require.NoError(t, err)
+	return out
+}
+
+// ExecE runs the 'docker exec' command to run the given command in the given running container, and returns its
+// stdout, stderr and exit code. If the command exits with a non-zero code, the output is returned along with an
+// ExecExitError. If the container is not running, a ContainerNotRunningError is returned.
+func ExecE(t testing.TestingT, container string, command []string, options *ExecOptions) (*ExecOutput, error) {
+	options.Logger.Logf(t, "Running 'docker exec' on container '%s' with command %v", container, command)
+
+	// 'docker exec' exits with code 1 both when the container is not running and when the command does, so check first
+	inspect, err := inspectE(t, options.Backend, container)
+	if err != nil {
+		return nil, err
+	}
+	if !inspect.Running {
+		return nil, ContainerNotRunningError{ID: container, Status: inspect.Status, ExitCode: inspect.ExitCode}
+	}
+
+	var out *ExecOutput
+	if resolveBackend(options.Backend) == BackendAPI {
+		out, err = execWithAPIE(t, container, command, options)
+	} else {
+		out, err = execWithCLIE(t, container, command, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if out.ExitCode != 0 {
+		return out, ExecExitError{Container: container, Command: command, ExitCode: out.ExitCode, Stderr: out.Stderr}
+	}
+	return out, nil
+}
+
+// execWithCLIE runs the command in the container with the docker CLI.
+func execWithCLIE(t testing.TestingT, container string, command []string, options *ExecOptions) (*ExecOutput, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    formatDockerExecArgs(container, command, options),
+		Logger:  options.Logger,
+	}
+	stdout, stderr, runErr := shell.RunCommandAndGetStdOutErrE(t, cmd)
+	exitCode, err := shell.GetExitCodeForRunCommandError(runErr)
+	if err != nil {
+		return nil, err
+	}
+	if runErr != nil && exitCode == 0 {
+		// The command could not be run at all
+		return nil, runErr
+	}
+	return &ExecOutput{Stdout: stdout, Stderr: stderr, ExitCode: exitCode}, nil
+}
+
+// formatDockerExecArgs formats the arguments for the 'docker exec' command.
+func formatDockerExecArgs(container string, command []string, options *ExecOptions) []string {
+	args := []string{"exec"}
+
+	if options.User != "" {
+		args = append(args, "--user", options.User)
+	}
+
+	if options.WorkingDir != "" {
+		args = append(args, "--workdir", options.WorkingDir)
+	}
+
+	for _, envVar := range options.EnvironmentVariables {
+		args = append(args, "--env", envVar)
+	}
+
+	args = append(args, container)
+	return append(args, command...)
+}
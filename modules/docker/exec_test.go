@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range []Backend{BackendCLI, BackendAPI} {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			t.Parallel()
+
+			id := RunAndGetID(t, "alpine:3.19", &RunOptions{Command: []string{"sleep", "60"}, Detach: true})
+			defer removeContainer(t, id)
+
+			options := &ExecOptions{
+				User:                 "nobody",
+				WorkingDir:           "/tmp",
+				EnvironmentVariables: []string{"GREETING=Hello"},
+				Backend:              backend,
+			}
+			out := Exec(t, id, []string{"sh", "-c", `echo "$GREETING from $(pwd) as $(id -un)"; echo oops >&2`}, options)
+			assert.Equal(t, "Hello from /tmp as nobody", out.Stdout)
+			assert.Equal(t, "oops", out.Stderr)
+			assert.Equal(t, 0, out.ExitCode)
+
+			out, err := ExecE(t, id, []string{"sh", "-c", "echo failed >&2; exit 3"}, &ExecOptions{Backend: backend})
+			var exitErr ExecExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, 3, exitErr.ExitCode)
+			assert.Equal(t, 3, out.ExitCode)
+			assert.Equal(t, "failed", out.Stderr)
+
+			Stop(t, []string{id}, &StopOptions{Time: 1})
+			_, err = ExecE(t, id, []string{"true"}, &ExecOptions{Backend: backend})
+			assert.ErrorAs(t, err, &ContainerNotRunningError{})
+		})
+	}
+}
@@ -73,8 +73,13 @@ func ListImages(t testing.TestingT, logger *logger.Logger) []Image {
 	return out
 }
 
-// ListImagesE calls docker images using the Docker CLI to list the available images on the local docker daemon.
+// ListImagesE calls docker images using the Docker CLI to list the available images on the local docker daemon. If the
+// TERRATEST_DOCKER_BACKEND environment variable is set to api, the Engine API is used instead.
 func ListImagesE(t testing.TestingT, logger *logger.Logger) ([]Image, error) {
+	if resolveBackend("") == BackendAPI {
+		return listImagesWithAPIE()
+	}
+
 	cmd := shell.Command{
 		Command: "docker",
 		Args:    []string{"images", "--format", "{{ json . }}"},
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"testing"
 	"time"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -113,7 +113,7 @@ type inspectOutput struct {
 
 // Inspect runs the 'docker inspect {container id}' command and returns a ContainerInspect
 // struct, converted from the output JSON, along with any errors
-func Inspect(t *testing.T, id string) *ContainerInspect {
+func Inspect(t testing.TestingT, id string) *ContainerInspect {
 	out, err := InspectE(t, id)
 	require.NoError(t, err)
 
@@ -121,8 +121,18 @@ func Inspect(t *testing.T, id string) *ContainerInspect {
 }
 
 // InspectE runs the 'docker inspect {container id}' command and returns a ContainerInspect
-// struct, converted from the output JSON, along with any errors
-func InspectE(t *testing.T, id string) (*ContainerInspect, error) {
+// struct, converted from the output JSON, along with any errors. If the TERRATEST_DOCKER_BACKEND
+// environment variable is set to api, the Engine API is used instead.
+func InspectE(t testing.TestingT, id string) (*ContainerInspect, error) {
+	return inspectE(t, "", id)
+}
+
+// inspectE inspects the given container with the given backend
+func inspectE(t testing.TestingT, backend Backend, id string) (*ContainerInspect, error) {
+	if resolveBackend(backend) == BackendAPI {
+		return inspectWithAPIE(t, id)
+	}
+
 	cmd := shell.Command{
 		Command: "docker",
 		Args:    []string{"container", "inspect", id},
@@ -151,7 +161,7 @@ func InspectE(t *testing.T, id string) (*ContainerInspect, error) {
 }
 
 // transformContainerPorts converts 'docker inspect' output JSON into a more friendly and testable format
-func transformContainer(t *testing.T, container inspectOutput) (*ContainerInspect, error) {
+func transformContainer(t testing.TestingT, container inspectOutput) (*ContainerInspect, error) {
 	name := strings.TrimLeft(container.Name, "/")
 
 	ports, err := transformContainerPorts(container)
@@ -0,0 +1,293 @@
+package docker
+
+import (
+	"bufio"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ImageInspect defines the output of the InspectImage method, with the options returned by 'docker image inspect' and
+// 'docker image history' converted into a more friendly and testable interface
+type ImageInspect struct {
+	// ID of the inspected image
+	ID string
+
+	// Tags (repo:tag) and digests (repo@digest) of the image
+	RepoTags    []string
+	RepoDigests []string
+
+	// time.Time that the image was created, or the zero time if the image has none
+	Created time.Time
+
+	// Platform of the image
+	Os           string
+	Architecture string
+
+	// Size of the image, in bytes
+	Size int64
+
+	// Configuration that containers created from the image use by default
+	Config ImageConfig
+
+	// Layers of the image, most recent first
+	History []ImageLayer
+}
+
+// ImageConfig represents the default configuration of the containers created from an image
+type ImageConfig struct {
+	// Username or UID (and optionally group) the containers run as. Empty means root.
+	User string
+
+	Entrypoint []string
+	Cmd        []string
+	WorkingDir string
+
+	// Environment variables, by name
+	Env map[string]string
+
+	// Exposed ports, sorted by protocol and port
+	ExposedPorts []ExposedPort
+
+	Labels map[string]string
+
+	// Health check of the image, if any
+	Healthcheck *ImageHealthCheck
+}
+
+// ExposedPort represents a port exposed by an image, e.g. with the EXPOSE instruction of a Dockerfile
+type ExposedPort struct {
+	Port     uint16
+	Protocol string
+}
+
+// ImageHealthCheck represents the health check configured in an image, e.g. with the HEALTHCHECK instruction of a
+// Dockerfile
+type ImageHealthCheck struct {
+	// Test to perform, e.g. ["CMD-SHELL", "curl -f http://localhost/"]
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// ImageLayer represents a layer of an image, as listed by 'docker image history'
+type ImageLayer struct {
+	// ID of the layer, or "<missing>" for layers built on another host
+	ID string
+
+	// Instruction that created the layer
+	CreatedBy string
+
+	// Timestamp for when the layer was created
+	CreatedAt string
+
+	// Size of the layer, in bytes
+	Size int64
+
+	Comment string
+}
+
+// RunsAsRoot returns true if the containers created from the image run as the root user by default.
+func (image ImageInspect) RunsAsRoot() bool {
+	user := strings.Split(image.Config.User, ":")[0]
+	return user == "" || user == "root" || user == "0"
+}
+
+// imageInspectOutput defines options that will be returned by 'docker image inspect', in JSON format.
+// Not all options are included here, only the ones that we might need
+type imageInspectOutput struct {
+	Id           string
+	RepoTags     []string
+	RepoDigests  []string
+	Created      string
+	Os           string
+	Architecture string
+	Size         int64
+	Config       struct {
+		User         string
+		Entrypoint   []string
+		Cmd          []string
+		WorkingDir   string
+		Env          []string
+		ExposedPorts map[string]struct{}
+		Labels       map[string]string
+		Healthcheck  *struct {
+			Test        []string
+			Interval    time.Duration
+			Timeout     time.Duration
+			StartPeriod time.Duration
+			Retries     int
+		}
+	}
+}
+
+// imageHistoryOutput defines a line returned by 'docker image history --format "{{json .}}"'
+type imageHistoryOutput struct {
+	ID        string
+	CreatedBy string
+	CreatedAt string
+	Size      string
+	Comment   string
+}
+
+// InspectImage runs the 'docker image inspect {image}' and 'docker image history {image}' commands and returns an
+// ImageInspect struct, converted from their output. This method fails the test if there are any errors.
+func InspectImage(t testing.TestingT, image string) *ImageInspect {
+	out, err := InspectImageE(t, image)
+	require.NoError(t, err)
+
+	return out
+}
+
+// InspectImageE runs the 'docker image inspect {image}' and 'docker image history {image}' commands and returns an
+// ImageInspect struct, converted from their output, along with any errors
+func InspectImageE(t testing.TestingT, image string) (*ImageInspect, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"image", "inspect", image},
+		// inspect is a short-running command, don't print the output.
+		Logger: logger.Discard,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []imageInspectOutput
+	if err := json.Unmarshal([]byte(out), &images); err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, ImageNotFoundError{Image: image}
+	}
+
+	inspect, err := transformImage(images[0])
+	if err != nil {
+		return nil, err
+	}
+
+	historyCmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"image", "history", "--no-trunc", "--human=false", "--format", "{{json .}}", image},
+		Logger:  logger.Discard,
+	}
+	historyOut, err := shell.RunCommandAndGetStdOutE(t, historyCmd)
+	if err != nil {
+		return nil, err
+	}
+	inspect.History, err = parseImageHistory(historyOut)
+	if err != nil {
+		return nil, err
+	}
+
+	return inspect, nil
+}
+
+// transformImage converts 'docker image inspect' output JSON into a more friendly and testable format
+func transformImage(image imageInspectOutput) (*ImageInspect, error) {
+	// Recent engines leave the creation time empty for images built without one, e.g. with reproducible timestamps
+	var created time.Time
+	if image.Created != "" {
+		var err error
+		created, err = time.Parse(time.RFC3339Nano, image.Created)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	env := map[string]string{}
+	for _, envVar := range image.Config.Env {
+		name, value, _ := strings.Cut(envVar, "=")
+		env[name] = value
+	}
+
+	exposedPorts := []ExposedPort{}
+	for key := range image.Config.ExposedPorts {
+		portStr, protocol, _ := strings.Cut(key, "/")
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		exposedPorts = append(exposedPorts, ExposedPort{Port: uint16(port), Protocol: protocol})
+	}
+	sort.Slice(exposedPorts, func(i, j int) bool {
+		if exposedPorts[i].Protocol != exposedPorts[j].Protocol {
+			return exposedPorts[i].Protocol < exposedPorts[j].Protocol
+		}
+		return exposedPorts[i].Port < exposedPorts[j].Port
+	})
+
+	labels := image.Config.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	inspect := ImageInspect{
+		ID:           image.Id,
+		RepoTags:     image.RepoTags,
+		RepoDigests:  image.RepoDigests,
+		Created:      created,
+		Os:           image.Os,
+		Architecture: image.Architecture,
+		Size:         image.Size,
+		Config: ImageConfig{
+			User:         image.Config.User,
+			Entrypoint:   image.Config.Entrypoint,
+			Cmd:          image.Config.Cmd,
+			WorkingDir:   image.Config.WorkingDir,
+			Env:          env,
+			ExposedPorts: exposedPorts,
+			Labels:       labels,
+		},
+	}
+	if healthcheck := image.Config.Healthcheck; healthcheck != nil {
+		inspect.Config.Healthcheck = &ImageHealthCheck{
+			Test:        healthcheck.Test,
+			Interval:    healthcheck.Interval,
+			Timeout:     healthcheck.Timeout,
+			StartPeriod: healthcheck.StartPeriod,
+			Retries:     healthcheck.Retries,
+		}
+	}
+
+	return &inspect, nil
+}
+
+// parseImageHistory converts the JSON lines output of 'docker image history' into image layers
+func parseImageHistory(out string) ([]ImageLayer, error) {
+	layers := []ImageLayer{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	// The instructions that created the layers are not truncated, and can exceed the default token size
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var history imageHistoryOutput
+		if err := json.Unmarshal([]byte(line), &history); err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(history.Size, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, ImageLayer{
+			ID:        history.ID,
+			CreatedBy: history.CreatedBy,
+			CreatedAt: history.CreatedAt,
+			Size:      size,
+			Comment:   history.Comment,
+		})
+	}
+	return layers, scanner.Err()
+}
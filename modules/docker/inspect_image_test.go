@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectImage(t *testing.T) {
+	t.Parallel()
+
+	img := fmt.Sprintf("gruntwork-io/test-structure:v1-%s", strings.ToLower(random.UniqueId()))
+	Build(t, "../../test/fixtures/docker-structure", &BuildOptions{Tags: []string{img}})
+	defer DeleteImage(t, img, nil)
+
+	image := InspectImage(t, img)
+	assert.Equal(t, []string{img}, image.RepoTags)
+	assert.Equal(t, "app", image.Config.User)
+	assert.False(t, image.RunsAsRoot())
+	assert.Equal(t, []string{"cat"}, image.Config.Entrypoint)
+	assert.Equal(t, []string{"hello.txt"}, image.Config.Cmd)
+	assert.Equal(t, "/opt/app", image.Config.Env["APP_HOME"])
+	assert.Equal(t, []ExposedPort{{Port: 8080, Protocol: "tcp"}}, image.Config.ExposedPorts)
+	assert.Equal(t, "terratest-structure", image.Config.Labels["org.opencontainers.image.title"])
+	require.NotNil(t, image.Config.Healthcheck)
+	assert.Equal(t, 5*time.Second, image.Config.Healthcheck.Interval)
+	assert.NotEmpty(t, image.History)
+	assert.NotZero(t, image.Size)
+
+	user := "app"
+	AssertImageStructure(t, img, ImageStructureTests{
+		Metadata: &MetadataTest{
+			User:         &user,
+			WorkingDir:   "/opt/app",
+			Env:          map[string]string{"APP_HOME": "/opt/app"},
+			ExposedPorts: []ExposedPort{{Port: 8080, Protocol: "tcp"}},
+		},
+		NotRoot: true,
+		Files: []FileTest{
+			{Path: "/opt/app", Owner: "app", IsDirectory: true},
+			{Path: "/opt/app/hello.txt", Mode: 0640, Owner: "app", Group: "app"},
+		},
+		Commands: []CommandTest{
+			{ExpectedOutput: []string{"^Hello, World!"}},
+			{Entrypoint: "id", Command: []string{"-u", "-n"}, ExpectedOutput: []string{"app"}, ExcludedOutput: []string{"root"}},
+			{Entrypoint: "cat", Command: []string{"/missing"}, ExitCode: 1},
+		},
+	})
+
+	err := AssertFileInImageE(t, img, FileTest{Path: "/opt/app/hello.txt", Mode: 0644}, nil)
+	assert.IsType(t, FileMismatchError{}, err)
+}
+
+func TestTransformImage(t *testing.T) {
+	t.Parallel()
+
+	out := `{
+		"Id": "sha256:4c7f0e1d",
+		"RepoTags": ["myapp:1.0.0"],
+		"Created": "2024-01-02T03:04:05.123456789Z",
+		"Os": "linux",
+		"Architecture": "amd64",
+		"Size": 7654321,
+		"Config": {
+			"Env": ["PATH=/usr/bin:/bin", "EMPTY="],
+			"ExposedPorts": {"8080/tcp": {}, "53/udp": {}, "443/tcp": {}},
+			"Healthcheck": {"Test": ["CMD-SHELL", "true"], "Interval": 5000000000, "Retries": 3}
+		}
+	}`
+	var output imageInspectOutput
+	require.NoError(t, json.Unmarshal([]byte(out), &output))
+
+	image, err := transformImage(output)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PATH": "/usr/bin:/bin", "EMPTY": ""}, image.Config.Env)
+	assert.Equal(t, []ExposedPort{{Port: 443, Protocol: "tcp"}, {Port: 8080, Protocol: "tcp"}, {Port: 53, Protocol: "udp"}}, image.Config.ExposedPorts)
+	assert.Equal(t, &ImageHealthCheck{Test: []string{"CMD-SHELL", "true"}, Interval: 5 * time.Second, Retries: 3}, image.Config.Healthcheck)
+	assert.True(t, image.RunsAsRoot())
+
+	layers, err := parseImageHistory(`{"Comment":"","CreatedAt":"2024-01-02T03:04:05Z","CreatedBy":"CMD [\"sh\"]","ID":"<missing>","Size":"0"}
+{"Comment":"","CreatedAt":"2024-01-02T03:04:04Z","CreatedBy":"ADD file:abc in /","ID":"<missing>","Size":"7654321"}
+`)
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+	assert.Equal(t, int64(7654321), layers[1].Size)
+}
+
+func TestTransformImageWithoutCreationTime(t *testing.T) {
+	t.Parallel()
+
+	var output imageInspectOutput
+	require.NoError(t, json.Unmarshal([]byte(`{"Id": "sha256:4c7f0e1d", "Created": "", "Os": "linux", "Config": {}}`), &output))
+
+	image, err := transformImage(output)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:4c7f0e1d", image.ID)
+	assert.True(t, image.Created.IsZero())
+}
+
+func TestCheckImageStructure(t *testing.T) {
+	t.Parallel()
+
+	user := "app"
+	err := checkImageMetadata("myapp", ImageConfig{User: "root", Env: map[string]string{"A": "1"}}, MetadataTest{
+		User:         &user,
+		Env:          map[string]string{"A": "1", "B": "2"},
+		ExposedPorts: []ExposedPort{{Port: 80, Protocol: "tcp"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected user to be app, but got root")
+	assert.Contains(t, err.Error(), "expected env B to be 2")
+	assert.Contains(t, err.Error(), "expected exposed ports to be to include 80/tcp")
+
+	assert.NoError(t, checkFileStat("myapp", FileTest{Path: "/app", Mode: 0755, Owner: "1000", IsDirectory: true}, "755|1000|1000|app|app|directory"))
+	assert.Error(t, checkFileStat("myapp", FileTest{Path: "/app", Group: "root"}, "755|1000|1000|app|app|directory"))
+	assert.Error(t, checkFileStat("myapp", FileTest{Path: "/app/run.sh", IsDirectory: true}, "4755|0|0|root|root|regular file"))
+
+	assert.NoError(t, checkCommandOutput("myapp", CommandTest{ExpectedOutput: []string{`v\d+`}, ExcludedOutput: []string{"error"}}, "v1\n", 0))
+	assert.Error(t, checkCommandOutput("myapp", CommandTest{}, "", 2))
+	assert.Error(t, checkCommandOutput("myapp", CommandTest{ExcludedOutput: []string{"error"}}, "an error\n", 0))
+}
@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// LogsOptions defines options that can be passed to the 'docker logs' command.
+type LogsOptions struct {
+	// Only return the logs written after Since, and before Until. Not set if zero.
+	Since time.Time
+	Until time.Time
+
+	// If set to true, pass the --timestamps flag to 'docker logs' to prefix every line with its RFC3339Nano timestamp
+	Timestamps bool
+
+	// If set, stop fetching or following the logs when the context is done
+	Context context.Context
+
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// Logs runs the 'docker logs' command on the given container and returns its stdout and stderr, interleaved. This
+// method fails the test if there are any errors.
+func Logs(t testing.TestingT, container string, options *LogsOptions) string {
+	out, err := LogsE(t, container, options)
+	require.NoError(t, err)
+	return out
+}
+
+// LogsE runs the 'docker logs' command on the given container and returns its stdout and stderr, interleaved, or any
+// error.
+func LogsE(t testing.TestingT, container string, options *LogsOptions) (string, error) {
+	options.Logger.Logf(t, "Running 'docker logs' on container '%s'", container)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return logsWithAPIE(getLogsContext(options), container, options)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    formatDockerLogsArgs(container, options, false),
+		Context: options.Context,
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandAndGetOutputE(t, cmd)
+}
+
+// FollowLogs runs the 'docker logs --follow' command on the given container, and passes each line of its stdout and
+// stderr to handleLine, until the container stops, handleLine returns false or the context of the options is done.
+// This method fails the test if there are any errors.
+func FollowLogs(t testing.TestingT, container string, options *LogsOptions, handleLine func(line string) bool) {
+	require.NoError(t, FollowLogsE(t, container, options, handleLine))
+}
+
+// FollowLogsE runs the 'docker logs --follow' command on the given container, and passes each line of its stdout and
+// stderr to handleLine, until the container stops, handleLine returns false or the context of the options is done. In
+// the latter case, the error of the context is returned. This is useful to wait for a given line, or to collect the
+// logs in the background.
+func FollowLogsE(t testing.TestingT, container string, options *LogsOptions, handleLine func(line string) bool) error {
+	options.Logger.Logf(t, "Running 'docker logs --follow' on container '%s'", container)
+
+	ctx, cancel := context.WithCancel(getLogsContext(options))
+	defer cancel()
+
+	var reader io.Reader
+	if resolveBackend(options.Backend) == BackendAPI {
+		logs, err := followLogsWithAPIE(ctx, container, options)
+		if err != nil {
+			return err
+		}
+		reader = logs
+	} else {
+		pipeReader, pipeWriter := io.Pipe()
+		// Unblock the copy of the output of the command, so that it can be waited for, if the lines stop being read
+		defer pipeReader.Close()
+		cmd := exec.CommandContext(ctx, "docker", formatDockerLogsArgs(container, options, true)...)
+		cmd.Stdout = pipeWriter
+		cmd.Stderr = pipeWriter
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		go func() {
+			pipeWriter.CloseWithError(cmd.Wait())
+		}()
+		reader = pipeReader
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		options.Logger.Logf(t, "%s", line)
+		if !handleLine(line) {
+			// Stop following, and wait for the logs to be closed
+			cancel()
+			_, _ = io.Copy(io.Discard, reader)
+			return nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
+
+// getLogsContext returns the context of the options, or the background context if none is set.
+func getLogsContext(options *LogsOptions) context.Context {
+	if options.Context != nil {
+		return options.Context
+	}
+	return context.Background()
+}
+
+// formatDockerLogsArgs formats the arguments for the 'docker logs' command.
+func formatDockerLogsArgs(container string, options *LogsOptions, follow bool) []string {
+	args := []string{"logs"}
+
+	if follow {
+		args = append(args, "--follow")
+	}
+
+	if !options.Since.IsZero() {
+		args = append(args, "--since", options.Since.Format(time.RFC3339Nano))
+	}
+
+	if !options.Until.IsZero() {
+		args = append(args, "--until", options.Until.Format(time.RFC3339Nano))
+	}
+
+	if options.Timestamps {
+		args = append(args, "--timestamps")
+	}
+
+	return append(args, container)
+}
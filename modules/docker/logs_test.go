@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogs(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range []Backend{BackendCLI, BackendAPI} {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			t.Parallel()
+
+			id := RunAndGetID(t, "alpine:3.19", &RunOptions{
+				Command:    []string{"-c", "for i in 1 2 3; do echo line $i; sleep 1; done; echo done >&2; sleep 60"},
+				Entrypoint: "sh",
+				Detach:     true,
+			})
+			defer removeContainer(t, id)
+
+			lines := []string{}
+			FollowLogs(t, id, &LogsOptions{Backend: backend}, func(line string) bool {
+				lines = append(lines, line)
+				return line != "done"
+			})
+			assert.Equal(t, []string{"line 1", "line 2", "line 3", "done"}, lines)
+
+			assert.Equal(t, "line 1\nline 2\nline 3\ndone", Logs(t, id, &LogsOptions{Backend: backend}))
+			assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\S+ line 1\n`, Logs(t, id, &LogsOptions{Timestamps: true, Backend: backend}))
+			assert.Empty(t, Logs(t, id, &LogsOptions{Since: time.Now().Add(time.Hour), Backend: backend}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			err := FollowLogsE(t, id, &LogsOptions{Context: ctx, Backend: backend}, func(string) bool { return true })
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+}
+
+func TestFormatDockerLogsArgs(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	args := formatDockerLogsArgs("my-container", &LogsOptions{Since: since, Timestamps: true}, true)
+	assert.Equal(t, []string{"logs", "--follow", "--since", "2024-01-02T03:04:05Z", "--timestamps", "my-container"}, args)
+}
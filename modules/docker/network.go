@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// NetworkOptions defines options that can be passed to the 'docker network' commands.
+type NetworkOptions struct {
+	// Driver of the network. Defaults to bridge.
+	Driver string
+
+	// If set to true, pass the --internal flag to 'docker network create' to restrict external access to the network
+	Internal bool
+
+	// Set metadata on the network
+	Labels map[string]string
+
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// CreateNetwork runs the 'docker network create' command to create a network with the given name, and returns its ID.
+// This method fails the test if there are any errors.
+func CreateNetwork(t testing.TestingT, name string, options *NetworkOptions) string {
+	id, err := CreateNetworkE(t, name, options)
+	require.NoError(t, err)
+	return id
+}
+
+// CreateNetworkE runs the 'docker network create' command to create a network with the given name, and returns its
+// ID, or any error.
+func CreateNetworkE(t testing.TestingT, name string, options *NetworkOptions) (string, error) {
+	options.Logger.Logf(t, "Creating docker network '%s'", name)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return createNetworkWithAPIE(name, options)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    formatDockerNetworkCreateArgs(name, options),
+		Logger:  options.Logger,
+	}
+	id, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	return strings.TrimSpace(id), err
+}
+
+// RemoveNetwork runs the 'docker network rm' command to remove the network with the given name or ID. Only the Backend
+// and Logger of the options are used. This method fails the test if there are any errors.
+func RemoveNetwork(t testing.TestingT, name string, options *NetworkOptions) {
+	require.NoError(t, RemoveNetworkE(t, name, options))
+}
+
+// RemoveNetworkE runs the 'docker network rm' command to remove the network with the given name or ID. Only the
+// Backend and Logger of the options are used. The network must not have any container connected to it.
+func RemoveNetworkE(t testing.TestingT, name string, options *NetworkOptions) error {
+	options.Logger.Logf(t, "Removing docker network '%s'", name)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return removeNetworkWithAPIE(name)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"network", "rm", name},
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// formatDockerNetworkCreateArgs formats the arguments for the 'docker network create' command.
+func formatDockerNetworkCreateArgs(name string, options *NetworkOptions) []string {
+	args := []string{"network", "create"}
+
+	if options.Driver != "" {
+		args = append(args, "--driver", options.Driver)
+	}
+
+	if options.Internal {
+		args = append(args, "--internal")
+	}
+
+	args = append(args, formatLabelArgs(options.Labels)...)
+
+	return append(args, name)
+}
+
+// formatLabelArgs formats the given labels as --label arguments, sorted by name.
+func formatLabelArgs(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := []string{}
+	for _, name := range names {
+		args = append(args, "--label", name+"="+labels[name])
+	}
+	return args
+}
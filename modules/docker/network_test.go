@@ -0,0 +1,58 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkAndVolume(t *testing.T) {
+	t.Parallel()
+
+	for _, backend := range []Backend{BackendCLI, BackendAPI} {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			t.Parallel()
+
+			name := "terratest-" + strings.ToLower(random.UniqueId())
+			networkOptions := &NetworkOptions{Labels: map[string]string{"test": "network"}, Backend: backend}
+			require.NotEmpty(t, CreateNetwork(t, name, networkOptions))
+			defer RemoveNetwork(t, name, networkOptions)
+			volumeOptions := &VolumeOptions{Backend: backend}
+			require.Equal(t, name, CreateVolume(t, name, volumeOptions))
+			defer RemoveVolume(t, name, volumeOptions)
+
+			// Write to the volume from a container reachable on the network under an alias
+			server := RunAndGetID(t, "alpine:3.19", &RunOptions{
+				Command:        []string{"-c", "echo hello > /data/index.html && httpd -f -h /data"},
+				Entrypoint:     "sh",
+				Detach:         true,
+				Network:        name,
+				NetworkAliases: []string{"server"},
+				Volumes:        []string{name + ":/data"},
+				Backend:        backend,
+			})
+			defer removeContainer(t, server)
+
+			out := Run(t, "alpine:3.19", &RunOptions{
+				Command:    []string{"-c", "sleep 1; wget -q -O - http://server/index.html && cat /data/index.html"},
+				Entrypoint: "sh",
+				Network:    name,
+				Remove:     true,
+				Volumes:    []string{name + ":/data:ro"},
+				Backend:    backend,
+			})
+			assert.Equal(t, "hello\nhello", out)
+		})
+	}
+}
+
+func TestFormatDockerNetworkCreateArgs(t *testing.T) {
+	t.Parallel()
+
+	args := formatDockerNetworkCreateArgs("my-network", &NetworkOptions{Internal: true, Labels: map[string]string{"b": "2", "a": "1"}})
+	assert.Equal(t, []string{"network", "create", "--internal", "--label", "a=1", "--label", "b=2", "my-network"}, args)
+}
@@ -28,6 +28,17 @@ type RunOptions struct {
 	// Assign a name to the container
 	Name string
 
+	// Connect the container to this network, e.g. one created with CreateNetwork
+	Network string
+
+	// Aliases under which other containers of the network can reach the container. Only supported on user-defined
+	// networks.
+	NetworkAliases []string
+
+	// Publish these container ports to the host, e.g. 8080:80, 127.0.0.1:8080:80 or 53/udp. Ports published without a
+	// host port are mapped to a random host port, see ContainerInspect.GetExposedHostPort.
+	Ports []string
+
 	// If set to true, pass the --privileged flag to 'docker run' to give extended privileges to the container
 	Privileged bool
 
@@ -48,6 +59,10 @@ type RunOptions struct {
 	// solely focus on the most important ones.
 	OtherOptions []string
 
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
 	// Set a logger that should be used. See the logger package for more info.
 	Logger *logger.Logger
 }
@@ -64,6 +79,11 @@ func Run(t testing.TestingT, image string, options *RunOptions) string {
 func RunE(t testing.TestingT, image string, options *RunOptions) (string, error) {
 	options.Logger.Logf(t, "Running 'docker run' on image '%s'", image)
 
+	if resolveBackend(options.Backend) == BackendAPI {
+		_, out, err := runWithAPIE(t, image, options)
+		return out, err
+	}
+
 	args, err := formatDockerRunArgs(image, options)
 	if err != nil {
 		return "", err
@@ -91,6 +111,11 @@ func RunAndGetID(t testing.TestingT, image string, options *RunOptions) string {
 func RunAndGetIDE(t testing.TestingT, image string, options *RunOptions) (string, error) {
 	options.Logger.Logf(t, "Running 'docker run' on image '%s', returning stdout", image)
 
+	if resolveBackend(options.Backend) == BackendAPI {
+		out, _, err := runWithAPIE(t, image, options)
+		return out, err
+	}
+
 	args, err := formatDockerRunArgs(image, options)
 	if err != nil {
 		return "", err
@@ -129,6 +154,18 @@ func formatDockerRunArgs(image string, options *RunOptions) ([]string, error) {
 		args = append(args, "--name", options.Name)
 	}
 
+	if options.Network != "" {
+		args = append(args, "--network", options.Network)
+	}
+
+	for _, alias := range options.NetworkAliases {
+		args = append(args, "--network-alias", alias)
+	}
+
+	for _, port := range options.Ports {
+		args = append(args, "--publish", port)
+	}
+
 	if options.Privileged {
 		args = append(args, "--privileged")
 	}
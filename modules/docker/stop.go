@@ -14,6 +14,10 @@ type StopOptions struct {
 	// Seconds to wait for stop before killing the container (default 10)
 	Time int
 
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
 	// Set a logger that should be used. See the logger package for more info.
 	Logger *logger.Logger
 }
@@ -30,6 +34,10 @@ func Stop(t testing.TestingT, containers []string, options *StopOptions) string
 func StopE(t testing.TestingT, containers []string, options *StopOptions) (string, error) {
 	options.Logger.Logf(t, "Running 'docker stop' on containers '%s'", containers)
 
+	if resolveBackend(options.Backend) == BackendAPI {
+		return stopWithAPIE(containers, options)
+	}
+
 	args, err := formatDockerStopArgs(containers, options)
 	if err != nil {
 		return "", err
@@ -0,0 +1,320 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+// ImageStructureTests defines a set of container-structure-test style checks to run against an image.
+type ImageStructureTests struct {
+	// Files that must exist in the image
+	Files []FileTest
+
+	// Commands to run in the image, and their expected outputs
+	Commands []CommandTest
+
+	// Expected configuration of the image, if set
+	Metadata *MetadataTest
+
+	// If set to true, check that the image does not run as root by default
+	NotRoot bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// FileTest defines the expected properties of a file in an image. It is checked by running stat in a container of the
+// image, so the image must contain a stat binary (e.g. from busybox or coreutils).
+type FileTest struct {
+	// Absolute path of the file in the image
+	Path string
+
+	// Expected permissions of the file, e.g. 0755. Not checked if zero.
+	Mode os.FileMode
+
+	// Expected owner and group of the file, either as names or numeric IDs. Not checked if empty.
+	Owner string
+	Group string
+
+	// If set to true, the file must be a directory
+	IsDirectory bool
+}
+
+// CommandTest defines a command to run in a container of an image, and its expected output.
+type CommandTest struct {
+	// Command to run. If Entrypoint is empty, it is passed to the default entrypoint of the image.
+	Command []string
+
+	// Override the default ENTRYPOINT of the image, e.g. sh
+	Entrypoint string
+
+	// Set environment variables, e.g. FOO=bar
+	EnvironmentVariables []string
+
+	// Regular expressions that must match the output (stdout and stderr) of the command
+	ExpectedOutput []string
+
+	// Regular expressions that must not match the output (stdout and stderr) of the command
+	ExcludedOutput []string
+
+	// Expected exit code of the command
+	ExitCode int
+}
+
+// MetadataTest defines the expected configuration of an image. Only the fields that are set are checked.
+type MetadataTest struct {
+	User       *string
+	Entrypoint []string
+	Cmd        []string
+	WorkingDir string
+
+	// Environment variables that must be set to the given values
+	Env map[string]string
+
+	// Labels that must be set to the given values
+	Labels map[string]string
+
+	// Ports that must be exposed
+	ExposedPorts []ExposedPort
+}
+
+// AssertImageStructure runs all the given structure tests against the image and fails the test if any of them fails.
+func AssertImageStructure(t testing.TestingT, image string, tests ImageStructureTests) {
+	require.NoError(t, AssertImageStructureE(t, image, tests))
+}
+
+// AssertImageStructureE runs all the given structure tests against the image and returns the failures, accumulated
+// into a MultiError.
+func AssertImageStructureE(t testing.TestingT, image string, tests ImageStructureTests) error {
+	var errorsOccurred = new(multierror.Error)
+
+	if tests.Metadata != nil {
+		errorsOccurred = multierror.Append(errorsOccurred, AssertImageMetadataE(t, image, *tests.Metadata))
+	}
+	if tests.NotRoot {
+		errorsOccurred = multierror.Append(errorsOccurred, AssertImageNotRunningAsRootE(t, image))
+	}
+	for _, file := range tests.Files {
+		errorsOccurred = multierror.Append(errorsOccurred, AssertFileInImageE(t, image, file, tests.Logger))
+	}
+	for _, command := range tests.Commands {
+		errorsOccurred = multierror.Append(errorsOccurred, AssertCommandInImageE(t, image, command, tests.Logger))
+	}
+
+	return errorsOccurred.ErrorOrNil()
+}
+
+// AssertImageMetadata checks that the configuration of the image matches the given expectations, and fails the test if
+// it does not.
+func AssertImageMetadata(t testing.TestingT, image string, expected MetadataTest) {
+	require.NoError(t, AssertImageMetadataE(t, image, expected))
+}
+
+// AssertImageMetadataE checks that the configuration of the image matches the given expectations, and returns an
+// ImageMetadataMismatchError for every field that does not match, accumulated into a MultiError.
+func AssertImageMetadataE(t testing.TestingT, image string, expected MetadataTest) error {
+	inspect, err := InspectImageE(t, image)
+	if err != nil {
+		return err
+	}
+	return checkImageMetadata(image, inspect.Config, expected)
+}
+
+// checkImageMetadata compares the fields of the image configuration that are set in the expectations.
+func checkImageMetadata(image string, config ImageConfig, expected MetadataTest) error {
+	var errorsOccurred = new(multierror.Error)
+	mismatch := func(field string, expected interface{}, actual interface{}) {
+		errorsOccurred = multierror.Append(errorsOccurred, ImageMetadataMismatchError{Image: image, Field: field, Expected: expected, Actual: actual})
+	}
+
+	if expected.User != nil && *expected.User != config.User {
+		mismatch("user", *expected.User, config.User)
+	}
+	if expected.Entrypoint != nil && !reflect.DeepEqual(expected.Entrypoint, config.Entrypoint) {
+		mismatch("entrypoint", expected.Entrypoint, config.Entrypoint)
+	}
+	if expected.Cmd != nil && !reflect.DeepEqual(expected.Cmd, config.Cmd) {
+		mismatch("cmd", expected.Cmd, config.Cmd)
+	}
+	if expected.WorkingDir != "" && expected.WorkingDir != config.WorkingDir {
+		mismatch("working directory", expected.WorkingDir, config.WorkingDir)
+	}
+	for _, name := range sortedKeys(expected.Env) {
+		if value, ok := config.Env[name]; !ok || value != expected.Env[name] {
+			mismatch("env "+name, expected.Env[name], value)
+		}
+	}
+	for _, name := range sortedKeys(expected.Labels) {
+		if value, ok := config.Labels[name]; !ok || value != expected.Labels[name] {
+			mismatch("label "+name, expected.Labels[name], value)
+		}
+	}
+	for _, port := range expected.ExposedPorts {
+		found := false
+		for _, exposedPort := range config.ExposedPorts {
+			found = found || exposedPort == port
+		}
+		if !found {
+			mismatch("exposed ports", fmt.Sprintf("to include %d/%s", port.Port, port.Protocol), config.ExposedPorts)
+		}
+	}
+
+	return errorsOccurred.ErrorOrNil()
+}
+
+// AssertImageNotRunningAsRoot checks that the image does not run as root by default, and fails the test if it does.
+func AssertImageNotRunningAsRoot(t testing.TestingT, image string) {
+	require.NoError(t, AssertImageNotRunningAsRootE(t, image))
+}
+
+// AssertImageNotRunningAsRootE checks that the image does not run as root by default, and returns an
+// ImageRunsAsRootError if it does.
+func AssertImageNotRunningAsRootE(t testing.TestingT, image string) error {
+	inspect, err := InspectImageE(t, image)
+	if err != nil {
+		return err
+	}
+	if inspect.RunsAsRoot() {
+		return ImageRunsAsRootError{Image: image, User: inspect.Config.User}
+	}
+	return nil
+}
+
+// AssertFileInImage checks that a file exists in the image with the expected properties, and fails the test if it
+// does not.
+func AssertFileInImage(t testing.TestingT, image string, expected FileTest, logger *logger.Logger) {
+	require.NoError(t, AssertFileInImageE(t, image, expected, logger))
+}
+
+// AssertFileInImageE checks that a file exists in the image with the expected properties, by running stat in a
+// container of the image. It returns a FileMismatchError if the file is missing or does not match.
+func AssertFileInImageE(t testing.TestingT, image string, expected FileTest, logger *logger.Logger) error {
+	args, err := formatDockerRunArgs(image, &RunOptions{
+		Entrypoint: "stat",
+		Command:    []string{"-c", "%a|%u|%g|%U|%G|%F", expected.Path},
+		Remove:     true,
+		User:       "0",
+	})
+	if err != nil {
+		return err
+	}
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("does not exist or cannot be inspected: %v", err)}
+	}
+	return checkFileStat(image, expected, strings.TrimSpace(out))
+}
+
+// checkFileStat compares the output of stat -c '%a|%u|%g|%U|%G|%F' with the expected properties of the file.
+func checkFileStat(image string, expected FileTest, stat string) error {
+	fields := strings.Split(stat, "|")
+	if len(fields) != 6 {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("unexpected stat output %q", stat)}
+	}
+	mode, err := strconv.ParseUint(fields[0], 8, 32)
+	if err != nil {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("unexpected mode %q", fields[0])}
+	}
+	uid, gid, owner, group, fileType := fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	if expected.Mode != 0 && os.FileMode(mode).Perm() != expected.Mode.Perm() {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("expected mode %s, but got %s", expected.Mode.Perm(), os.FileMode(mode).Perm())}
+	}
+	if expected.Owner != "" && expected.Owner != owner && expected.Owner != uid {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("expected owner %s, but got %s (%s)", expected.Owner, owner, uid)}
+	}
+	if expected.Group != "" && expected.Group != group && expected.Group != gid {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("expected group %s, but got %s (%s)", expected.Group, group, gid)}
+	}
+	if expected.IsDirectory && fileType != "directory" {
+		return FileMismatchError{Image: image, Path: expected.Path, Reason: fmt.Sprintf("expected a directory, but got a %s", fileType)}
+	}
+	return nil
+}
+
+// AssertCommandInImage runs a command in a container of the image and checks its output and exit code, and fails the
+// test if they do not match.
+func AssertCommandInImage(t testing.TestingT, image string, expected CommandTest, logger *logger.Logger) {
+	require.NoError(t, AssertCommandInImageE(t, image, expected, logger))
+}
+
+// AssertCommandInImageE runs a command in a new container of the image, which is removed afterwards, and checks its
+// output and exit code. It returns a CommandOutputMismatchError if they do not match.
+func AssertCommandInImageE(t testing.TestingT, image string, expected CommandTest, logger *logger.Logger) error {
+	args, err := formatDockerRunArgs(image, &RunOptions{
+		Entrypoint:           expected.Entrypoint,
+		Command:              expected.Command,
+		EnvironmentVariables: expected.EnvironmentVariables,
+		Remove:               true,
+	})
+	if err != nil {
+		return err
+	}
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  logger,
+	}
+	out, runErr := shell.RunCommandAndGetOutputE(t, cmd)
+	exitCode, err := shell.GetExitCodeForRunCommandError(runErr)
+	if err != nil {
+		return err
+	}
+	return checkCommandOutput(image, expected, out, exitCode)
+}
+
+// checkCommandOutput compares the output and exit code of a command with the expected ones.
+func checkCommandOutput(image string, expected CommandTest, out string, exitCode int) error {
+	mismatch := func(reason string) error {
+		return CommandOutputMismatchError{Image: image, Command: expected.Command, Reason: reason, Output: out}
+	}
+
+	if exitCode != expected.ExitCode {
+		return mismatch(fmt.Sprintf("expected exit code %d, but got %d", expected.ExitCode, exitCode))
+	}
+	for _, pattern := range expected.ExpectedOutput {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(out) {
+			return mismatch(fmt.Sprintf("expected output to match %q", pattern))
+		}
+	}
+	for _, pattern := range expected.ExcludedOutput {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		if re.MatchString(out) {
+			return mismatch(fmt.Sprintf("expected output not to match %q", pattern))
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the given map, sorted.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+// TopologyOptions defines a set of containers to run together on a dedicated network with RunTopology.
+type TopologyOptions struct {
+	// Containers to run, in order. Each container is ready, as defined by its wait strategies, before the next one is
+	// started, so that dependencies can be listed first.
+	Containers []TopologyContainer
+
+	// Options of the network that is created for the containers. Its backend and logger default to the ones of the
+	// topology.
+	Network NetworkOptions
+
+	// Backend to use to talk to the Docker engine, for the network and the containers that do not set one. Defaults to
+	// the one selected with the TERRATEST_DOCKER_BACKEND environment variable, or to the docker CLI.
+	Backend Backend
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// TopologyContainer defines a container of a topology.
+type TopologyContainer struct {
+	// Name of the container in the topology. The other containers of the topology can reach it under this name.
+	Name string
+
+	// Image to run the container from
+	Image string
+
+	// Options of the container. Its container name and network are set by RunTopology, and its name in the topology is
+	// added to its network aliases.
+	ContainerOptions
+}
+
+// Topology represents a set of containers running together on a dedicated network.
+type Topology struct {
+	// Name of the network the containers are connected to
+	Network string
+
+	// Containers of the topology, by name
+	Containers map[string]*Container
+
+	// Names of the containers, in the order they were started
+	names []string
+
+	networkOptions *NetworkOptions
+}
+
+// RunTopology creates a dedicated network, and runs the given containers on it in order. If the test supports it (as
+// *testing.T does), the containers and the network are removed when the test completes; otherwise, the caller must
+// call Topology.Remove. This will fail the test if there is an error.
+func RunTopology(t testing.TestingT, options *TopologyOptions) *Topology {
+	topology, err := RunTopologyE(t, options)
+	require.NoError(t, err)
+	return topology
+}
+
+// RunTopologyE creates a dedicated network, and runs the given containers on it in order. If the test supports it (as
+// *testing.T does), the containers and the network are removed when the test completes; otherwise, the caller must
+// call Topology.Remove. If a container fails to start, the containers started so far and the network are removed.
+func RunTopologyE(t testing.TestingT, options *TopologyOptions) (*Topology, error) {
+	networkOptions := options.Network
+	if networkOptions.Backend == "" {
+		networkOptions.Backend = options.Backend
+	}
+	if networkOptions.Logger == nil {
+		networkOptions.Logger = options.Logger
+	}
+
+	networkName := "terratest-" + strings.ToLower(random.UniqueId())
+	if _, err := CreateNetworkE(t, networkName, &networkOptions); err != nil {
+		return nil, err
+	}
+	topology := &Topology{
+		Network:        networkName,
+		Containers:     map[string]*Container{},
+		networkOptions: &networkOptions,
+	}
+
+	// Cleanup functions run in reverse order, so the network is removed after the containers, which RunContainerE
+	// registers for cleanup
+	cleaner, registerCleanup := t.(interface{ Cleanup(func()) })
+	if registerCleanup {
+		cleaner.Cleanup(func() {
+			if err := RemoveNetworkE(t, topology.Network, &networkOptions); err != nil {
+				networkOptions.Logger.Logf(t, "Failed to remove network %s: %v", topology.Network, err)
+			}
+		})
+	}
+
+	for _, topologyContainer := range options.Containers {
+		if _, exists := topology.Containers[topologyContainer.Name]; exists {
+			err := fmt.Errorf("container %s is defined more than once in the topology", topologyContainer.Name)
+			return nil, topology.removeAfterFailure(t, registerCleanup, err)
+		}
+
+		containerOptions := topologyContainer.ContainerOptions
+		containerOptions.Name = fmt.Sprintf("%s-%s", networkName, topologyContainer.Name)
+		containerOptions.Network = networkName
+		containerOptions.NetworkAliases = append([]string{topologyContainer.Name}, topologyContainer.NetworkAliases...)
+		if containerOptions.Backend == "" {
+			containerOptions.Backend = options.Backend
+		}
+		if containerOptions.Logger == nil {
+			containerOptions.Logger = options.Logger
+		}
+
+		container, err := RunContainerE(t, topologyContainer.Image, &containerOptions)
+		if err != nil {
+			return nil, topology.removeAfterFailure(t, registerCleanup, err)
+		}
+		topology.Containers[topologyContainer.Name] = container
+		topology.names = append(topology.names, topologyContainer.Name)
+	}
+
+	return topology, nil
+}
+
+// removeAfterFailure removes the topology after it failed to start, unless it is removed when the test completes, and
+// returns the given error.
+func (topology *Topology) removeAfterFailure(t testing.TestingT, registeredCleanup bool, err error) error {
+	if !registeredCleanup {
+		if removeErr := topology.RemoveE(t); removeErr != nil {
+			topology.networkOptions.Logger.Logf(t, "Failed to remove topology on network %s: %v", topology.Network, removeErr)
+		}
+	}
+	return err
+}
+
+// Remove removes the containers of the topology, in reverse order, and its network. This will fail the test if there
+// is an error.
+func (topology *Topology) Remove(t testing.TestingT) {
+	require.NoError(t, topology.RemoveE(t))
+}
+
+// RemoveE removes the containers of the topology, in reverse order, and its network.
+func (topology *Topology) RemoveE(t testing.TestingT) error {
+	var errorsOccurred = new(multierror.Error)
+	for i := len(topology.names) - 1; i >= 0; i-- {
+		errorsOccurred = multierror.Append(errorsOccurred, topology.Containers[topology.names[i]].RemoveE(t))
+	}
+	if errorsOccurred.ErrorOrNil() == nil {
+		errorsOccurred = multierror.Append(errorsOccurred, RemoveNetworkE(t, topology.Network, topology.networkOptions))
+	}
+	return errorsOccurred.ErrorOrNil()
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTopology(t *testing.T) {
+	t.Parallel()
+
+	topology := RunTopology(t, &TopologyOptions{
+		Containers: []TopologyContainer{
+			{
+				Name:  "web",
+				Image: dockerInspectTestImage,
+				ContainerOptions: ContainerOptions{
+					RunOptions: RunOptions{Ports: []string{"80"}},
+					WaitFor:    []WaitStrategy{WaitForHTTP(80, "/")},
+				},
+			},
+			{
+				Name:  "client",
+				Image: "alpine:3.19",
+				ContainerOptions: ContainerOptions{
+					RunOptions:  RunOptions{Command: []string{"sleep", "60"}},
+					WaitFor:     []WaitStrategy{WaitForExec("wget", "-q", "-O", "/dev/null", "http://web/")},
+					WaitTimeout: 30 * time.Second,
+				},
+			},
+		},
+	})
+
+	assert.Len(t, topology.Containers, 2)
+	assert.NotZero(t, topology.Containers["web"].GetHostPort(80))
+	out := Exec(t, topology.Containers["client"].ID, []string{"wget", "-q", "-O", "-", "http://web/"}, &ExecOptions{})
+	assert.Contains(t, out.Stdout, "Welcome to nginx!")
+}
@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// VolumeOptions defines options that can be passed to the 'docker volume' commands.
+type VolumeOptions struct {
+	// Driver of the volume. Defaults to local.
+	Driver string
+
+	// Set metadata on the volume
+	Labels map[string]string
+
+	// Backend to use to talk to the Docker engine. Defaults to the one selected with the TERRATEST_DOCKER_BACKEND
+	// environment variable, or to the docker CLI.
+	Backend Backend
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// CreateVolume runs the 'docker volume create' command to create a volume with the given name, and returns its name.
+// A name is generated if the given one is empty. This method fails the test if there are any errors.
+func CreateVolume(t testing.TestingT, name string, options *VolumeOptions) string {
+	out, err := CreateVolumeE(t, name, options)
+	require.NoError(t, err)
+	return out
+}
+
+// CreateVolumeE runs the 'docker volume create' command to create a volume with the given name, and returns its name,
+// or any error. A name is generated if the given one is empty.
+func CreateVolumeE(t testing.TestingT, name string, options *VolumeOptions) (string, error) {
+	options.Logger.Logf(t, "Creating docker volume '%s'", name)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return createVolumeWithAPIE(name, options)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    formatDockerVolumeCreateArgs(name, options),
+		Logger:  options.Logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	return strings.TrimSpace(out), err
+}
+
+// RemoveVolume runs the 'docker volume rm' command to remove the volume with the given name. Only the Backend and
+// Logger of the options are used. This method fails the test if there are any errors.
+func RemoveVolume(t testing.TestingT, name string, options *VolumeOptions) {
+	require.NoError(t, RemoveVolumeE(t, name, options))
+}
+
+// RemoveVolumeE runs the 'docker volume rm' command to remove the volume with the given name. Only the Backend and
+// Logger of the options are used. The volume must not be in use by any container.
+func RemoveVolumeE(t testing.TestingT, name string, options *VolumeOptions) error {
+	options.Logger.Logf(t, "Removing docker volume '%s'", name)
+
+	if resolveBackend(options.Backend) == BackendAPI {
+		return removeVolumeWithAPIE(name)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"volume", "rm", name},
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// formatDockerVolumeCreateArgs formats the arguments for the 'docker volume create' command.
+func formatDockerVolumeCreateArgs(name string, options *VolumeOptions) []string {
+	args := []string{"volume", "create"}
+
+	if options.Driver != "" {
+		args = append(args, "--driver", options.Driver)
+	}
+
+	args = append(args, formatLabelArgs(options.Labels)...)
+
+	if name != "" {
+		args = append(args, name)
+	}
+	return args
+}
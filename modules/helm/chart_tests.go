@@ -0,0 +1,247 @@
+package helm
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	go_test "testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	// ChartTestPhaseSucceeded is the phase of a test hook whose pod ran to completion successfully.
+	ChartTestPhaseSucceeded = "Succeeded"
+	// ChartTestPhaseFailed is the phase of a test hook whose pod failed or timed out.
+	ChartTestPhaseFailed = "Failed"
+	// ChartTestPhaseNotRun is the phase of a test hook that was not executed by the last `helm test` run, e.g. because
+	// a previous test hook failed.
+	ChartTestPhaseNotRun = "NotRun"
+)
+
+// ChartTestResult is the outcome of a single test hook (a pod annotated with `helm.sh/hook: test`) of a release.
+type ChartTestResult struct {
+	// Name is the name of the test pod.
+	Name string
+	// Path is the template the test hook was rendered from, e.g. mychart/templates/tests/test-connection.yaml.
+	Path string
+	// Phase is the helm phase of the hook: Succeeded, Failed, Running or Unknown, or NotRun if the hook was not
+	// executed.
+	Phase       string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Duration    time.Duration
+	// Logs holds the logs of the test pod, keyed by container name. This is empty if the pod was deleted by a hook
+	// delete policy before the logs could be retrieved.
+	Logs map[string]string
+}
+
+// Succeeded returns true if the test hook ran to completion successfully.
+func (result ChartTestResult) Succeeded() bool {
+	return result.Phase == ChartTestPhaseSucceeded
+}
+
+// hookTime is a timestamp as serialized by helm, which encodes the zero time as an empty string.
+type hookTime struct {
+	time.Time
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (hookTime *hookTime) UnmarshalJSON(data []byte) error {
+	if string(data) == `""` || string(data) == "null" {
+		hookTime.Time = time.Time{}
+		return nil
+	}
+	return json.Unmarshal(data, &hookTime.Time)
+}
+
+// rawHooks is the subset of the release object printed by `helm status -o json` that describes the hooks.
+type rawHooks struct {
+	Namespace string `json:"namespace"`
+	Hooks     []struct {
+		Name    string   `json:"name"`
+		Kind    string   `json:"kind"`
+		Path    string   `json:"path"`
+		Events  []string `json:"events"`
+		Weight  int      `json:"weight"`
+		LastRun struct {
+			StartedAt   hookTime `json:"started_at"`
+			CompletedAt hookTime `json:"completed_at"`
+			Phase       string   `json:"phase"`
+		} `json:"last_run"`
+	} `json:"hooks"`
+}
+
+// RunChartTests runs the test hooks of the given release and reports each of them as a subtest named after the test
+// pod, which fails if the hook did not succeed and logs the output of the test pod. The results are returned so that
+// they can be further inspected.
+// Note that go_test is an alias to Golang's native testing package created to avoid naming conflicts with Terratest's
+// own testing package.
+func RunChartTests(t *go_test.T, options *Options, releaseName string, timeout time.Duration) []ChartTestResult {
+	results, err := RunChartTestsE(t, options, releaseName, timeout)
+	if err != nil && results == nil {
+		require.NoError(t, err)
+	}
+
+	failedSubtest := false
+	for _, result := range results {
+		result := result
+		passed := t.Run(result.Name, func(t *go_test.T) {
+			for _, container := range sortedKeys(result.Logs) {
+				t.Logf("Logs of container %s:\n%s", container, result.Logs[container])
+			}
+			if result.Phase == ChartTestPhaseNotRun {
+				t.Skipf("Test hook %s was not run by helm test", result.Path)
+			}
+			assert.Equal(t, ChartTestPhaseSucceeded, result.Phase, "Test hook %s did not succeed (took %s)", result.Path, result.Duration)
+		})
+		failedSubtest = failedSubtest || !passed
+	}
+	// Only report the helm error if it is not already explained by a failing hook, e.g. when helm timed out.
+	if err != nil && !failedSubtest {
+		require.NoError(t, err)
+	}
+	return results
+}
+
+// RunChartTestsE runs the test hooks of the given release with `helm test`, waiting up to timeout for each of them to
+// complete (use 0 for the helm default). It returns one result per test hook, in the order helm runs them, with the
+// logs of the test pods pulled with k8s.GetPodLogsE. If any hook fails, the results are returned alongside the error of
+// `helm test`, so callers can report which hook failed and why.
+func RunChartTestsE(t testing.TestingT, options *Options, releaseName string, timeout time.Duration) ([]ChartTestResult, error) {
+	args := getExtraArgs(options, "test")
+	if timeout > 0 {
+		args = append(args, "--timeout", timeout.String())
+	}
+	args = append(args, releaseName)
+
+	// helm records the start time of the hooks with the local clock, so we can tell which ones ran in this invocation.
+	// Truncate to the second, as that is the precision of the timestamps in some helm versions.
+	startedAt := time.Now().Truncate(time.Second)
+	_, testErr := RunHelmCommandAndGetOutputE(t, options, "test", args...)
+
+	statusArgs := append(getExtraArgs(options, "status"), releaseName, "--output", "json")
+	out, err := RunHelmCommandAndGetStdOutE(t, options, "status", statusArgs...)
+	if err != nil {
+		return nil, err
+	}
+	results, namespace, err := parseChartTestResultsE(out, startedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	kubectlOptions := getReleaseKubectlOptions(options, namespace)
+	for i := range results {
+		if results[i].Phase == ChartTestPhaseNotRun {
+			continue
+		}
+		logs, err := getTestPodLogsE(t, kubectlOptions, results[i].Name)
+		if err != nil {
+			return results, err
+		}
+		results[i].Logs = logs
+	}
+	return results, testErr
+}
+
+// parseChartTestResultsE extracts the test hooks from the JSON output of `helm status`, along with the namespace of the
+// release. Hooks that were last started before startedAt are reported as not run.
+func parseChartTestResultsE(out string, startedAt time.Time) ([]ChartTestResult, string, error) {
+	var release rawHooks
+	if err := json.Unmarshal([]byte(out), &release); err != nil {
+		return nil, "", errors.WithStackTrace(err)
+	}
+
+	// helm runs the hooks by ascending weight, then by name
+	hooks := release.Hooks
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Weight != hooks[j].Weight {
+			return hooks[i].Weight < hooks[j].Weight
+		}
+		return hooks[i].Name < hooks[j].Name
+	})
+
+	results := []ChartTestResult{}
+	for _, hook := range hooks {
+		if hook.Kind != "Pod" || !isTestHook(hook.Events) {
+			continue
+		}
+		result := ChartTestResult{
+			Name:        hook.Name,
+			Path:        hook.Path,
+			Phase:       hook.LastRun.Phase,
+			StartedAt:   hook.LastRun.StartedAt.Time,
+			CompletedAt: hook.LastRun.CompletedAt.Time,
+		}
+		if result.StartedAt.IsZero() || result.StartedAt.Before(startedAt) {
+			result.Phase = ChartTestPhaseNotRun
+		}
+		if !result.StartedAt.IsZero() && !result.CompletedAt.IsZero() {
+			result.Duration = result.CompletedAt.Sub(result.StartedAt)
+		}
+		results = append(results, result)
+	}
+	return results, release.Namespace, nil
+}
+
+// isTestHook returns true if the hook events include the test event. Helm 2 charts used test-success instead.
+func isTestHook(events []string) bool {
+	for _, event := range events {
+		if event == "test" || event == "test-success" {
+			return true
+		}
+	}
+	return false
+}
+
+// getReleaseKubectlOptions returns kubectl options for the cluster targeted by the helm options, in the given
+// namespace.
+func getReleaseKubectlOptions(options *Options, namespace string) *k8s.KubectlOptions {
+	if options.KubectlOptions == nil {
+		kubectlOptions := k8s.NewKubectlOptions("", "", namespace)
+		kubectlOptions.Logger = options.Logger
+		return kubectlOptions
+	}
+	kubectlOptions := *options.KubectlOptions
+	if namespace != "" {
+		kubectlOptions.Namespace = namespace
+	}
+	return &kubectlOptions
+}
+
+// getTestPodLogsE returns the logs of every container of the given test pod, or nil if the pod no longer exists.
+func getTestPodLogsE(t testing.TestingT, options *k8s.KubectlOptions, podName string) (map[string]string, error) {
+	pod, err := k8s.GetPodE(t, options, podName)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	logs := map[string]string{}
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		containerLogs, err := k8s.GetPodLogsE(t, options, pod, container.Name)
+		if err != nil {
+			return nil, err
+		}
+		logs[container.Name] = strings.TrimSpace(containerLogs)
+	}
+	return logs, nil
+}
+
+// sortedKeys returns the keys of the given map in lexical order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
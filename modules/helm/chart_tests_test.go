@@ -0,0 +1,72 @@
+package helm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChartTestResults(t *testing.T) {
+	t.Parallel()
+
+	out := `{
+  "name": "nginx-abc123",
+  "namespace": "tests",
+  "version": 1,
+  "hooks": [
+    {
+      "name": "nginx-abc123-test-slow",
+      "kind": "Pod",
+      "path": "nginx/templates/tests/test-slow.yaml",
+      "events": ["test"],
+      "last_run": {"started_at": "", "completed_at": "", "phase": ""},
+      "weight": 5
+    },
+    {
+      "name": "nginx-abc123-migrate",
+      "kind": "Job",
+      "path": "nginx/templates/migrate.yaml",
+      "events": ["pre-install"],
+      "last_run": {"started_at": "2023-11-20T10:00:00Z", "completed_at": "2023-11-20T10:00:05Z", "phase": "Succeeded"},
+      "weight": 0
+    },
+    {
+      "name": "nginx-abc123-test-connection",
+      "kind": "Pod",
+      "path": "nginx/templates/tests/test-connection.yaml",
+      "events": ["test"],
+      "last_run": {"started_at": "2023-11-20T10:10:00.5Z", "completed_at": "2023-11-20T10:10:03Z", "phase": "Failed"},
+      "weight": 0
+    },
+    {
+      "name": "nginx-abc123-test-stale",
+      "kind": "Pod",
+      "path": "nginx/templates/tests/test-stale.yaml",
+      "events": ["test"],
+      "last_run": {"started_at": "2023-11-19T10:00:00Z", "completed_at": "2023-11-19T10:00:01Z", "phase": "Succeeded"},
+      "weight": 1
+    }
+  ]
+}`
+	startedAt := time.Date(2023, 11, 20, 10, 10, 0, 0, time.UTC)
+	results, namespace, err := parseChartTestResultsE(out, startedAt)
+	require.NoError(t, err)
+	assert.Equal(t, "tests", namespace)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "nginx-abc123-test-connection", results[0].Name)
+	assert.Equal(t, "nginx/templates/tests/test-connection.yaml", results[0].Path)
+	assert.Equal(t, ChartTestPhaseFailed, results[0].Phase)
+	assert.Equal(t, 2500*time.Millisecond, results[0].Duration)
+	assert.False(t, results[0].Succeeded())
+
+	assert.Equal(t, "nginx-abc123-test-stale", results[1].Name)
+	assert.Equal(t, ChartTestPhaseNotRun, results[1].Phase)
+
+	assert.Equal(t, "nginx-abc123-test-slow", results[2].Name)
+	assert.Equal(t, ChartTestPhaseNotRun, results[2].Phase)
+	assert.True(t, results[2].StartedAt.IsZero())
+	assert.Equal(t, time.Duration(0), results[2].Duration)
+}
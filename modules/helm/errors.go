@@ -2,6 +2,7 @@ package helm
 
 import (
 	"fmt"
+	"strings"
 )
 
 // ValuesFileNotFoundError is returned when a provided values file input is not found on the host path.
@@ -40,3 +41,69 @@ type ChartNotFoundError struct {
 func (err ChartNotFoundError) Error() string {
 	return fmt.Sprintf("Could not chart path %s", err.Path)
 }
+
+// ChartArchiveNotFoundError is returned when `helm package` did not produce exactly one chart archive
+type ChartArchiveNotFoundError struct {
+	ChartDir    string
+	Destination string
+}
+
+func (err ChartArchiveNotFoundError) Error() string {
+	return fmt.Sprintf("Could not find the archive of chart %s in %s", err.ChartDir, err.Destination)
+}
+
+// UnexpectedPushOutputError is returned when the output of `helm push` does not contain the reference of the pushed chart
+type UnexpectedPushOutputError struct {
+	Output string
+}
+
+func (err UnexpectedPushOutputError) Error() string {
+	return fmt.Sprintf("Could not find the pushed chart reference in the output of helm push: %s", err.Output)
+}
+
+// ChartLintError is returned when `helm lint` fails on a chart
+type ChartLintError struct {
+	ChartDir string
+	Messages []LintMessage
+}
+
+func (err ChartLintError) Error() string {
+	lines := []string{}
+	for _, message := range err.Messages {
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", message.Severity, message.Path, message.Message))
+	}
+	return fmt.Sprintf("Chart %s failed to lint:\n%s", err.ChartDir, strings.Join(lines, "\n"))
+}
+
+// ValuesSchemaError is returned when the values do not meet the values.schema.json of a chart
+type ValuesSchemaError struct {
+	ChartDir   string
+	Violations []ValuesSchemaViolation
+}
+
+func (err ValuesSchemaError) Error() string {
+	lines := []string{}
+	for _, violation := range err.Violations {
+		lines = append(lines, fmt.Sprintf("%s: %s: %s", violation.Chart, violation.Path, violation.Message))
+	}
+	return fmt.Sprintf("Values of chart %s do not meet the schema:\n%s", err.ChartDir, strings.Join(lines, "\n"))
+}
+
+// InvalidSnapshotIgnorePathError is returned when a snapshot ignore path is not a valid JSON pointer
+type InvalidSnapshotIgnorePathError struct {
+	Path string
+}
+
+func (err InvalidSnapshotIgnorePathError) Error() string {
+	return fmt.Sprintf("Invalid snapshot ignore path %s: expected a JSON pointer such as /metadata/labels or Secret:/data/*", err.Path)
+}
+
+// InvalidSnapshotNameError is returned when a snapshot name is not a plain directory name, which could make the
+// snapshots escape the snapshot path.
+type InvalidSnapshotNameError struct {
+	Name string
+}
+
+func (err InvalidSnapshotNameError) Error() string {
+	return fmt.Sprintf("Invalid snapshot name %q: expected a plain directory name, without path separators", err.Name)
+}
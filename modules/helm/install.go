@@ -19,7 +19,7 @@ func Install(t testing.TestingT, options *Options, chart string, releaseName str
 // InstallE will install the selected helm chart with the provided options under the given release name.
 func InstallE(t testing.TestingT, options *Options, chart string, releaseName string) error {
 	// If the chart refers to a path, convert to absolute path. Otherwise, pass straight through as it may be a remote
-	// chart.
+	// chart, including a chart in an OCI registry (oci://...).
 	if files.FileExists(chart) {
 		absChartDir, err := filepath.Abs(chart)
 		if err != nil {
@@ -28,8 +28,8 @@ func InstallE(t testing.TestingT, options *Options, chart string, releaseName st
 		chart = absChartDir
 	}
 
-	// build chart dependencies
-	if options.BuildDependencies {
+	// build chart dependencies. Charts pulled from an OCI registry are packaged with their dependencies.
+	if options.BuildDependencies && !IsOCIReference(chart) {
 		if _, err := RunHelmCommandAndGetOutputE(t, options, "dependency", "build", chart); err != nil {
 			return errors.WithStackTrace(err)
 		}
@@ -0,0 +1,175 @@
+package helm
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// LintSeverity is the severity of a message reported by `helm lint`.
+type LintSeverity string
+
+const (
+	LintSeverityInfo    LintSeverity = "INFO"
+	LintSeverityWarning LintSeverity = "WARNING"
+	LintSeverityError   LintSeverity = "ERROR"
+)
+
+// LintMessage is a single message reported by `helm lint`.
+type LintMessage struct {
+	Severity LintSeverity
+	// Path is the file or directory of the chart the message is about, e.g. templates/deployment.yaml or Chart.yaml.
+	Path    string
+	Message string
+}
+
+// ValuesSchemaViolation is a value that does not meet the values.schema.json of a chart.
+type ValuesSchemaViolation struct {
+	// Chart is the name of the chart whose schema is violated, which is the name of a subchart for subchart values.
+	Chart string
+	// Path is the path of the invalid value as reported by helm, e.g. replicaCount, image.tag or (root).
+	Path    string
+	Message string
+}
+
+var lintMessageRegexp = regexp.MustCompile(`^\[(INFO|WARNING|ERROR)\] ([^:]*): (.*)$`)
+
+// schemaErrorHeader is how helm introduces the list of schema violations in its error output.
+const schemaErrorHeader = "values don't meet the specifications of the schema(s) in the following chart(s):"
+
+// Lint runs `helm lint` on the given chart and returns the reported messages. This will fail the test if the chart
+// fails to lint, listing all the errors.
+func Lint(t testing.TestingT, options *Options, chartDir string) []LintMessage {
+	messages, err := LintE(t, options, chartDir)
+	require.NoError(t, err)
+	return messages
+}
+
+// LintE runs `helm lint` on the given chart, with the values set in the options merged exactly as RenderTemplateE does,
+// and returns all the reported messages. If the chart fails to lint, the messages are returned along with a
+// ChartLintError listing the errors (and warnings when linting with --strict in Options.ExtraArgs["lint"]).
+func LintE(t testing.TestingT, options *Options, chartDir string) ([]LintMessage, error) {
+	args := getExtraArgs(options, "lint")
+	args, err := getValuesArgsE(t, options, args...)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, chartDir)
+
+	out, lintErr := RunHelmCommandAndGetOutputE(t, options, "lint", args...)
+	messages := parseLintOutput(out)
+	if lintErr == nil {
+		return messages, nil
+	}
+
+	failures := []LintMessage{}
+	for _, message := range messages {
+		if message.Severity != LintSeverityInfo {
+			failures = append(failures, message)
+		}
+	}
+	if len(failures) == 0 {
+		return messages, lintErr
+	}
+	return messages, ChartLintError{ChartDir: chartDir, Messages: failures}
+}
+
+// parseLintOutput parses the output of `helm lint`, which looks like:
+//
+// ==> Linting mychart
+// [INFO] Chart.yaml: icon is recommended
+// [ERROR] templates/: template: mychart/templates/deployment.yaml:12:3: executing ...
+//
+// Messages that span multiple lines are joined with newlines.
+func parseLintOutput(out string) []LintMessage {
+	messages := []LintMessage{}
+	inMessage := false
+	for _, line := range strings.Split(out, "\n") {
+		if matches := lintMessageRegexp.FindStringSubmatch(line); matches != nil {
+			messages = append(messages, LintMessage{
+				Severity: LintSeverity(matches[1]),
+				Path:     matches[2],
+				Message:  matches[3],
+			})
+			inMessage = true
+			continue
+		}
+		// A blank line or the next chart ends the current message
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "==>") {
+			inMessage = false
+			continue
+		}
+		if inMessage {
+			last := &messages[len(messages)-1]
+			last.Message += "\n" + line
+		}
+	}
+	return messages
+}
+
+// ValidateValues checks the values set in the options against the values.schema.json of the chart and its subcharts.
+// This will fail the test listing all the violations, if any.
+func ValidateValues(t testing.TestingT, options *Options, chartDir string) {
+	_, err := ValidateValuesE(t, options, chartDir)
+	require.NoError(t, err)
+}
+
+// ValidateValuesE checks the values set in the options against the values.schema.json of the chart and its subcharts,
+// without a cluster. The values are merged exactly as RenderTemplateE does, since the validation is done by helm while
+// rendering the chart. It returns all the violations, along with a ValuesSchemaError if there is any. Charts without a
+// values.schema.json have no violations.
+func ValidateValuesE(t testing.TestingT, options *Options, chartDir string) ([]ValuesSchemaViolation, error) {
+	_, err := RenderTemplateE(t, options, chartDir, "release-name", []string{})
+	if err == nil {
+		return []ValuesSchemaViolation{}, nil
+	}
+	violations := parseSchemaViolations(err.Error())
+	if len(violations) == 0 {
+		return nil, err
+	}
+	return violations, ValuesSchemaError{ChartDir: chartDir, Violations: violations}
+}
+
+// parseSchemaViolations extracts the schema violations from the error output of helm, which looks like:
+//
+// Error: values don't meet the specifications of the schema(s) in the following chart(s):
+// mychart:
+// - replicaCount: Invalid type. Expected: integer, given: string
+// - (root): image is required
+//
+// Recent versions of helm report the path of the violations as JSON pointers instead, e.g.
+// - at '/replicaCount': got string, want integer
+func parseSchemaViolations(out string) []ValuesSchemaViolation {
+	violations := []ValuesSchemaViolation{}
+	headerIndex := strings.Index(out, schemaErrorHeader)
+	if headerIndex < 0 {
+		return violations
+	}
+
+	chart := ""
+	for _, line := range strings.Split(out[headerIndex+len(schemaErrorHeader):], "\n") {
+		line = strings.TrimRight(line, " \r")
+		switch {
+		case strings.HasPrefix(line, "- "):
+			violation := strings.TrimPrefix(line, "- ")
+			path, message := "", violation
+			if strings.HasPrefix(violation, "at '") {
+				if end := strings.Index(violation, "': "); end > 0 {
+					path, message = violation[len("at '"):end], violation[end+len("': "):]
+				}
+			} else if separator := strings.Index(violation, ": "); separator > 0 {
+				path, message = violation[:separator], violation[separator+len(": "):]
+			}
+			violations = append(violations, ValuesSchemaViolation{Chart: chart, Path: path, Message: message})
+		case strings.HasSuffix(line, ":") && !strings.Contains(line, " "):
+			chart = strings.TrimSuffix(line, ":")
+		case strings.TrimSpace(line) != "" && len(violations) > 0:
+			// Violations with nested causes span multiple lines
+			violations[len(violations)-1].Message += "\n" + strings.TrimSpace(line)
+		}
+	}
+	return violations
+}
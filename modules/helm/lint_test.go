@@ -0,0 +1,69 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLintOutput(t *testing.T) {
+	t.Parallel()
+
+	out := `==> Linting ../../examples/helm-basic-example
+[INFO] Chart.yaml: icon is recommended
+[ERROR] templates/: values don't meet the specifications of the schema(s) in the following chart(s):
+helm-basic-example:
+- (root): containerImageTag is required
+
+[WARNING] templates/service.yaml: object name does not conform to Kubernetes naming requirements
+
+Error: 1 chart(s) linted, 1 chart(s) failed
+`
+	messages := parseLintOutput(out)
+	assert.Equal(t, []LintMessage{
+		{Severity: LintSeverityInfo, Path: "Chart.yaml", Message: "icon is recommended"},
+		{
+			Severity: LintSeverityError,
+			Path:     "templates/",
+			Message:  "values don't meet the specifications of the schema(s) in the following chart(s):\nhelm-basic-example:\n- (root): containerImageTag is required",
+		},
+		{Severity: LintSeverityWarning, Path: "templates/service.yaml", Message: "object name does not conform to Kubernetes naming requirements"},
+	}, messages)
+}
+
+func TestParseSchemaViolations(t *testing.T) {
+	t.Parallel()
+
+	out := `error while running command: exit status 1; Error: values don't meet the specifications of the schema(s) in the following chart(s):
+helm-dependency-example:
+- replicaCount: Invalid type. Expected: integer, given: string
+basic:
+- (root): containerImageTag is required
+- containerImageRepo: String length must be greater than or equal to 1
+`
+	assert.Equal(t, []ValuesSchemaViolation{
+		{Chart: "helm-dependency-example", Path: "replicaCount", Message: "Invalid type. Expected: integer, given: string"},
+		{Chart: "basic", Path: "(root)", Message: "containerImageTag is required"},
+		{Chart: "basic", Path: "containerImageRepo", Message: "String length must be greater than or equal to 1"},
+	}, parseSchemaViolations(out))
+}
+
+func TestParseSchemaViolationsWithJSONPointers(t *testing.T) {
+	t.Parallel()
+
+	out := `Error: values don't meet the specifications of the schema(s) in the following chart(s):
+helm-basic-example:
+- at '': missing property 'containerImageTag'
+- at '/containerImageRepo': minLength: got 0, want 1
+`
+	assert.Equal(t, []ValuesSchemaViolation{
+		{Chart: "helm-basic-example", Path: "", Message: "missing property 'containerImageTag'"},
+		{Chart: "helm-basic-example", Path: "/containerImageRepo", Message: "minLength: got 0, want 1"},
+	}, parseSchemaViolations(out))
+}
+
+func TestParseSchemaViolationsWithoutSchemaError(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, parseSchemaViolations("Error: template: helm-basic-example/templates/deployment.yaml:27:20: executing ..."))
+}
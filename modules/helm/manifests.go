@@ -0,0 +1,323 @@
+package helm
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// sourceCommentPrefix is the comment helm prepends to every rendered document to record its template.
+const sourceCommentPrefix = "# Source: "
+
+// RenderedObject is a single Kubernetes object rendered from a chart.
+type RenderedObject struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+	// Source is the template the object was rendered from, as reported by helm, e.g.
+	// mychart/templates/deployment.yaml or mychart/charts/subchart/templates/service.yaml.
+	Source string
+	// Object is the decoded object: a typed client-go object (e.g. *appsv1.Deployment) for the kinds registered in the
+	// client-go scheme, or *unstructured.Unstructured for other kinds, such as custom resources.
+	Object runtime.Object
+	// YAML is the raw rendered document.
+	YAML string
+}
+
+// RenderedContainer is a container (or init container) of a workload rendered from a chart.
+type RenderedContainer struct {
+	// Owner is the object whose pod template declares the container, e.g. a Deployment.
+	Owner     RenderedObject
+	Container corev1.Container
+	Init      bool
+}
+
+// RenderedManifests holds every object rendered from a chart, indexed by API group, kind, namespace and name, and by
+// source template.
+type RenderedManifests struct {
+	// Objects holds the rendered objects in the order helm rendered them.
+	Objects []RenderedObject
+
+	byKey    map[string]int
+	byKind   map[string][]int
+	bySource map[string][]int
+}
+
+// RenderManifests renders the chart with RenderTemplateE and parses the output. This will fail the test if there is an
+// error.
+func RenderManifests(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) *RenderedManifests {
+	manifests, err := RenderManifestsE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	require.NoError(t, err)
+	return manifests
+}
+
+// RenderManifestsE renders the chart with RenderTemplateE and parses the output with ParseRenderedManifestsE.
+func RenderManifestsE(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) (*RenderedManifests, error) {
+	out, err := RenderTemplateE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRenderedManifestsE(t, out)
+}
+
+// ParseRenderedManifests parses the multi-document output of `helm template`. This will fail the test if there is an
+// error.
+func ParseRenderedManifests(t testing.TestingT, yamlData string) *RenderedManifests {
+	manifests, err := ParseRenderedManifestsE(t, yamlData)
+	require.NoError(t, err)
+	return manifests
+}
+
+// ParseRenderedManifestsE parses the multi-document output of `helm template` (or `helm get manifest`), decoding every
+// document into a typed client-go object, or an unstructured object for kinds unknown to client-go such as custom
+// resources. Empty documents are skipped.
+func ParseRenderedManifestsE(t testing.TestingT, yamlData string) (*RenderedManifests, error) {
+	manifests := &RenderedManifests{
+		Objects:  []RenderedObject{},
+		byKey:    map[string]int{},
+		byKind:   map[string][]int{},
+		bySource: map[string][]int{},
+	}
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(strings.NewReader(yamlData)))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			return manifests, nil
+		}
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		object, err := decodeRenderedObjectE(string(document))
+		if err != nil {
+			return nil, err
+		}
+		if object != nil {
+			manifests.add(*object)
+		}
+	}
+}
+
+// decodeRenderedObjectE decodes a single rendered document, returning nil if the document is empty.
+func decodeRenderedObjectE(document string) (*RenderedObject, error) {
+	jsonData, err := yaml.YAMLToJSON([]byte(document))
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if string(jsonData) == "null" || string(jsonData) == "{}" {
+		return nil, nil
+	}
+
+	unstructuredObject := &unstructured.Unstructured{}
+	if err := unstructuredObject.UnmarshalJSON(jsonData); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	var object runtime.Object = unstructuredObject
+	typedObject, _, err := scheme.Codecs.UniversalDeserializer().Decode(jsonData, nil, nil)
+	if err == nil {
+		object = typedObject
+	} else if !runtime.IsNotRegisteredError(err) {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return &RenderedObject{
+		GroupVersionKind: unstructuredObject.GroupVersionKind(),
+		Namespace:        unstructuredObject.GetNamespace(),
+		Name:             unstructuredObject.GetName(),
+		Source:           getRenderedSource(document),
+		Object:           object,
+		YAML:             document,
+	}, nil
+}
+
+// getRenderedSource returns the template path from the source comment of a rendered document, if any.
+func getRenderedSource(document string) string {
+	for _, line := range strings.Split(document, "\n") {
+		if strings.HasPrefix(line, sourceCommentPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, sourceCommentPrefix))
+		}
+	}
+	return ""
+}
+
+// add appends the object and indexes it.
+func (manifests *RenderedManifests) add(object RenderedObject) {
+	index := len(manifests.Objects)
+	manifests.Objects = append(manifests.Objects, object)
+	manifests.byKey[renderedObjectKey(object.GroupVersionKind.GroupKind(), object.Namespace, object.Name)] = index
+	kindKey := renderedObjectKey(schema.GroupKind{Kind: object.GroupVersionKind.Kind}, object.Namespace, object.Name)
+	manifests.byKind[kindKey] = append(manifests.byKind[kindKey], index)
+	if object.Source != "" {
+		manifests.bySource[object.Source] = append(manifests.bySource[object.Source], index)
+	}
+}
+
+// renderedObjectKey returns the key of an object in the group/kind/namespace/name index. The API group is part of the
+// key, as kinds of different groups may have the same name, e.g. Service and Service.serving.knative.dev.
+func renderedObjectKey(groupKind schema.GroupKind, namespace string, name string) string {
+	return strings.ToLower(groupKind.String()) + "/" + namespace + "/" + name
+}
+
+// Get returns the object with the given kind, namespace and name, and whether it was found. The kind can be qualified
+// with its API group as in kubectl, e.g. Deployment.apps or Certificate.cert-manager.io. An unqualified kind, e.g.
+// Deployment, matches the kind of the core API group if there is one, or else the kind of any group, as long as a
+// single object matches. Use an empty namespace for cluster-scoped objects, and for namespaced objects the chart
+// renders without a namespace.
+func (manifests *RenderedManifests) Get(kind string, namespace string, name string) (RenderedObject, bool) {
+	groupKind := schema.ParseGroupKind(kind)
+	if object, found := manifests.GetByGroupKind(groupKind, namespace, name); found || groupKind.Group != "" {
+		return object, found
+	}
+	indexes := manifests.byKind[renderedObjectKey(groupKind, namespace, name)]
+	if len(indexes) != 1 {
+		return RenderedObject{}, false
+	}
+	return manifests.Objects[indexes[0]], true
+}
+
+// GetByGroupKind returns the object with the given API group and kind, namespace and name, and whether it was found.
+// Use an empty group for the core API group, e.g. for Service.
+func (manifests *RenderedManifests) GetByGroupKind(groupKind schema.GroupKind, namespace string, name string) (RenderedObject, bool) {
+	index, ok := manifests.byKey[renderedObjectKey(groupKind, namespace, name)]
+	if !ok {
+		return RenderedObject{}, false
+	}
+	return manifests.Objects[index], true
+}
+
+// ByKind returns all the objects of the given kind (e.g. Deployment), in render order.
+func (manifests *RenderedManifests) ByKind(kind string) []RenderedObject {
+	objects := []RenderedObject{}
+	for _, object := range manifests.Objects {
+		if strings.EqualFold(object.GroupVersionKind.Kind, kind) {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// BySource returns all the objects rendered from the given template. The template can be given as reported by helm
+// (mychart/templates/deployment.yaml) or relative to the chart, as for RenderTemplateE (templates/deployment.yaml or
+// charts/subchart/templates/service.yaml).
+func (manifests *RenderedManifests) BySource(templateFile string) []RenderedObject {
+	objects := []RenderedObject{}
+	for _, object := range manifests.Objects {
+		source := object.Source
+		if source == "" {
+			continue
+		}
+		relativeSource := source
+		if separator := strings.Index(source, "/"); separator >= 0 {
+			relativeSource = source[separator+1:]
+		}
+		if source == templateFile || relativeSource == templateFile {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// Sources returns the templates that rendered at least one object, sorted.
+func (manifests *RenderedManifests) Sources() []string {
+	sources := make([]string, 0, len(manifests.bySource))
+	for source := range manifests.bySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Containers returns the containers and init containers of every rendered workload: pods, the kinds with a pod
+// template in the client-go scheme (e.g. Deployment, StatefulSet, DaemonSet, Job or CronJob), and custom resources
+// with a pod template under spec.template, such as Argo Rollouts.
+func (manifests *RenderedManifests) Containers() []RenderedContainer {
+	containers := []RenderedContainer{}
+	for _, object := range manifests.Objects {
+		podSpec := getRenderedPodSpec(object.Object)
+		if podSpec == nil {
+			continue
+		}
+		for _, container := range podSpec.InitContainers {
+			containers = append(containers, RenderedContainer{Owner: object, Container: container, Init: true})
+		}
+		for _, container := range podSpec.Containers {
+			containers = append(containers, RenderedContainer{Owner: object, Container: container})
+		}
+	}
+	return containers
+}
+
+// ContainersWithoutResourceLimits returns the containers that do not set both a CPU and a memory limit.
+func (manifests *RenderedManifests) ContainersWithoutResourceLimits() []RenderedContainer {
+	containers := []RenderedContainer{}
+	for _, container := range manifests.Containers() {
+		limits := container.Container.Resources.Limits
+		if limits.Cpu().IsZero() || limits.Memory().IsZero() {
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+// Images returns the images used by all the rendered containers, deduplicated and sorted.
+func (manifests *RenderedManifests) Images() []string {
+	images := map[string]bool{}
+	for _, container := range manifests.Containers() {
+		images[container.Container.Image] = true
+	}
+	sortedImages := make([]string, 0, len(images))
+	for image := range images {
+		sortedImages = append(sortedImages, image)
+	}
+	sort.Strings(sortedImages)
+	return sortedImages
+}
+
+// getRenderedPodSpec returns the pod spec of a workload object, or nil if the object is not a workload.
+func getRenderedPodSpec(object runtime.Object) *corev1.PodSpec {
+	switch typedObject := object.(type) {
+	case *corev1.Pod:
+		return &typedObject.Spec
+	case *corev1.ReplicationController:
+		if typedObject.Spec.Template != nil {
+			return &typedObject.Spec.Template.Spec
+		}
+	case *appsv1.Deployment:
+		return &typedObject.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return &typedObject.Spec.Template.Spec
+	case *appsv1.DaemonSet:
+		return &typedObject.Spec.Template.Spec
+	case *appsv1.ReplicaSet:
+		return &typedObject.Spec.Template.Spec
+	case *batchv1.Job:
+		return &typedObject.Spec.Template.Spec
+	case *batchv1.CronJob:
+		return &typedObject.Spec.JobTemplate.Spec.Template.Spec
+	case *unstructured.Unstructured:
+		podSpecObject, found, err := unstructured.NestedMap(typedObject.Object, "spec", "template", "spec")
+		if err != nil || !found {
+			return nil
+		}
+		podSpec := &corev1.PodSpec{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpecObject, podSpec); err != nil {
+			return nil
+		}
+		return podSpec
+	}
+	return nil
+}
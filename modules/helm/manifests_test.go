@@ -0,0 +1,182 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const exampleRenderedManifests = `---
+# Source: myapp/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: myapp
+---
+# Source: myapp/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+  namespace: prod
+spec:
+  ports:
+  - port: 80
+---
+# Source: myapp/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  namespace: prod
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: myapp:1.0.0
+      containers:
+      - name: app
+        image: myapp:1.0.0
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+      - name: sidecar
+        image: envoyproxy/envoy:v1.28.0
+        resources:
+          limits:
+            cpu: 100m
+---
+# Source: myapp/templates/disabled.yaml
+# This template rendered nothing
+---
+# Source: myapp/charts/cron/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: myapp-cleanup
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: busybox:1.36
+---
+# Source: myapp/templates/rollout.yaml
+apiVersion: argoproj.io/v1alpha1
+kind: Rollout
+metadata:
+  name: myapp-canary
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: myapp:1.1.0
+`
+
+func TestParseRenderedManifests(t *testing.T) {
+	t.Parallel()
+
+	manifests := ParseRenderedManifests(t, exampleRenderedManifests)
+	require.Len(t, manifests.Objects, 5)
+
+	object, found := manifests.Get("Deployment", "prod", "myapp")
+	require.True(t, found)
+	assert.Equal(t, "myapp/templates/deployment.yaml", object.Source)
+	deployment, ok := object.Object.(*appsv1.Deployment)
+	require.True(t, ok)
+	assert.Len(t, deployment.Spec.Template.Spec.Containers, 2)
+
+	_, found = manifests.Get("Deployment", "", "myapp")
+	assert.False(t, found)
+
+	object, found = manifests.Get("rollout", "prod", "myapp-canary")
+	require.True(t, found)
+	assert.IsType(t, &unstructured.Unstructured{}, object.Object)
+	assert.Equal(t, "argoproj.io", object.GroupVersionKind.Group)
+
+	_, found = manifests.Get("Deployment.apps", "prod", "myapp")
+	assert.True(t, found)
+	_, found = manifests.Get("Deployment.extensions", "prod", "myapp")
+	assert.False(t, found)
+
+	assert.Len(t, manifests.ByKind("Service"), 1)
+	assert.Len(t, manifests.BySource("templates/deployment.yaml"), 1)
+	assert.Len(t, manifests.BySource("myapp/charts/cron/templates/cronjob.yaml"), 1)
+	assert.Len(t, manifests.BySource("charts/cron/templates/cronjob.yaml"), 1)
+	assert.Empty(t, manifests.BySource("templates/disabled.yaml"))
+	assert.Equal(t, []string{
+		"myapp/charts/cron/templates/cronjob.yaml",
+		"myapp/templates/deployment.yaml",
+		"myapp/templates/rollout.yaml",
+		"myapp/templates/service.yaml",
+		"myapp/templates/serviceaccount.yaml",
+	}, manifests.Sources())
+}
+
+func TestRenderedManifestsKindsOfDifferentGroups(t *testing.T) {
+	t.Parallel()
+
+	manifests := ParseRenderedManifests(t, `---
+apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: myapp
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: myapp
+---
+apiVersion: example.com/v1
+kind: Certificate
+metadata:
+  name: myapp
+`)
+	require.Len(t, manifests.Objects, 4)
+
+	object, found := manifests.Get("Service.serving.knative.dev", "", "myapp")
+	require.True(t, found)
+	assert.Equal(t, "serving.knative.dev", object.GroupVersionKind.Group)
+
+	// An unqualified kind matches the core group first
+	object, found = manifests.Get("Service", "", "myapp")
+	require.True(t, found)
+	assert.Equal(t, "", object.GroupVersionKind.Group)
+
+	// An unqualified kind that matches several groups is ambiguous
+	_, found = manifests.Get("Certificate", "", "myapp")
+	assert.False(t, found)
+	object, found = manifests.GetByGroupKind(schema.GroupKind{Group: "cert-manager.io", Kind: "Certificate"}, "", "myapp")
+	require.True(t, found)
+	assert.Equal(t, "cert-manager.io", object.GroupVersionKind.Group)
+}
+
+func TestRenderedManifestsContainers(t *testing.T) {
+	t.Parallel()
+
+	manifests := ParseRenderedManifests(t, exampleRenderedManifests)
+	assert.Len(t, manifests.Containers(), 5)
+	assert.Equal(t, []string{"busybox:1.36", "envoyproxy/envoy:v1.28.0", "myapp:1.0.0", "myapp:1.1.0"}, manifests.Images())
+
+	names := []string{}
+	for _, container := range manifests.ContainersWithoutResourceLimits() {
+		names = append(names, container.Owner.Name+"/"+container.Container.Name)
+	}
+	assert.Equal(t, []string{"myapp/migrate", "myapp/sidecar", "myapp-cleanup/cleanup", "myapp-canary/app"}, names)
+}
@@ -0,0 +1,172 @@
+package helm
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// OCIReferencePrefix is the scheme of chart references that point to an OCI registry, e.g.
+// oci://registry.example.com/charts/mychart.
+const OCIReferencePrefix = "oci://"
+
+// PushedChart describes a chart pushed to an OCI registry with PushChartE.
+type PushedChart struct {
+	// Reference is the OCI reference of the chart without the version, e.g. oci://localhost:5000/charts/mychart. It
+	// can be passed as the chart to Install, Upgrade and RenderRemoteTemplate, with Options.Version set to Version.
+	Reference string
+	Version   string
+	// Digest is the digest of the chart manifest in the registry, e.g. sha256:1234...
+	Digest string
+}
+
+// IsOCIReference returns true if the given chart reference points to an OCI registry.
+func IsOCIReference(chart string) bool {
+	return strings.HasPrefix(chart, OCIReferencePrefix)
+}
+
+// RegistryLogin logs in to the given OCI registry (e.g. localhost:5000). This will fail the test if there is an error.
+func RegistryLogin(t testing.TestingT, options *Options, registry string, username string, password string) {
+	require.NoError(t, RegistryLoginE(t, options, registry, username, password))
+}
+
+// RegistryLoginE logs in to the given OCI registry (e.g. localhost:5000) by running `helm registry login`. The password
+// is passed on stdin so that it is not logged. The credentials are stored in the registry config of helm, which can be
+// isolated with the HELM_REGISTRY_CONFIG variable in Options.EnvVars.
+func RegistryLoginE(t testing.TestingT, options *Options, registry string, username string, password string) error {
+	args := []string{"login"}
+	args = append(args, getExtraArgs(options, "registryLogin")...)
+	args = append(args, strings.TrimPrefix(registry, OCIReferencePrefix), "--username", username, "--password-stdin")
+
+	helmCmd := prepareHelmCommand(t, options, "registry", args...)
+	helmCmd.Stdin = strings.NewReader(password)
+	_, err := shell.RunCommandAndGetOutputE(t, helmCmd)
+	return err
+}
+
+// RegistryLogout logs out of the given OCI registry. This will fail the test if there is an error.
+func RegistryLogout(t testing.TestingT, options *Options, registry string) {
+	require.NoError(t, RegistryLogoutE(t, options, registry))
+}
+
+// RegistryLogoutE logs out of the given OCI registry by running `helm registry logout`.
+func RegistryLogoutE(t testing.TestingT, options *Options, registry string) error {
+	_, err := RunHelmCommandAndGetOutputE(t, options, "registry", "logout", strings.TrimPrefix(registry, OCIReferencePrefix))
+	return err
+}
+
+// PushChart packages the given chart directory and pushes it to the given OCI repository. This will fail the test if
+// there is an error.
+func PushChart(t testing.TestingT, options *Options, chartDir string, repository string) PushedChart {
+	pushedChart, err := PushChartE(t, options, chartDir, repository)
+	require.NoError(t, err)
+	return pushedChart
+}
+
+// PushChartE packages the given chart directory with `helm package` and pushes the archive with `helm push` to the given
+// OCI repository, e.g. oci://localhost:5000/charts. If Options.Version is set, it overrides the version of the chart.
+// Chart dependencies are packaged when Options.BuildDependencies is set.
+func PushChartE(t testing.TestingT, options *Options, chartDir string, repository string) (PushedChart, error) {
+	if !IsOCIReference(repository) {
+		repository = OCIReferencePrefix + repository
+	}
+
+	archiveDir, err := os.MkdirTemp("", "terratest-helm-package")
+	if err != nil {
+		return PushedChart{}, errors.WithStackTrace(err)
+	}
+	defer os.RemoveAll(archiveDir)
+
+	archivePath, err := packageChartE(t, options, chartDir, archiveDir)
+	if err != nil {
+		return PushedChart{}, err
+	}
+
+	args := getExtraArgs(options, "push")
+	args = append(args, archivePath, repository)
+	out, err := RunHelmCommandAndGetOutputE(t, options, "push", args...)
+	if err != nil {
+		return PushedChart{}, err
+	}
+	return parsePushOutputE(out)
+}
+
+// packageChartE runs `helm package` on the given chart directory and returns the path of the chart archive.
+func packageChartE(t testing.TestingT, options *Options, chartDir string, destination string) (string, error) {
+	args := getExtraArgs(options, "package")
+	if options.Version != "" {
+		args = append(args, "--version", options.Version)
+	}
+	if options.BuildDependencies {
+		args = append(args, "--dependency-update")
+	}
+	args = append(args, "--destination", destination, chartDir)
+	if _, err := RunHelmCommandAndGetOutputE(t, options, "package", args...); err != nil {
+		return "", err
+	}
+
+	archives, err := filepath.Glob(filepath.Join(destination, "*.tgz"))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	if len(archives) != 1 {
+		return "", errors.WithStackTrace(ChartArchiveNotFoundError{ChartDir: chartDir, Destination: destination})
+	}
+	return archives[0], nil
+}
+
+// parsePushOutputE parses the output of `helm push`, which looks like:
+//
+// Pushed: localhost:5000/charts/mychart:0.1.0
+// Digest: sha256:1234...
+func parsePushOutputE(out string) (PushedChart, error) {
+	pushedChart := PushedChart{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if ref := strings.TrimPrefix(line, "Pushed: "); ref != line {
+			separator := strings.LastIndex(ref, ":")
+			if separator <= strings.LastIndex(ref, "/") {
+				return PushedChart{}, errors.WithStackTrace(UnexpectedPushOutputError{Output: out})
+			}
+			pushedChart.Reference = OCIReferencePrefix + ref[:separator]
+			pushedChart.Version = ref[separator+1:]
+		}
+		if digest := strings.TrimPrefix(line, "Digest: "); digest != line {
+			pushedChart.Digest = digest
+		}
+	}
+	if pushedChart.Reference == "" {
+		return PushedChart{}, errors.WithStackTrace(UnexpectedPushOutputError{Output: out})
+	}
+	return pushedChart, nil
+}
+
+// PullChart downloads the given chart and extracts it into the destination directory, returning the path of the chart
+// directory. This will fail the test if there is an error.
+func PullChart(t testing.TestingT, options *Options, chart string, destination string) string {
+	chartDir, err := PullChartE(t, options, chart, destination)
+	require.NoError(t, err)
+	return chartDir
+}
+
+// PullChartE downloads the given chart (e.g. oci://localhost:5000/charts/mychart) with `helm pull` and extracts it into
+// the destination directory, returning the path of the chart directory so that it can be passed to RenderTemplate.
+// Options.Version selects the version of the chart to pull; otherwise, the latest version is pulled.
+func PullChartE(t testing.TestingT, options *Options, chart string, destination string) (string, error) {
+	args := getExtraArgs(options, "pull")
+	if options.Version != "" {
+		args = append(args, "--version", options.Version)
+	}
+	args = append(args, "--untar", "--destination", destination, chart)
+	if _, err := RunHelmCommandAndGetOutputE(t, options, "pull", args...); err != nil {
+		return "", err
+	}
+	return filepath.Join(destination, path.Base(chart)), nil
+}
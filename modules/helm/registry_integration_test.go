@@ -0,0 +1,96 @@
+//go:build kubeall || helm
+// +build kubeall helm
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests, and further differentiate helm
+// tests. This is done because minikube is heavy and can interfere with docker related tests in terratest. Similarly,
+// helm can overload the minikube system and thus interfere with the other kubernetes tests. To avoid overloading the
+// system, we run the kubernetes tests and helm tests separately from the others.
+
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/gruntwork-io/terratest/modules/docker"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+// Test that we can push a chart to an OCI registry, then pull, render and install it from there.
+func TestOCIRegistryPushPullInstall(t *testing.T) {
+	t.Parallel()
+
+	// Start a throwaway OCI registry on a random host port
+	containerName := fmt.Sprintf("terratest-registry-%s", strings.ToLower(random.UniqueId()))
+	docker.Run(t, "registry:2", &docker.RunOptions{
+		Name:         containerName,
+		Detach:       true,
+		Remove:       true,
+		OtherOptions: []string{"--publish", "5000"},
+	})
+	defer docker.Stop(t, []string{containerName}, &docker.StopOptions{})
+	registryPort := docker.Inspect(t, containerName).GetExposedHostPort(5000)
+	registry := fmt.Sprintf("localhost:%d", registryPort)
+
+	namespaceName := fmt.Sprintf(
+		"%s-%s",
+		strings.ToLower(t.Name()),
+		strings.ToLower(random.UniqueId()),
+	)
+	kubectlOptions := k8s.NewKubectlOptions("", "", namespaceName)
+	defer k8s.DeleteNamespace(t, kubectlOptions, namespaceName)
+	k8s.CreateNamespace(t, kubectlOptions, namespaceName)
+
+	// Use a dedicated registry config so that the login does not touch the user's credentials
+	registryConfigDir := t.TempDir()
+	options := &Options{
+		KubectlOptions: kubectlOptions,
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+			"containerImageTag":  "1.15.8",
+		},
+		EnvVars: map[string]string{
+			"HELM_REGISTRY_CONFIG": filepath.Join(registryConfigDir, "config.json"),
+		},
+		Version: "0.1.0",
+	}
+
+	// The registry does not require authentication, but accepts any credentials
+	RegistryLogin(t, options, registry, "terratest", "terratest")
+	defer RegistryLogout(t, options, registry)
+	_, err := os.Stat(filepath.Join(registryConfigDir, "config.json"))
+	require.NoError(t, err)
+
+	pushedChart := PushChart(t, options, "../../examples/helm-basic-example", fmt.Sprintf("oci://%s/charts", registry))
+	assert.Equal(t, fmt.Sprintf("oci://%s/charts/helm-basic-example", registry), pushedChart.Reference)
+	assert.Equal(t, "0.1.0", pushedChart.Version)
+	assert.True(t, strings.HasPrefix(pushedChart.Digest, "sha256:"))
+
+	chartDir := PullChart(t, options, pushedChart.Reference, t.TempDir())
+	assert.FileExists(t, filepath.Join(chartDir, "Chart.yaml"))
+
+	// Render the chart both from the pulled copy and straight from the registry
+	releaseName := fmt.Sprintf("helm-basic-example-%s", strings.ToLower(random.UniqueId()))
+	for _, chart := range []string{chartDir, pushedChart.Reference} {
+		output := RenderTemplate(t, options, chart, releaseName, []string{"templates/deployment.yaml"})
+		var deployment appsv1.Deployment
+		UnmarshalK8SYaml(t, output, &deployment)
+		assert.Equal(t, "nginx:1.15.8", deployment.Spec.Template.Spec.Containers[0].Image)
+	}
+
+	defer Delete(t, options, releaseName, true)
+	Install(t, options, pushedChart.Reference, releaseName)
+	Upgrade(t, options, pushedChart.Reference, releaseName)
+	status := GetReleaseStatus(t, options, releaseName)
+	assert.Equal(t, "helm-basic-example", status.ChartName)
+	assert.Equal(t, "0.1.0", status.ChartVersion)
+	assert.Equal(t, 2, status.Revision)
+}
@@ -0,0 +1,37 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOCIReference(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsOCIReference("oci://localhost:5000/charts/mychart"))
+	assert.False(t, IsOCIReference("bitnami/nginx"))
+	assert.False(t, IsOCIReference("../../examples/helm-basic-example"))
+}
+
+func TestParsePushOutput(t *testing.T) {
+	t.Parallel()
+
+	out := "Pushed: localhost:5000/charts/helm-basic-example:0.0.1\nDigest: sha256:0123456789abcdef\n"
+	pushedChart, err := parsePushOutputE(out)
+	require.NoError(t, err)
+	assert.Equal(t, PushedChart{
+		Reference: "oci://localhost:5000/charts/helm-basic-example",
+		Version:   "0.0.1",
+		Digest:    "sha256:0123456789abcdef",
+	}, pushedChart)
+}
+
+func TestParsePushOutputWithoutReference(t *testing.T) {
+	t.Parallel()
+
+	_, err := parsePushOutputE("Error: unexpected status from HEAD request\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Could not find the pushed chart reference")
+}
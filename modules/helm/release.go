@@ -0,0 +1,194 @@
+package helm
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ReleaseStatus is the state of a helm release at its current revision, as reported by `helm status`.
+type ReleaseStatus struct {
+	Name      string
+	Namespace string
+	Revision  int
+	// Status is the helm status of the release, e.g. deployed, failed, superseded or pending-upgrade.
+	Status        string
+	Description   string
+	Notes         string
+	ChartName     string
+	ChartVersion  string
+	AppVersion    string
+	FirstDeployed time.Time
+	LastDeployed  time.Time
+}
+
+// ReleaseRevision is an entry of the release history, as reported by `helm history`.
+type ReleaseRevision struct {
+	Revision    int       `json:"revision"`
+	Updated     time.Time `json:"updated"`
+	Status      string    `json:"status"`
+	Chart       string    `json:"chart"`
+	AppVersion  string    `json:"app_version"`
+	Description string    `json:"description"`
+}
+
+// rawRelease is the subset of the release object printed by `helm status -o json` that ReleaseStatus is built from.
+type rawRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		FirstDeployed time.Time `json:"first_deployed"`
+		LastDeployed  time.Time `json:"last_deployed"`
+		Description   string    `json:"description"`
+		Status        string    `json:"status"`
+		Notes         string    `json:"notes"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// GetReleaseStatus returns the status of the given release at its current revision. This will fail the test if there
+// is an error.
+func GetReleaseStatus(t testing.TestingT, options *Options, releaseName string) *ReleaseStatus {
+	status, err := GetReleaseStatusE(t, options, releaseName)
+	require.NoError(t, err)
+	return status
+}
+
+// GetReleaseStatusE returns the status of the given release at its current revision, by running
+// `helm status RELEASE -o json`.
+func GetReleaseStatusE(t testing.TestingT, options *Options, releaseName string) (*ReleaseStatus, error) {
+	args := getExtraArgs(options, "status")
+	args = append(args, releaseName, "--output", "json")
+	out, err := RunHelmCommandAndGetStdOutE(t, options, "status", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseReleaseStatusE(out)
+}
+
+// parseReleaseStatusE parses the JSON output of `helm status`.
+func parseReleaseStatusE(out string) (*ReleaseStatus, error) {
+	var release rawRelease
+	if err := json.Unmarshal([]byte(out), &release); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return &ReleaseStatus{
+		Name:          release.Name,
+		Namespace:     release.Namespace,
+		Revision:      release.Version,
+		Status:        release.Info.Status,
+		Description:   release.Info.Description,
+		Notes:         release.Info.Notes,
+		ChartName:     release.Chart.Metadata.Name,
+		ChartVersion:  release.Chart.Metadata.Version,
+		AppVersion:    release.Chart.Metadata.AppVersion,
+		FirstDeployed: release.Info.FirstDeployed,
+		LastDeployed:  release.Info.LastDeployed,
+	}, nil
+}
+
+// GetReleaseHistory returns every revision of the given release, oldest first. This will fail the test if there is an
+// error.
+func GetReleaseHistory(t testing.TestingT, options *Options, releaseName string) []ReleaseRevision {
+	history, err := GetReleaseHistoryE(t, options, releaseName)
+	require.NoError(t, err)
+	return history
+}
+
+// GetReleaseHistoryE returns every revision of the given release, oldest first, by running
+// `helm history RELEASE -o json`. Note that helm only keeps the last 10 revisions by default (see --history-max).
+func GetReleaseHistoryE(t testing.TestingT, options *Options, releaseName string) ([]ReleaseRevision, error) {
+	args := getExtraArgs(options, "history")
+	args = append(args, releaseName, "--output", "json")
+	out, err := RunHelmCommandAndGetStdOutE(t, options, "history", args...)
+	if err != nil {
+		return nil, err
+	}
+	history := []ReleaseRevision{}
+	if err := json.Unmarshal([]byte(out), &history); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return history, nil
+}
+
+// GetReleaseValues returns the values of the given release. See GetReleaseValuesE for a description of the
+// arguments. This will fail the test if there is an error.
+func GetReleaseValues(t testing.TestingT, options *Options, releaseName string, revision int, allValues bool) map[string]interface{} {
+	values, err := GetReleaseValuesE(t, options, releaseName, revision, allValues)
+	require.NoError(t, err)
+	return values
+}
+
+// GetReleaseValuesE returns the values of the given release, by running `helm get values RELEASE -o json`. Use 0 for
+// the revision to get the values of the current revision. If allValues is false, only the values supplied by the user
+// are returned; otherwise, the computed values (chart defaults merged with user values) are returned.
+func GetReleaseValuesE(t testing.TestingT, options *Options, releaseName string, revision int, allValues bool) (map[string]interface{}, error) {
+	args := append([]string{"values"}, getExtraArgs(options, "get")...)
+	args = append(args, releaseName, "--output", "json")
+	args = append(args, getRevisionArgs(revision)...)
+	if allValues {
+		args = append(args, "--all")
+	}
+	out, err := RunHelmCommandAndGetStdOutE(t, options, "get", args...)
+	if err != nil {
+		return nil, err
+	}
+	// helm prints null when the user did not supply any values
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(out), &values); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// GetReleaseManifest returns the rendered manifests of the given release. Use 0 for the revision to get the manifests
+// of the current revision. This will fail the test if there is an error.
+func GetReleaseManifest(t testing.TestingT, options *Options, releaseName string, revision int) string {
+	manifest, err := GetReleaseManifestE(t, options, releaseName, revision)
+	require.NoError(t, err)
+	return manifest
+}
+
+// GetReleaseManifestE returns the rendered manifests of the given release, by running `helm get manifest RELEASE`. Use
+// 0 for the revision to get the manifests of the current revision. The output is a multi-document YAML string in the
+// same format as RenderTemplateE.
+func GetReleaseManifestE(t testing.TestingT, options *Options, releaseName string, revision int) (string, error) {
+	args := append([]string{"manifest"}, getExtraArgs(options, "get")...)
+	args = append(args, releaseName)
+	args = append(args, getRevisionArgs(revision)...)
+	return RunHelmCommandAndGetStdOutE(t, options, "get", args...)
+}
+
+// getExtraArgs returns the extra arguments set in the helm Options struct for the given command, if any.
+func getExtraArgs(options *Options, command string) []string {
+	args := []string{}
+	if options.ExtraArgs != nil {
+		if extraArgs, ok := options.ExtraArgs[command]; ok {
+			args = append(args, extraArgs...)
+		}
+	}
+	return args
+}
+
+// getRevisionArgs returns the args to select the given release revision, where 0 means the current revision.
+func getRevisionArgs(revision int) []string {
+	if revision <= 0 {
+		return []string{}
+	}
+	return []string{"--revision", strconv.Itoa(revision)}
+}
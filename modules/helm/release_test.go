@@ -0,0 +1,51 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReleaseStatus(t *testing.T) {
+	t.Parallel()
+
+	out := `{
+  "name": "nginx-abc123",
+  "info": {
+    "first_deployed": "2023-11-20T10:00:00.123456+01:00",
+    "last_deployed": "2023-11-20T10:05:00.123456+01:00",
+    "deleted": "",
+    "description": "Upgrade complete",
+    "status": "deployed",
+    "notes": "Thanks for installing nginx"
+  },
+  "chart": {
+    "metadata": {"name": "nginx", "version": "13.2.23", "appVersion": "1.23.3", "apiVersion": "v2"},
+    "templates": []
+  },
+  "config": {"replicaCount": 2},
+  "manifest": "---\n",
+  "version": 2,
+  "namespace": "default"
+}`
+	status, err := parseReleaseStatusE(out)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx-abc123", status.Name)
+	assert.Equal(t, "default", status.Namespace)
+	assert.Equal(t, 2, status.Revision)
+	assert.Equal(t, "deployed", status.Status)
+	assert.Equal(t, "Upgrade complete", status.Description)
+	assert.Equal(t, "Thanks for installing nginx", status.Notes)
+	assert.Equal(t, "nginx", status.ChartName)
+	assert.Equal(t, "13.2.23", status.ChartVersion)
+	assert.Equal(t, "1.23.3", status.AppVersion)
+	assert.True(t, status.LastDeployed.After(status.FirstDeployed))
+}
+
+func TestGetRevisionArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{}, getRevisionArgs(0))
+	assert.Equal(t, []string{"--revision", "3"}, getRevisionArgs(3))
+}
@@ -0,0 +1,375 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gonvenience/ytbx"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/homeport/dyff/pkg/dyff"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// SnapshotUpdateEnvVar is the environment variable that switches AssertSnapshot to update mode: when it is set to a
+// true value (e.g. TERRATEST_UPDATE_SNAPSHOTS=1 go test ./...), the snapshots are rewritten from the rendered manifests
+// instead of being compared, so that the snapshots of a whole test suite can be regenerated in one run.
+const SnapshotUpdateEnvVar = "TERRATEST_UPDATE_SNAPSHOTS"
+
+// SnapshotIgnoredValue replaces the values matched by Options.SnapshotIgnorePaths in resource snapshots.
+const SnapshotIgnoredValue = "<ignored>"
+
+const defaultSnapshotDir = "__snapshot__"
+
+// SnapshotChange is how a resource differs from its snapshot.
+type SnapshotChange string
+
+const (
+	// SnapshotChangeAdded is a rendered resource that has no snapshot.
+	SnapshotChangeAdded SnapshotChange = "added"
+	// SnapshotChangeRemoved is a snapshot of a resource that is no longer rendered.
+	SnapshotChangeRemoved SnapshotChange = "removed"
+	// SnapshotChangeModified is a rendered resource that differs from its snapshot.
+	SnapshotChangeModified SnapshotChange = "modified"
+)
+
+// ResourceSnapshotDiff is the difference between a rendered resource and its snapshot.
+type ResourceSnapshotDiff struct {
+	// File is the snapshot file of the resource.
+	File   string
+	Change SnapshotChange
+	// Report is the human readable dyff report of the differences, for modified resources.
+	Report string
+}
+
+// String returns a human readable description of the difference.
+func (diff ResourceSnapshotDiff) String() string {
+	if diff.Change != SnapshotChangeModified {
+		return fmt.Sprintf("%s: resource %s", diff.File, diff.Change)
+	}
+	return fmt.Sprintf("%s: resource modified\n%s", diff.File, diff.Report)
+}
+
+// IsSnapshotUpdateMode returns true if SnapshotUpdateEnvVar is set to a true value.
+func IsSnapshotUpdateMode() bool {
+	update, err := strconv.ParseBool(os.Getenv(SnapshotUpdateEnvVar))
+	return err == nil && update
+}
+
+// AssertSnapshot compares the rendered manifests with the per-resource snapshots stored under the given name, and fails
+// the test with the dyff report of every resource that was added, removed or modified. In update mode (see
+// SnapshotUpdateEnvVar), the snapshots are written instead. If there are no snapshots under that name, the test fails
+// unless in update mode, so that a missing snapshot cannot go unnoticed, e.g. in CI.
+func AssertSnapshot(t testing.TestingT, options *Options, yamlData string, snapshotName string) {
+	require.NoError(t, validateSnapshotNameE(snapshotName))
+	snapshotDir := getResourceSnapshotDir(options, snapshotName)
+	if IsSnapshotUpdateMode() {
+		require.NoError(t, UpdateResourceSnapshotsE(t, options, yamlData, snapshotName))
+		return
+	}
+	if !snapshotDirExists(snapshotDir) {
+		t.Errorf("No snapshot found in %s. Set %s=1 to write the snapshots.", snapshotDir, SnapshotUpdateEnvVar)
+		return
+	}
+
+	diffs, err := DiffAgainstResourceSnapshotsE(t, options, yamlData, snapshotName)
+	require.NoError(t, err)
+	if len(diffs) == 0 {
+		return
+	}
+	messages := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		messages = append(messages, diff.String())
+	}
+	t.Errorf(
+		"Rendered manifests do not match the %d snapshot(s) in %s. Set %s=1 to update the snapshots.\n%s",
+		len(diffs), snapshotDir, SnapshotUpdateEnvVar, strings.Join(messages, "\n"),
+	)
+}
+
+// UpdateResourceSnapshots writes one snapshot per rendered resource under the given name. This will fail the test if
+// there is an error.
+func UpdateResourceSnapshots(t testing.TestingT, options *Options, yamlData string, snapshotName string) {
+	require.NoError(t, UpdateResourceSnapshotsE(t, options, yamlData, snapshotName))
+}
+
+// UpdateResourceSnapshotsE writes one snapshot per rendered resource into the directory
+// SNAPSHOT_PATH/snapshotName, where SNAPSHOT_PATH is Options.SnapshotPath ($PWD/__snapshot__ by default). The values
+// matched by Options.SnapshotIgnorePaths are replaced with SnapshotIgnoredValue, and the snapshots of resources that
+// are no longer rendered are removed. The snapshot name must be a plain directory name, such as myapp or myapp-prod.
+func UpdateResourceSnapshotsE(t testing.TestingT, options *Options, yamlData string, snapshotName string) error {
+	if err := validateSnapshotNameE(snapshotName); err != nil {
+		return err
+	}
+	resources, err := getResourceSnapshotsE(t, options, yamlData)
+	if err != nil {
+		return err
+	}
+
+	snapshotDir := getResourceSnapshotDir(options, snapshotName)
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	// Only remove the snapshot files, in case the directory holds anything else
+	previousSnapshotFiles, err := filepath.Glob(filepath.Join(snapshotDir, "*.yaml"))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	for _, previousSnapshotFile := range previousSnapshotFiles {
+		if err := os.Remove(previousSnapshotFile); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	for _, fileName := range sortedKeys(resources) {
+		if err := os.WriteFile(filepath.Join(snapshotDir, fileName), []byte(resources[fileName]), 0644); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	options.Logger.Logf(t, "%d resource snapshot(s) written into directory: %s", len(resources), snapshotDir)
+	return nil
+}
+
+// DiffAgainstResourceSnapshots compares the rendered manifests with the per-resource snapshots stored under the given
+// name and returns the differences. This will fail the test if there is an error.
+func DiffAgainstResourceSnapshots(t testing.TestingT, options *Options, yamlData string, snapshotName string) []ResourceSnapshotDiff {
+	diffs, err := DiffAgainstResourceSnapshotsE(t, options, yamlData, snapshotName)
+	require.NoError(t, err)
+	return diffs
+}
+
+// DiffAgainstResourceSnapshotsE compares the rendered manifests with the per-resource snapshots written by
+// UpdateResourceSnapshotsE under the given name, ignoring the values matched by Options.SnapshotIgnorePaths. It returns
+// one entry per resource that was added, removed or modified, sorted by snapshot file.
+func DiffAgainstResourceSnapshotsE(t testing.TestingT, options *Options, yamlData string, snapshotName string) ([]ResourceSnapshotDiff, error) {
+	if err := validateSnapshotNameE(snapshotName); err != nil {
+		return nil, err
+	}
+	resources, err := getResourceSnapshotsE(t, options, yamlData)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotDir := getResourceSnapshotDir(options, snapshotName)
+	snapshotFiles, err := filepath.Glob(filepath.Join(snapshotDir, "*.yaml"))
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	snapshots := map[string]bool{}
+	for _, snapshotFile := range snapshotFiles {
+		snapshots[filepath.Base(snapshotFile)] = true
+	}
+
+	diffs := []ResourceSnapshotDiff{}
+	for fileName := range snapshots {
+		if _, rendered := resources[fileName]; !rendered {
+			diffs = append(diffs, ResourceSnapshotDiff{File: filepath.Join(snapshotDir, fileName), Change: SnapshotChangeRemoved})
+		}
+	}
+	for fileName, resource := range resources {
+		snapshotFile := filepath.Join(snapshotDir, fileName)
+		if !snapshots[fileName] {
+			diffs = append(diffs, ResourceSnapshotDiff{File: snapshotFile, Change: SnapshotChangeAdded})
+			continue
+		}
+		report, err := diffResourceAgainstSnapshotE(snapshotFile, resource)
+		if err != nil {
+			return nil, err
+		}
+		if report != "" {
+			diffs = append(diffs, ResourceSnapshotDiff{File: snapshotFile, Change: SnapshotChangeModified, Report: report})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].File < diffs[j].File })
+	return diffs, nil
+}
+
+// diffResourceAgainstSnapshotE compares a resource with its snapshot file using dyff, and returns the human readable
+// report of the differences, or an empty string if there is none.
+func diffResourceAgainstSnapshotE(snapshotFile string, resource string) (string, error) {
+	snapshot, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return diffYAMLDocumentsE(string(snapshot), resource)
+}
+
+// diffYAMLDocumentsE compares two YAML documents using dyff, and returns the human readable report of the differences,
+// or an empty string if there is none.
+func diffYAMLDocumentsE(fromYAML string, toYAML string) (string, error) {
+	fromDocuments, err := ytbx.LoadDocuments([]byte(fromYAML))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	toDocuments, err := ytbx.LoadDocuments([]byte(toYAML))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	from := ytbx.InputFile{Location: "from", Documents: fromDocuments}
+	to := ytbx.InputFile{Location: "to", Documents: toDocuments}
+
+	report, err := dyff.CompareInputFiles(from, to, dyff.KubernetesEntityDetection(false))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	if len(report.Diffs) == 0 {
+		return "", nil
+	}
+
+	var out bytes.Buffer
+	reportWriter := &dyff.HumanReport{Report: report, OmitHeader: true}
+	if err := reportWriter.WriteReport(&out); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// getResourceSnapshotsE splits the rendered manifests into one snapshot per resource, keyed by snapshot file name, with
+// the ignored values replaced.
+func getResourceSnapshotsE(t testing.TestingT, options *Options, yamlData string) (map[string]string, error) {
+	manifests, err := ParseRenderedManifestsE(t, yamlData)
+	if err != nil {
+		return nil, err
+	}
+	ignoreRules, err := parseSnapshotIgnoreRulesE(options.SnapshotIgnorePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := map[string]string{}
+	for _, object := range manifests.Objects {
+		jsonData, err := yaml.YAMLToJSON([]byte(object.YAML))
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		var resource interface{}
+		if err := json.Unmarshal(jsonData, &resource); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, rule := range ignoreRules {
+			if rule.kind == "" || strings.EqualFold(rule.kind, object.GroupVersionKind.Kind) {
+				resource = replaceIgnoredValues(resource, rule.path)
+			}
+		}
+		yamlData, err := yaml.Marshal(resource)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		// The name may be ignored, e.g. when it has a random suffix, so derive the file name from the sanitized resource
+		name := object.Name
+		if resourceMap, ok := resource.(map[string]interface{}); ok {
+			if metadata, ok := resourceMap["metadata"].(map[string]interface{}); ok {
+				name, _ = metadata["name"].(string)
+			}
+		}
+		fileName := getResourceSnapshotFileName(resources, object.GroupVersionKind.Kind, object.Namespace, name)
+		header := ""
+		if object.Source != "" {
+			header = sourceCommentPrefix + object.Source + "\n"
+		}
+		resources[fileName] = header + string(yamlData)
+	}
+	return resources, nil
+}
+
+var snapshotFileNameRegexp = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// getResourceSnapshotFileName returns a unique file name for the snapshot of the given resource, e.g.
+// deployment_prod_nginx.yaml.
+func getResourceSnapshotFileName(existing map[string]string, kind string, namespace string, name string) string {
+	parts := []string{kind}
+	if namespace != "" {
+		parts = append(parts, namespace)
+	}
+	parts = append(parts, name)
+	baseName := snapshotFileNameRegexp.ReplaceAllString(strings.ToLower(strings.Join(parts, "_")), "_")
+	fileName := baseName + ".yaml"
+	for i := 2; ; i++ {
+		if _, exists := existing[fileName]; !exists {
+			return fileName
+		}
+		fileName = fmt.Sprintf("%s_%d.yaml", baseName, i)
+	}
+}
+
+// getResourceSnapshotDir returns the directory of the per-resource snapshots with the given name.
+func getResourceSnapshotDir(options *Options, snapshotName string) string {
+	snapshotDir := defaultSnapshotDir
+	if options.SnapshotPath != "" {
+		snapshotDir = options.SnapshotPath
+	}
+	return filepath.Join(snapshotDir, snapshotName)
+}
+
+// validateSnapshotNameE checks that the snapshot name is a plain directory name, so that the snapshot directory is a
+// subdirectory of the snapshot path, and not the snapshot path itself or a directory outside of it.
+func validateSnapshotNameE(snapshotName string) error {
+	if snapshotName == "" || snapshotName == "." || snapshotName == ".." || filepath.Base(snapshotName) != snapshotName {
+		return errors.WithStackTrace(InvalidSnapshotNameError{Name: snapshotName})
+	}
+	return nil
+}
+
+// snapshotDirExists returns true if the given snapshot directory exists.
+func snapshotDirExists(snapshotDir string) bool {
+	info, err := os.Stat(snapshotDir)
+	return err == nil && info.IsDir()
+}
+
+// snapshotIgnoreRule is a parsed entry of Options.SnapshotIgnorePaths.
+type snapshotIgnoreRule struct {
+	kind string
+	path []string
+}
+
+// parseSnapshotIgnoreRulesE parses ignore paths of the form [KIND:]/json/pointer, where * matches any key or list index,
+// e.g. /metadata/annotations/checksum~1config or Secret:/data/*.
+func parseSnapshotIgnoreRulesE(ignorePaths []string) ([]snapshotIgnoreRule, error) {
+	rules := []snapshotIgnoreRule{}
+	for _, ignorePath := range ignorePaths {
+		kind, pointer := "", ignorePath
+		if separator := strings.Index(ignorePath, ":/"); separator > 0 && !strings.HasPrefix(ignorePath, "/") {
+			kind, pointer = ignorePath[:separator], ignorePath[separator+1:]
+		}
+		if !strings.HasPrefix(pointer, "/") || pointer == "/" {
+			return nil, errors.WithStackTrace(InvalidSnapshotIgnorePathError{Path: ignorePath})
+		}
+		path := strings.Split(pointer[1:], "/")
+		for i := range path {
+			path[i] = strings.ReplaceAll(strings.ReplaceAll(path[i], "~1", "/"), "~0", "~")
+		}
+		rules = append(rules, snapshotIgnoreRule{kind: kind, path: path})
+	}
+	return rules, nil
+}
+
+// replaceIgnoredValues replaces the values at the given path of the resource with SnapshotIgnoredValue.
+func replaceIgnoredValues(value interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return SnapshotIgnoredValue
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typedValue {
+			if path[0] == "*" || path[0] == key {
+				typedValue[key] = replaceIgnoredValues(child, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range typedValue {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				typedValue[i] = replaceIgnoredValues(child, path[1:])
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+const exampleSnapshotManifests = `---
+# Source: myapp/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp-tls
+data:
+  tls.crt: Zmlyc3Q=
+---
+# Source: myapp/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+  namespace: prod
+spec:
+  replicas: 2
+  template:
+    metadata:
+      annotations:
+        checksum/config: 3f2a9c0d
+    spec:
+      containers:
+      - name: app
+        image: myapp:1.0.0
+---
+# Source: myapp/templates/job.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: myapp-migrate-x7k2p
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: myapp:1.0.0
+`
+
+func TestResourceSnapshots(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		SnapshotPath: t.TempDir(),
+		SnapshotIgnorePaths: []string{
+			"/spec/template/metadata/annotations/checksum~1config",
+			"Secret:/data/*",
+			"Job:/metadata/name",
+		},
+		Logger: logger.Discard,
+	}
+	UpdateResourceSnapshots(t, options, exampleSnapshotManifests, "myapp")
+
+	snapshotDir := filepath.Join(options.SnapshotPath, "myapp")
+	snapshotFiles, err := filepath.Glob(filepath.Join(snapshotDir, "*.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(snapshotDir, "deployment_prod_myapp.yaml"),
+		filepath.Join(snapshotDir, "job_ignored_.yaml"),
+		filepath.Join(snapshotDir, "secret_myapp-tls.yaml"),
+	}, snapshotFiles)
+	secretSnapshot, err := os.ReadFile(filepath.Join(snapshotDir, "secret_myapp-tls.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(secretSnapshot), "# Source: myapp/templates/secret.yaml\n")
+	assert.Contains(t, string(secretSnapshot), "tls.crt: <ignored>")
+
+	// Volatile values do not make a difference
+	volatileChanges := strings.NewReplacer(
+		"Zmlyc3Q=", "c2Vjb25k",
+		"3f2a9c0d", "b81e44a7",
+		"myapp-migrate-x7k2p", "myapp-migrate-q9d4w",
+	)
+	assert.Empty(t, DiffAgainstResourceSnapshots(t, options, volatileChanges.Replace(exampleSnapshotManifests), "myapp"))
+	AssertSnapshot(t, options, volatileChanges.Replace(exampleSnapshotManifests), "myapp")
+
+	// Every other change is reported, per resource
+	changes := strings.NewReplacer(
+		"replicas: 2", "replicas: 3",
+		"name: myapp-tls", "name: myapp-certificate",
+	)
+	diffs := DiffAgainstResourceSnapshots(t, options, changes.Replace(exampleSnapshotManifests), "myapp")
+	require.Len(t, diffs, 3)
+	assert.Equal(t, SnapshotChangeModified, diffs[0].Change)
+	assert.Equal(t, filepath.Join(snapshotDir, "deployment_prod_myapp.yaml"), diffs[0].File)
+	assert.Contains(t, diffs[0].Report, "spec.replicas")
+	assert.Equal(t, ResourceSnapshotDiff{File: filepath.Join(snapshotDir, "secret_myapp-certificate.yaml"), Change: SnapshotChangeAdded}, diffs[1])
+	assert.Equal(t, ResourceSnapshotDiff{File: filepath.Join(snapshotDir, "secret_myapp-tls.yaml"), Change: SnapshotChangeRemoved}, diffs[2])
+}
+
+func TestAssertSnapshotUpdateMode(t *testing.T) {
+	t.Setenv(SnapshotUpdateEnvVar, "true")
+	require.True(t, IsSnapshotUpdateMode())
+
+	options := &Options{SnapshotPath: t.TempDir(), Logger: logger.Discard}
+	UpdateResourceSnapshots(t, options, exampleSnapshotManifests, "myapp")
+
+	// In update mode, the snapshots are rewritten instead of compared
+	updatedManifests := strings.ReplaceAll(exampleSnapshotManifests, "replicas: 2", "replicas: 3")
+	AssertSnapshot(t, options, updatedManifests, "myapp")
+	assert.Empty(t, DiffAgainstResourceSnapshots(t, options, updatedManifests, "myapp"))
+}
+
+func TestAssertSnapshotWithoutSnapshots(t *testing.T) {
+	t.Setenv(SnapshotUpdateEnvVar, "")
+
+	options := &Options{SnapshotPath: t.TempDir(), Logger: logger.Discard}
+	mockT := &snapshotMockT{}
+	AssertSnapshot(mockT, options, exampleSnapshotManifests, "myapp")
+	assert.True(t, mockT.failed)
+	assert.Contains(t, mockT.message, SnapshotUpdateEnvVar)
+
+	// Missing snapshots are not written outside of update mode
+	assert.NoDirExists(t, filepath.Join(options.SnapshotPath, "myapp"))
+}
+
+func TestResourceSnapshotsRejectInvalidNames(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{SnapshotPath: t.TempDir(), Logger: logger.Discard}
+	legacySnapshot := filepath.Join(options.SnapshotPath, "myapp.yaml")
+	require.NoError(t, os.WriteFile(legacySnapshot, []byte(exampleSnapshotManifests), 0644))
+
+	for _, snapshotName := range []string{"", ".", "..", "../myapp", "myapp/prod"} {
+		err := UpdateResourceSnapshotsE(t, options, exampleSnapshotManifests, snapshotName)
+		assert.IsType(t, InvalidSnapshotNameError{}, errors.Unwrap(err), snapshotName)
+		_, err = DiffAgainstResourceSnapshotsE(t, options, exampleSnapshotManifests, snapshotName)
+		assert.IsType(t, InvalidSnapshotNameError{}, errors.Unwrap(err), snapshotName)
+	}
+	assert.FileExists(t, legacySnapshot)
+
+	// Updating the snapshots only replaces the snapshot files
+	snapshotDir := filepath.Join(options.SnapshotPath, "myapp")
+	require.NoError(t, os.MkdirAll(snapshotDir, 0755))
+	readme := filepath.Join(snapshotDir, "README.md")
+	require.NoError(t, os.WriteFile(readme, []byte("Regenerate with TERRATEST_UPDATE_SNAPSHOTS=1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(snapshotDir, "configmap_stale.yaml"), []byte("{}"), 0644))
+	UpdateResourceSnapshots(t, options, exampleSnapshotManifests, "myapp")
+	assert.FileExists(t, readme)
+	assert.NoFileExists(t, filepath.Join(snapshotDir, "configmap_stale.yaml"))
+	assert.FileExists(t, filepath.Join(snapshotDir, "deployment_prod_myapp.yaml"))
+}
+
+// snapshotMockT records the failure of the test instead of failing it.
+type snapshotMockT struct {
+	failed  bool
+	message string
+}
+
+func (t *snapshotMockT) Fail()                                     { t.failed = true }
+func (t *snapshotMockT) FailNow()                                  { t.failed = true }
+func (t *snapshotMockT) Error(args ...interface{})                 { t.failed = true }
+func (t *snapshotMockT) Fatal(args ...interface{})                 { t.failed = true }
+func (t *snapshotMockT) Fatalf(format string, args ...interface{}) { t.failed = true }
+func (t *snapshotMockT) Name() string                              { return "snapshotMockT" }
+func (t *snapshotMockT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+	t.message = fmt.Sprintf(format, args...)
+}
+
+func TestParseSnapshotIgnoreRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := parseSnapshotIgnoreRulesE([]string{"/metadata/annotations/checksum~1config", "Secret:/data/*"})
+	require.NoError(t, err)
+	assert.Equal(t, []snapshotIgnoreRule{
+		{path: []string{"metadata", "annotations", "checksum/config"}},
+		{kind: "Secret", path: []string{"data", "*"}},
+	}, rules)
+
+	_, err = parseSnapshotIgnoreRulesE([]string{"metadata.annotations"})
+	require.Error(t, err)
+}
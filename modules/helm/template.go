@@ -28,8 +28,14 @@ func RenderTemplate(t testing.TestingT, options *Options, chartDir string, relea
 }
 
 // RenderTemplateE runs `helm template` to render the template given the provided options and returns stdout/stderr from
-// the template command. If you pass in templateFiles, this will only render those templates.
+// the template command. If you pass in templateFiles, this will only render those templates. The chart can also be a
+// chart in an OCI registry (oci://...), in which case this is the same as RenderRemoteTemplateE.
 func RenderTemplateE(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) (string, error) {
+	// Charts in an OCI registry are rendered from the registry, so there is no local dir to check
+	if IsOCIReference(chartDir) {
+		return RenderRemoteTemplateE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	}
+
 	// First, verify the charts dir exists
 	absChartDir, err := filepath.Abs(chartDir)
 	if err != nil {
@@ -87,7 +93,9 @@ func RenderRemoteTemplate(t testing.TestingT, options *Options, chartURL string,
 }
 
 // RenderTemplate runs `helm template` to render a *remote* helm chart  given the provided options and returns stdout/stderr from
-// the template command. If you pass in templateFiles, this will only render those templates.
+// the template command. If you pass in templateFiles, this will only render those templates. The chartURL is either the
+// URL of a classic chart repository or the OCI reference of the chart (oci://...), in which case Options.Version
+// selects the version of the chart to render.
 func RenderRemoteTemplateE(t testing.TestingT, options *Options, chartURL string, releaseName string, templateFiles []string, extraHelmArgs ...string) (string, error) {
 	// Now construct the args
 	// We first construct the template args
@@ -108,8 +116,16 @@ func RenderRemoteTemplateE(t testing.TestingT, options *Options, chartURL string
 	// deal extraHelmArgs
 	args = append(args, extraHelmArgs...)
 
-	// ... and add the helm chart name, the remote repo and chart URL at the end
-	args = append(args, releaseName, "--repo", chartURL)
+	// ... and add the helm chart name, the remote repo and chart URL at the end. Charts in an OCI registry are referenced
+	// directly, as `helm template` does not support OCI registries with --repo.
+	if IsOCIReference(chartURL) {
+		if options.Version != "" {
+			args = append(args, "--version", options.Version)
+		}
+		args = append(args, releaseName, chartURL)
+	} else {
+		args = append(args, releaseName, "--repo", chartURL)
+	}
 
 	// Finally, call out to helm template command
 	return RunHelmCommandAndGetStdOutE(t, options, "template", args...)
@@ -216,23 +232,12 @@ func DiffAgainstSnapshotE(t testing.TestingT, options *Options, yamlData string,
 		return -1, errors.WithStackTrace(err)
 	}
 
-	// write the current manifest into a file as `dyff` does not support string input
-	currentManifests := releaseName + ".yaml"
-	file, err := os.Create(currentManifests)
-	if err != nil {
-		return -1, errors.WithStackTrace(err)
-	}
-
-	if _, err = file.WriteString(yamlData); err != nil {
-		return -1, errors.WithStackTrace(err)
-	}
-	defer file.Close()
-	defer os.Remove(currentManifests)
-
-	to, err := ytbx.LoadFile(currentManifests)
+	// load the current manifests from memory rather than a file, so that nothing is written into the working directory
+	documents, err := ytbx.LoadDocuments([]byte(yamlData))
 	if err != nil {
 		return -1, errors.WithStackTrace(err)
 	}
+	to := ytbx.InputFile{Location: releaseName + ".yaml", Documents: documents}
 
 	// compare the two manifests using `dyff`
 	compOpt := dyff.KubernetesEntityDetection(false)
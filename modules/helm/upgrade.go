@@ -1,6 +1,7 @@
 package helm
 
 import (
+	"encoding/json"
 	"path/filepath"
 
 	"github.com/gruntwork-io/go-commons/errors"
@@ -15,22 +16,55 @@ func Upgrade(t testing.TestingT, options *Options, chart string, releaseName str
 	require.NoError(t, UpgradeE(t, options, chart, releaseName))
 }
 
-// UpgradeE will upgrade the release and chart will be deployed with the lastest configuration.
+// UpgradeE will upgrade the release and chart will be deployed with the lastest configuration. For charts in an OCI
+// registry (oci://...), Options.Version selects the version of the chart to upgrade to.
 func UpgradeE(t testing.TestingT, options *Options, chart string, releaseName string) error {
+	args, err := getUpgradeArgsE(t, options, chart, releaseName)
+	if err != nil {
+		return err
+	}
+	_, err = RunHelmCommandAndGetOutputE(t, options, "upgrade", args...)
+	return err
+}
+
+// renderUpgradeManifestE runs the upgrade of the release in dry-run mode, and returns the manifest that the upgrade
+// would apply. Unlike `helm template`, the manifest is rendered as for an actual upgrade, e.g. with .Release.IsUpgrade
+// set.
+func renderUpgradeManifestE(t testing.TestingT, options *Options, chart string, releaseName string) (string, error) {
+	args, err := getUpgradeArgsE(t, options, chart, releaseName)
+	if err != nil {
+		return "", err
+	}
+	out, err := RunHelmCommandAndGetStdOutE(t, options, "upgrade", append([]string{"--dry-run", "--output", "json"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	var release struct {
+		Manifest string `json:"manifest"`
+	}
+	if err := json.Unmarshal([]byte(out), &release); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return release.Manifest, nil
+}
+
+// getUpgradeArgsE returns the arguments of the `helm upgrade` command for the given chart and release, building the
+// dependencies of the chart first if requested.
+func getUpgradeArgsE(t testing.TestingT, options *Options, chart string, releaseName string) ([]string, error) {
 	// If the chart refers to a path, convert to absolute path. Otherwise, pass straight through as it may be a remote
-	// chart.
+	// chart, including a chart in an OCI registry (oci://...).
 	if files.FileExists(chart) {
 		absChartDir, err := filepath.Abs(chart)
 		if err != nil {
-			return errors.WithStackTrace(err)
+			return nil, errors.WithStackTrace(err)
 		}
 		chart = absChartDir
 	}
 
-	// build chart dependencies
-	if options.BuildDependencies {
+	// build chart dependencies. Charts pulled from an OCI registry are packaged with their dependencies.
+	if options.BuildDependencies && !IsOCIReference(chart) {
 		if _, err := RunHelmCommandAndGetOutputE(t, options, "dependency", "build", chart); err != nil {
-			return errors.WithStackTrace(err)
+			return nil, errors.WithStackTrace(err)
 		}
 	}
 	var err error
@@ -40,12 +74,15 @@ func UpgradeE(t testing.TestingT, options *Options, chart string, releaseName st
 			args = append(args, upgradeArgs...)
 		}
 	}
+	// Charts from classic repositories are upgraded to their latest version for backwards compatibility, while charts
+	// from OCI registries honor the requested version.
+	if IsOCIReference(chart) && options.Version != "" {
+		args = append(args, "--version", options.Version)
+	}
 	args, err = getValuesArgsE(t, options, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	args = append(args, "--install", releaseName, chart)
-	_, err = RunHelmCommandAndGetOutputE(t, options, "upgrade", args...)
-	return err
+	return append(args, "--install", releaseName, chart), nil
 }
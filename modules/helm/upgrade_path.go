@@ -0,0 +1,357 @@
+package helm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	defaultUpgradePathWaitRetries             = 60
+	defaultUpgradePathWaitSleepBetweenRetries = 5 * time.Second
+)
+
+// ResourceChangeType is how a resource of a release changed between two revisions.
+type ResourceChangeType string
+
+const (
+	ResourceAdded    ResourceChangeType = "added"
+	ResourceRemoved  ResourceChangeType = "removed"
+	ResourceModified ResourceChangeType = "modified"
+)
+
+// immutableFields lists, by kind qualified with its API group, the fields that cannot be updated in place. Changing
+// them makes `helm upgrade` fail, unless the resource is deleted and recreated (e.g. with --force).
+var immutableFields = map[string][][]string{
+	"Deployment.apps":       {{"spec", "selector"}},
+	"ReplicaSet.apps":       {{"spec", "selector"}},
+	"DaemonSet.apps":        {{"spec", "selector"}},
+	"StatefulSet.apps":      {{"spec", "selector"}, {"spec", "serviceName"}, {"spec", "volumeClaimTemplates"}, {"spec", "podManagementPolicy"}},
+	"Job.batch":             {{"spec", "selector"}, {"spec", "template"}, {"spec", "completionMode"}},
+	"Service":               {{"spec", "clusterIP"}},
+	"PersistentVolumeClaim": {{"spec", "accessModes"}, {"spec", "storageClassName"}, {"spec", "volumeName"}, {"spec", "selector"}, {"spec", "volumeMode"}},
+}
+
+// UpgradePathOptions configures UpgradeFromPreviousVersionE.
+type UpgradePathOptions struct {
+	// PreviousChart is the published chart to install first, e.g. myrepo/mychart or oci://registry/charts/mychart.
+	PreviousChart string
+	// PreviousVersion is the version of PreviousChart to install, typically the last release (N-1). Empty means the
+	// latest version.
+	PreviousVersion string
+	// WaitRetries and WaitSleepBetweenRetries control how long to wait for the workloads of the release to become
+	// available after the install and after the upgrade. Default to 60 retries, 5 seconds apart.
+	WaitRetries             int
+	WaitSleepBetweenRetries time.Duration
+}
+
+// ResourceChange is a resource of a release that changed between two revisions.
+type ResourceChange struct {
+	// Group is the API group of the resource, empty for the core group, e.g. apps for a Deployment
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+	Change    ResourceChangeType
+	// Diff is the human readable dyff report of the changes, for modified resources.
+	Diff string
+	// ImmutableFieldChanges are the immutable fields that changed (e.g. spec.selector), which require the resource to be
+	// deleted and recreated.
+	ImmutableFieldChanges []string
+	// Recreated is true if the resource was deleted and recreated by the upgrade, i.e. its UID changed.
+	Recreated bool
+}
+
+// String returns a human readable description of the change.
+func (change ResourceChange) String() string {
+	description := fmt.Sprintf("%s %s/%s %s", change.Kind, change.Namespace, change.Name, change.Change)
+	if len(change.ImmutableFieldChanges) > 0 {
+		description += fmt.Sprintf(" (immutable fields changed: %s)", strings.Join(change.ImmutableFieldChanges, ", "))
+	}
+	if change.Recreated {
+		description += " (recreated)"
+	}
+	return description
+}
+
+// key returns the key of the changed resource in the index of RenderedManifests.
+func (change ResourceChange) key() string {
+	return renderedObjectKey(schema.GroupKind{Group: change.Group, Kind: change.Kind}, change.Namespace, change.Name)
+}
+
+// UpgradePathResult is the outcome of upgrading a release from a previous version of the chart.
+type UpgradePathResult struct {
+	PreviousRevision int
+	// Revision is the revision of the release after the upgrade, or 0 if the upgrade failed.
+	Revision int
+	// Changes are the resources that were added, removed or modified by the upgrade, sorted by kind, namespace and name.
+	Changes []ResourceChange
+}
+
+// RequiresRecreate returns the resources whose immutable fields changed, or that were recreated by the upgrade.
+func (result *UpgradePathResult) RequiresRecreate() []ResourceChange {
+	changes := []ResourceChange{}
+	for _, change := range result.Changes {
+		if change.Recreated || len(change.ImmutableFieldChanges) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// UpgradeFromPreviousVersion installs the previous version of the chart, upgrades the release to the given chart and
+// checks that its workloads are available. See UpgradeFromPreviousVersionE for more details. This will fail the test if
+// there is an error.
+func UpgradeFromPreviousVersion(t testing.TestingT, options *Options, chart string, releaseName string, upgradeOptions UpgradePathOptions) *UpgradePathResult {
+	result, err := UpgradeFromPreviousVersionE(t, options, chart, releaseName, upgradeOptions)
+	require.NoError(t, err)
+	return result
+}
+
+// UpgradeFromPreviousVersionE tests the upgrade path of a chart: it installs upgradeOptions.PreviousChart at
+// upgradeOptions.PreviousVersion with InstallE, waits for the Deployments and StatefulSets of the release to be
+// available, upgrades the release to the given chart (typically the local chart under test) with UpgradeE, and waits
+// for the workloads to be available again. The same options are used for both the install and the upgrade, except for
+// the version. It returns the resources changed by the upgrade, flagging the ones that had to be (or would need to be)
+// deleted and recreated. The changes are computed with a dry run before upgrading, so if the upgrade fails, e.g.
+// because an immutable field such as a selector changed, the result is returned along with the error of the upgrade,
+// and its Revision is 0. The release is left in place, so the caller is responsible for deleting it.
+func UpgradeFromPreviousVersionE(t testing.TestingT, options *Options, chart string, releaseName string, upgradeOptions UpgradePathOptions) (*UpgradePathResult, error) {
+	retries := upgradeOptions.WaitRetries
+	if retries <= 0 {
+		retries = defaultUpgradePathWaitRetries
+	}
+	sleepBetweenRetries := upgradeOptions.WaitSleepBetweenRetries
+	if sleepBetweenRetries <= 0 {
+		sleepBetweenRetries = defaultUpgradePathWaitSleepBetweenRetries
+	}
+
+	previousOptions := *options
+	previousOptions.Version = upgradeOptions.PreviousVersion
+	options.Logger.Logf(t, "Installing release %s from %s version %s", releaseName, upgradeOptions.PreviousChart, upgradeOptions.PreviousVersion)
+	if err := InstallE(t, &previousOptions, upgradeOptions.PreviousChart, releaseName); err != nil {
+		return nil, err
+	}
+	if err := WaitUntilReleaseWorkloadsAvailableE(t, options, releaseName, retries, sleepBetweenRetries); err != nil {
+		return nil, err
+	}
+	previousStatus, err := GetReleaseStatusE(t, options, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	previousManifests, previousUIDs, err := getReleaseResourcesE(t, options, releaseName, previousStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	// Diff the manifests before upgrading, so that the changes are reported even if the upgrade fails, e.g. because
+	// an immutable field changed
+	currentOptions := *options
+	currentOptions.Version = ""
+	plannedManifest, err := renderUpgradeManifestE(t, &currentOptions, chart, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	plannedManifests, err := ParseRenderedManifestsE(t, plannedManifest)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := diffReleaseManifestsE(previousManifests, plannedManifests)
+	if err != nil {
+		return nil, err
+	}
+	result := &UpgradePathResult{PreviousRevision: previousStatus.Revision, Changes: changes}
+
+	options.Logger.Logf(t, "Upgrading release %s to %s", releaseName, chart)
+	// The dependencies were built for the dry run
+	currentOptions.BuildDependencies = false
+	if err := UpgradeE(t, &currentOptions, chart, releaseName); err != nil {
+		for _, change := range result.RequiresRecreate() {
+			options.Logger.Logf(t, "Upgrade of release %s failed, it requires recreating %s", releaseName, change)
+		}
+		return result, err
+	}
+	status, err := GetReleaseStatusE(t, options, releaseName)
+	if err != nil {
+		return result, err
+	}
+	manifests, uids, err := getReleaseResourcesE(t, options, releaseName, status)
+	if err != nil {
+		return result, err
+	}
+
+	// The manifest of the release may still differ from the dry run, e.g. when the templates look up live resources
+	changes, err = diffReleaseManifestsE(previousManifests, manifests)
+	if err != nil {
+		return result, err
+	}
+	for i := range changes {
+		key := changes[i].key()
+		previousUID, currentUID := previousUIDs[key], uids[key]
+		changes[i].Recreated = previousUID != "" && currentUID != "" && previousUID != currentUID
+	}
+	result.Revision = status.Revision
+	result.Changes = changes
+	for _, change := range result.RequiresRecreate() {
+		options.Logger.Logf(t, "Upgrade of release %s requires recreating %s", releaseName, change)
+	}
+
+	return result, WaitUntilReleaseWorkloadsAvailableE(t, options, releaseName, retries, sleepBetweenRetries)
+}
+
+// WaitUntilReleaseWorkloadsAvailable waits until the Deployments and StatefulSets of the current revision of the given
+// release are available. This will fail the test if there is an error.
+func WaitUntilReleaseWorkloadsAvailable(t testing.TestingT, options *Options, releaseName string, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilReleaseWorkloadsAvailableE(t, options, releaseName, retries, sleepBetweenRetries))
+}
+
+// WaitUntilReleaseWorkloadsAvailableE waits until the Deployments and StatefulSets of the current revision of the given
+// release are available, using k8s.WaitUntilDeploymentAvailableE and k8s.WaitUntilStatefulSetAvailableE with the given
+// retries for each of them.
+func WaitUntilReleaseWorkloadsAvailableE(t testing.TestingT, options *Options, releaseName string, retries int, sleepBetweenRetries time.Duration) error {
+	status, err := GetReleaseStatusE(t, options, releaseName)
+	if err != nil {
+		return err
+	}
+	manifest, err := GetReleaseManifestE(t, options, releaseName, status.Revision)
+	if err != nil {
+		return err
+	}
+	manifests, err := ParseRenderedManifestsE(t, manifest)
+	if err != nil {
+		return err
+	}
+	for _, object := range manifests.Objects {
+		kubectlOptions := getObjectKubectlOptions(options, status.Namespace, object.Namespace)
+		switch object.GroupVersionKind.GroupKind().String() {
+		case "Deployment.apps":
+			err = k8s.WaitUntilDeploymentAvailableE(t, kubectlOptions, object.Name, retries, sleepBetweenRetries)
+		case "StatefulSet.apps":
+			err = k8s.WaitUntilStatefulSetAvailableE(t, kubectlOptions, object.Name, retries, sleepBetweenRetries)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getReleaseResourcesE returns the parsed manifests of the given release revision, along with the UIDs of the live
+// resources keyed by renderedObjectKey.
+func getReleaseResourcesE(t testing.TestingT, options *Options, releaseName string, status *ReleaseStatus) (*RenderedManifests, map[string]string, error) {
+	manifest, err := GetReleaseManifestE(t, options, releaseName, status.Revision)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifests, err := ParseRenderedManifestsE(t, manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	uids := map[string]string{}
+	for _, object := range manifests.Objects {
+		resource := strings.ToLower(object.GroupVersionKind.Kind)
+		if group := object.GroupVersionKind.Group; group != "" {
+			resource += "." + group
+		}
+		kubectlOptions := getObjectKubectlOptions(options, status.Namespace, object.Namespace)
+		uid, err := k8s.RunKubectlAndGetOutputE(
+			t, kubectlOptions, "get", resource+"/"+object.Name, "--ignore-not-found", "--output", "jsonpath={.metadata.uid}",
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		uids[renderedObjectKey(object.GroupVersionKind.GroupKind(), object.Namespace, object.Name)] = strings.TrimSpace(uid)
+	}
+	return manifests, uids, nil
+}
+
+// getObjectKubectlOptions returns kubectl options targeting the namespace of an object of the release, which defaults
+// to the namespace of the release.
+func getObjectKubectlOptions(options *Options, releaseNamespace string, objectNamespace string) *k8s.KubectlOptions {
+	if objectNamespace != "" {
+		return getReleaseKubectlOptions(options, objectNamespace)
+	}
+	return getReleaseKubectlOptions(options, releaseNamespace)
+}
+
+// diffReleaseManifestsE returns the resources that were added, removed or modified between two revisions of a release.
+func diffReleaseManifestsE(previous *RenderedManifests, current *RenderedManifests) ([]ResourceChange, error) {
+	changes := []ResourceChange{}
+	for _, object := range previous.Objects {
+		if _, found := current.GetByGroupKind(object.GroupVersionKind.GroupKind(), object.Namespace, object.Name); !found {
+			changes = append(changes, newResourceChange(object, ResourceRemoved))
+		}
+	}
+	for _, object := range current.Objects {
+		previousObject, found := previous.GetByGroupKind(object.GroupVersionKind.GroupKind(), object.Namespace, object.Name)
+		if !found {
+			changes = append(changes, newResourceChange(object, ResourceAdded))
+			continue
+		}
+		diff, err := diffYAMLDocumentsE(previousObject.YAML, object.YAML)
+		if err != nil {
+			return nil, err
+		}
+		if diff == "" {
+			continue
+		}
+		change := newResourceChange(object, ResourceModified)
+		change.Diff = diff
+		change.ImmutableFieldChanges, err = getImmutableFieldChangesE(previousObject, object)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].key() < changes[j].key()
+	})
+	return changes, nil
+}
+
+// getImmutableFieldChangesE returns the immutable fields that differ between two versions of an object.
+func getImmutableFieldChangesE(previous RenderedObject, current RenderedObject) ([]string, error) {
+	fields, ok := immutableFields[current.GroupVersionKind.GroupKind().String()]
+	if !ok {
+		return nil, nil
+	}
+	previousContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(previous.Object)
+	if err != nil {
+		return nil, err
+	}
+	currentContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for _, field := range fields {
+		previousValue, _, _ := unstructured.NestedFieldNoCopy(previousContent, field...)
+		currentValue, _, _ := unstructured.NestedFieldNoCopy(currentContent, field...)
+		if !reflect.DeepEqual(previousValue, currentValue) {
+			changed = append(changed, strings.Join(field, "."))
+		}
+	}
+	return changed, nil
+}
+
+// newResourceChange returns the change of the given type for the given object.
+func newResourceChange(object RenderedObject, change ResourceChangeType) ResourceChange {
+	return ResourceChange{
+		Group:     object.GroupVersionKind.Group,
+		Kind:      object.GroupVersionKind.Kind,
+		Namespace: object.Namespace,
+		Name:      object.Name,
+		Change:    change,
+	}
+}
@@ -0,0 +1,103 @@
+//go:build kubeall || helm
+// +build kubeall helm
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests, and further differentiate helm
+// tests. This is done because minikube is heavy and can interfere with docker related tests in terratest. Similarly,
+// helm can overload the minikube system and thus interfere with the other kubernetes tests. To avoid overloading the
+// system, we run the kubernetes tests and helm tests separately from the others.
+
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+const upgradePathTestDeploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      %[1]s: {{ .Release.Name }}
+  template:
+    metadata:
+      labels:
+        %[1]s: {{ .Release.Name }}
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:%[2]s
+        ports:
+        - containerPort: 80
+`
+
+// Test that the changes of an upgrade are reported, including when the upgrade fails because of an immutable field.
+func TestUpgradeFromPreviousVersion(t *testing.T) {
+	t.Parallel()
+
+	namespaceName := fmt.Sprintf(
+		"%s-%s",
+		strings.ToLower(t.Name()),
+		strings.ToLower(random.UniqueId()),
+	)
+	kubectlOptions := k8s.NewKubectlOptions("", "", namespaceName)
+	defer k8s.DeleteNamespace(t, kubectlOptions, namespaceName)
+	k8s.CreateNamespace(t, kubectlOptions, namespaceName)
+
+	options := &Options{KubectlOptions: kubectlOptions}
+	upgradeOptions := UpgradePathOptions{
+		PreviousChart:           writeUpgradePathTestChart(t, "0.1.0", "app", "1.24"),
+		WaitRetries:             30,
+		WaitSleepBetweenRetries: 2 * time.Second,
+	}
+
+	// Changing the image is a regular rolling update
+	releaseName := fmt.Sprintf("upgrade-%s", strings.ToLower(random.UniqueId()))
+	defer Delete(t, options, releaseName, true)
+	result := UpgradeFromPreviousVersion(t, options, writeUpgradePathTestChart(t, "0.2.0", "app", "1.25"), releaseName, upgradeOptions)
+	assert.Equal(t, 1, result.PreviousRevision)
+	assert.Equal(t, 2, result.Revision)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, "apps", result.Changes[0].Group)
+	assert.Equal(t, ResourceModified, result.Changes[0].Change)
+	assert.Empty(t, result.RequiresRecreate())
+
+	// Changing the selector makes the upgrade fail, and the change is still reported
+	failedReleaseName := fmt.Sprintf("upgrade-%s", strings.ToLower(random.UniqueId()))
+	defer Delete(t, options, failedReleaseName, true)
+	result, err := UpgradeFromPreviousVersionE(t, options, writeUpgradePathTestChart(t, "0.2.0", "app.kubernetes.io/name", "1.24"), failedReleaseName, upgradeOptions)
+	require.Error(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 1, result.PreviousRevision)
+	assert.Equal(t, 0, result.Revision)
+	requiresRecreate := result.RequiresRecreate()
+	require.Len(t, requiresRecreate, 1)
+	assert.Equal(t, "Deployment", requiresRecreate[0].Kind)
+	assert.Equal(t, failedReleaseName, requiresRecreate[0].Name)
+	assert.Equal(t, []string{"spec.selector"}, requiresRecreate[0].ImmutableFieldChanges)
+}
+
+// writeUpgradePathTestChart writes a chart with a single Deployment, selecting its pods with the given label, and
+// returns its directory.
+func writeUpgradePathTestChart(t *testing.T, version string, selectorLabel string, imageTag string) string {
+	chartDir := filepath.Join(t.TempDir(), "upgrade-path-test")
+	require.NoError(t, os.MkdirAll(filepath.Join(chartDir, "templates"), 0755))
+	chartYAML := fmt.Sprintf("apiVersion: v2\nname: upgrade-path-test\nversion: %s\n", version)
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644))
+	deploymentYAML := fmt.Sprintf(upgradePathTestDeploymentTemplate, selectorLabel, imageTag)
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "templates", "deployment.yaml"), []byte(deploymentYAML), 0644))
+	return chartDir
+}
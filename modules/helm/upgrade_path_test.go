@@ -0,0 +1,93 @@
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const examplePreviousReleaseManifest = `---
+# Source: myapp/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp-legacy
+data:
+  mode: legacy
+---
+# Source: myapp/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+spec:
+  ports:
+  - port: 80
+---
+# Source: myapp/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: myapp
+  template:
+    metadata:
+      labels:
+        app: myapp
+    spec:
+      containers:
+      - name: app
+        image: myapp:1.0.0
+`
+
+func TestDiffReleaseManifests(t *testing.T) {
+	t.Parallel()
+
+	currentManifest := strings.NewReplacer(
+		"myapp:1.0.0", "myapp:1.1.0",
+		"      app: myapp\n  template", "      app.kubernetes.io/name: myapp\n  template",
+		"      labels:\n        app: myapp", "      labels:\n        app.kubernetes.io/name: myapp",
+	).Replace(examplePreviousReleaseManifest)
+	currentManifest = strings.Replace(currentManifest, "name: myapp-legacy\ndata:\n  mode: legacy", "name: myapp-config\ndata:\n  mode: current", 1)
+
+	previous := ParseRenderedManifests(t, examplePreviousReleaseManifest)
+	current := ParseRenderedManifests(t, currentManifest)
+	changes, err := diffReleaseManifestsE(previous, current)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, ResourceChange{Kind: "ConfigMap", Name: "myapp-config", Change: ResourceAdded}, changes[0])
+	assert.Equal(t, ResourceChange{Kind: "ConfigMap", Name: "myapp-legacy", Change: ResourceRemoved}, changes[1])
+	assert.Equal(t, "Deployment", changes[2].Kind)
+	assert.Equal(t, ResourceModified, changes[2].Change)
+	assert.Contains(t, changes[2].Diff, "myapp:1.1.0")
+	assert.Equal(t, []string{"spec.selector"}, changes[2].ImmutableFieldChanges)
+
+	result := UpgradePathResult{Changes: changes}
+	require.Len(t, result.RequiresRecreate(), 1)
+	assert.Equal(t, "Deployment /myapp modified (immutable fields changed: spec.selector)", result.RequiresRecreate()[0].String())
+
+	// An upgrade that only changes mutable fields does not require recreating anything
+	changes, err = diffReleaseManifestsE(previous, ParseRenderedManifests(t, strings.Replace(examplePreviousReleaseManifest, "replicas: 2", "replicas: 3", 1)))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Empty(t, changes[0].ImmutableFieldChanges)
+
+	// Kinds with the same name in different API groups are different resources
+	knativeService := "---\napiVersion: serving.knative.dev/v1\nkind: Service\nmetadata:\n  name: myapp\nspec:\n  template: {}\n"
+	changes, err = diffReleaseManifestsE(previous, ParseRenderedManifests(t, examplePreviousReleaseManifest+knativeService))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ResourceChange{Group: "serving.knative.dev", Kind: "Service", Name: "myapp", Change: ResourceAdded}, changes[0])
+
+	// Identical revisions do not change anything
+	changes, err = diffReleaseManifestsE(previous, previous)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
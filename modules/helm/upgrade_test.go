@@ -96,9 +96,25 @@ func TestRemoteChartInstallUpgradeRollback(t *testing.T) {
 		},
 	)
 
+	// Verify the release metadata reflects the upgrade
+	status := GetReleaseStatus(t, options, releaseName)
+	assert.Equal(t, 2, status.Revision)
+	assert.Equal(t, "deployed", status.Status)
+	assert.Equal(t, remoteChartName, status.ChartName)
+	assert.Equal(t, remoteChartVersion, status.ChartVersion)
+	assert.Equal(t, map[string]interface{}{"replicaCount": float64(2), "service": map[string]interface{}{"type": "NodePort"}}, GetReleaseValues(t, options, releaseName, 0, false))
+	assert.Equal(t, float64(1), GetReleaseValues(t, options, releaseName, 1, true)["replicaCount"])
+	assert.Contains(t, GetReleaseManifest(t, options, releaseName, 0), "kind: Deployment")
+
 	// Finally, test rollback functionality. When rolling back, we should see the pods go back down to 1.
 	Rollback(t, options, releaseName, "")
 	waitForRemoteChartPods(t, kubectlOptions, releaseName, 1)
+
+	history := GetReleaseHistory(t, options, releaseName)
+	require.Len(t, history, 3)
+	assert.Equal(t, "superseded", history[1].Status)
+	assert.Equal(t, "deployed", history[2].Status)
+	assert.Equal(t, "Rollback to 1", history[2].Description)
 }
 
 // Test deployment of helm chart with dependencies.
@@ -0,0 +1,170 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+	go_test "testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ValuesMatrixDimension is a named dimension of a values matrix, e.g. ingress.enabled, whose options are rendered in
+// combination with the options of every other dimension.
+type ValuesMatrixDimension struct {
+	Name    string
+	Options []ValuesMatrixOption
+}
+
+// ValuesMatrixOption is one of the options of a values matrix dimension. Its values are applied on top of the base
+// Options passed to RenderValuesMatrix.
+type ValuesMatrixOption struct {
+	// Name identifies the option in the subtest name, e.g. true.
+	Name          string
+	SetValues     map[string]string
+	SetStrValues  map[string]string
+	SetJsonValues map[string]string
+	ValuesFiles   []string
+	// ExtraHelmArgs are additional arguments to pass to `helm template`, e.g. --kube-version 1.28.
+	ExtraHelmArgs []string
+}
+
+// ValuesCombination is one combination of the options of a values matrix.
+type ValuesCombination struct {
+	// Name encodes the option of every dimension, e.g. ingress.enabled=true,kube-version=1.28. It is the name of the
+	// subtest of the combination.
+	Name string
+	// Selection is the name of the selected option, by dimension name.
+	Selection map[string]string
+	// Options are the base options with the values of the selected options applied.
+	Options       *Options
+	ExtraHelmArgs []string
+}
+
+// ValuesMatrixValidation validates the manifests rendered for a combination of a values matrix.
+type ValuesMatrixValidation func(t *go_test.T, combination ValuesCombination, manifests *RenderedManifests)
+
+// NewValuesDimension returns a dimension that sets the given value key to each of the given values with --set, e.g.
+// NewValuesDimension("ingress.enabled", "true", "false").
+func NewValuesDimension(key string, values ...string) ValuesMatrixDimension {
+	dimension := ValuesMatrixDimension{Name: key}
+	for _, value := range values {
+		dimension.Options = append(dimension.Options, ValuesMatrixOption{
+			Name:      value,
+			SetValues: map[string]string{key: value},
+		})
+	}
+	return dimension
+}
+
+// NewKubeVersionDimension returns a dimension that renders the chart for each of the given Kubernetes versions with
+// --kube-version, which drives the .Capabilities.KubeVersion of the templates.
+func NewKubeVersionDimension(versions ...string) ValuesMatrixDimension {
+	dimension := ValuesMatrixDimension{Name: "kube-version"}
+	for _, version := range versions {
+		dimension.Options = append(dimension.Options, ValuesMatrixOption{
+			Name:          version,
+			ExtraHelmArgs: []string{"--kube-version", version},
+		})
+	}
+	return dimension
+}
+
+// RenderValuesMatrix renders the chart for every combination of the options of the given dimensions, each in its own
+// parallel subtest named after the combination (e.g. ingress.enabled=true,kube-version=1.28), and runs the validation
+// over the parsed manifests. The subtests are grouped under a ValuesMatrix subtest, which returns once all of them are
+// done. A combination fails if the chart does not render or if the validation fails.
+// Note that go_test is an alias to Golang's native testing package created to avoid naming conflicts with Terratest's
+// own testing package.
+func RenderValuesMatrix(
+	t *go_test.T,
+	options *Options,
+	chartDir string,
+	releaseName string,
+	dimensions []ValuesMatrixDimension,
+	validation ValuesMatrixValidation,
+) {
+	combinations := GetValuesMatrixCombinations(options, dimensions)
+	t.Run("ValuesMatrix", func(t *go_test.T) {
+		for _, combination := range combinations {
+			combination := combination
+			t.Run(combination.Name, func(t *go_test.T) {
+				t.Parallel()
+				manifests, err := RenderManifestsE(t, combination.Options, chartDir, releaseName, []string{}, combination.ExtraHelmArgs...)
+				require.NoError(t, err, "Rendering combination %s", combination.Name)
+				validation(t, combination, manifests)
+			})
+		}
+	})
+}
+
+// GetValuesMatrixCombinations returns every combination of the options of the given dimensions, in order, the options
+// of the last dimension varying fastest. The values of the selected options are applied on top of a copy of the base
+// options: values maps are merged (later dimensions win), while values files and extra helm args are appended.
+// Dimensions without options are skipped.
+func GetValuesMatrixCombinations(options *Options, dimensions []ValuesMatrixDimension) []ValuesCombination {
+	// Each combination is represented by the selected option of every dimension, in order
+	selections := [][]ValuesMatrixOption{{}}
+	nonEmptyDimensions := []ValuesMatrixDimension{}
+	for _, dimension := range dimensions {
+		if len(dimension.Options) == 0 {
+			continue
+		}
+		nonEmptyDimensions = append(nonEmptyDimensions, dimension)
+		next := [][]ValuesMatrixOption{}
+		for _, selection := range selections {
+			for _, option := range dimension.Options {
+				next = append(next, append(append([]ValuesMatrixOption{}, selection...), option))
+			}
+		}
+		selections = next
+	}
+
+	combinations := []ValuesCombination{}
+	for _, selection := range selections {
+		combinations = append(combinations, newValuesCombination(options, nonEmptyDimensions, selection))
+	}
+	return combinations
+}
+
+// newValuesCombination applies the selected option of every dimension on top of a copy of the base options.
+func newValuesCombination(options *Options, dimensions []ValuesMatrixDimension, selection []ValuesMatrixOption) ValuesCombination {
+	combinationOptions := *options
+	combinationOptions.SetValues = copyStringMap(options.SetValues)
+	combinationOptions.SetStrValues = copyStringMap(options.SetStrValues)
+	combinationOptions.SetJsonValues = copyStringMap(options.SetJsonValues)
+	combinationOptions.ValuesFiles = append([]string{}, options.ValuesFiles...)
+
+	combination := ValuesCombination{
+		Selection:     map[string]string{},
+		Options:       &combinationOptions,
+		ExtraHelmArgs: []string{},
+	}
+	nameParts := []string{}
+	for i, option := range selection {
+		dimension := dimensions[i]
+		nameParts = append(nameParts, fmt.Sprintf("%s=%s", dimension.Name, option.Name))
+		combination.Selection[dimension.Name] = option.Name
+
+		mergeStringMap(combinationOptions.SetValues, option.SetValues)
+		mergeStringMap(combinationOptions.SetStrValues, option.SetStrValues)
+		mergeStringMap(combinationOptions.SetJsonValues, option.SetJsonValues)
+		combinationOptions.ValuesFiles = append(combinationOptions.ValuesFiles, option.ValuesFiles...)
+		combination.ExtraHelmArgs = append(combination.ExtraHelmArgs, option.ExtraHelmArgs...)
+	}
+	combination.Name = strings.Join(nameParts, ",")
+	return combination
+}
+
+// copyStringMap returns a copy of the given map, which is never nil.
+func copyStringMap(values map[string]string) map[string]string {
+	copied := map[string]string{}
+	mergeStringMap(copied, values)
+	return copied
+}
+
+// mergeStringMap sets all the entries of source in destination.
+func mergeStringMap(destination map[string]string, source map[string]string) {
+	for key, value := range source {
+		destination[key] = value
+	}
+}
@@ -0,0 +1,54 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetValuesMatrixCombinations(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		SetValues:   map[string]string{"image.tag": "1.0.0", "ingress.enabled": "false"},
+		ValuesFiles: []string{"values-ci.yaml"},
+	}
+	dimensions := []ValuesMatrixDimension{
+		NewValuesDimension("ingress.enabled", "true", "false"),
+		{Name: "empty"},
+		{
+			Name: "persistence",
+			Options: []ValuesMatrixOption{
+				{Name: "disabled", SetValues: map[string]string{"persistence.enabled": "false"}},
+				{Name: "large", SetJsonValues: map[string]string{"persistence": `{"enabled":true,"size":"100Gi"}`}, ValuesFiles: []string{"values-large.yaml"}},
+			},
+		},
+		NewKubeVersionDimension("1.27"),
+	}
+
+	combinations := GetValuesMatrixCombinations(options, dimensions)
+	require.Len(t, combinations, 4)
+
+	names := []string{}
+	for _, combination := range combinations {
+		names = append(names, combination.Name)
+	}
+	assert.Equal(t, []string{
+		"ingress.enabled=true,persistence=disabled,kube-version=1.27",
+		"ingress.enabled=true,persistence=large,kube-version=1.27",
+		"ingress.enabled=false,persistence=disabled,kube-version=1.27",
+		"ingress.enabled=false,persistence=large,kube-version=1.27",
+	}, names)
+
+	combination := combinations[1]
+	assert.Equal(t, map[string]string{"ingress.enabled": "true", "persistence": "large", "kube-version": "1.27"}, combination.Selection)
+	assert.Equal(t, map[string]string{"image.tag": "1.0.0", "ingress.enabled": "true"}, combination.Options.SetValues)
+	assert.Equal(t, map[string]string{"persistence": `{"enabled":true,"size":"100Gi"}`}, combination.Options.SetJsonValues)
+	assert.Equal(t, []string{"values-ci.yaml", "values-large.yaml"}, combination.Options.ValuesFiles)
+	assert.Equal(t, []string{"--kube-version", "1.27"}, combination.ExtraHelmArgs)
+
+	// The base options are left untouched
+	assert.Equal(t, map[string]string{"image.tag": "1.0.0", "ingress.enabled": "false"}, options.SetValues)
+	assert.Equal(t, []string{"values-ci.yaml"}, options.ValuesFiles)
+}
@@ -18,6 +18,7 @@ type GetResponse struct {
 // This function will return a sync.WaitGroup that can be used to wait for the checking to stop, and a read only channel
 // to stream the responses for each check.
 // Note that the channel has a buffer of 1000, after which it will start to drop the send events
+// See StartDowntimeMonitor to probe several URLs or custom requests, and get a report on the availability and latency.
 func ContinuouslyCheckUrl(
 	t testing.TestingT,
 	url string,
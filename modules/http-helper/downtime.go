@@ -0,0 +1,387 @@
+package http_helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// DowntimeMonitorOptions defines the requests that a downtime monitor probes, and how often.
+type DowntimeMonitorOptions struct {
+	// Requests to probe, e.g. to the URL of a load balancer and to a health check endpoint. Each request is probed
+	// independently, and gets its own report. The timeout of the requests defaults to 10 seconds.
+	Requests []HttpDoOptions
+
+	// Time between the start of two probes of a request. Defaults to 1 second, and must not be negative. A probe that
+	// takes longer delays the next one, rather than overlapping with it.
+	Interval time.Duration
+
+	// Function that decides whether a response shows that the service is up, by returning an error describing why it
+	// is not. Defaults to checking that the status code is 200.
+	ValidateResponse func(*Response) error
+}
+
+// Probe is the result of a single request made by a downtime monitor.
+type Probe struct {
+	// Time the request was made
+	Time time.Time
+
+	// Time until the response was read, or until the request failed
+	Latency time.Duration
+
+	// Status code of the response, or -1 if the request failed
+	StatusCode int
+
+	// Why the probe found the service down, or nil if it is up
+	Err error
+}
+
+// Up returns whether the probe found the service up.
+func (probe Probe) Up() bool {
+	return probe.Err == nil
+}
+
+// Outage is a window of consecutive failed probes.
+type Outage struct {
+	// Time of the first failed probe
+	Start time.Time
+
+	// Time of the next successful probe, or the time the monitor was stopped if the service never came back up. As no
+	// probe is made in between, the service may have come back up earlier.
+	End time.Time
+
+	// Number of failed probes during the outage, and the error of the first one
+	Probes   int
+	FirstErr error
+}
+
+// Duration returns the duration of the outage.
+func (outage Outage) Duration() time.Duration {
+	return outage.End.Sub(outage.Start)
+}
+
+// LatencyPercentiles summarizes the latency of the probes that got a response.
+type LatencyPercentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// TargetReport reports on the availability of one of the requests probed by a downtime monitor.
+type TargetReport struct {
+	Method string
+	Url    string
+
+	// All the probes of the request, in order
+	Probes []Probe
+
+	// Percentage of the probes that found the service up, e.g. 99.5
+	Availability float64
+
+	// Windows during which the service was down, in order, and the longest of them (zero if there was none)
+	Outages       []Outage
+	LongestOutage Outage
+
+	// Latency of the probes that got a response, whether it was valid or not
+	Latency LatencyPercentiles
+}
+
+// DowntimeReport reports on the availability of the requests probed by a downtime monitor, from the time it was
+// started until it was stopped.
+type DowntimeReport struct {
+	Start time.Time
+	End   time.Time
+
+	// Reports of each request, in the order of the options of the monitor
+	Targets []TargetReport
+}
+
+// DowntimeMonitor probes requests in the background to record the availability of a service, e.g. while it is
+// redeployed. Use StartDowntimeMonitor to create one.
+type DowntimeMonitor struct {
+	start    time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	targets  []*downtimeTarget
+	report   *DowntimeReport
+	t        testing.TestingT
+}
+
+// downtimeTarget records the probes of one of the requests of a downtime monitor.
+type downtimeTarget struct {
+	options     HttpDoOptions
+	requestBody []byte
+	probes      []Probe
+
+	// The client is reused by every probe, so that they do not open new connections, nor log the requests
+	client *http.Client
+}
+
+// StartDowntimeMonitor starts probing the given requests in the background, until Stop is called. If the test
+// supports it (as *testing.T does), the monitor is stopped when the test completes, if it was not stopped before. This
+// will fail the test if there is an error.
+func StartDowntimeMonitor(t testing.TestingT, options *DowntimeMonitorOptions) *DowntimeMonitor {
+	monitor, err := StartDowntimeMonitorE(t, options)
+	require.NoError(t, err)
+	return monitor
+}
+
+// StartDowntimeMonitorE starts probing the given requests in the background, until Stop is called. If the test
+// supports it (as *testing.T does), the monitor is stopped when the test completes, if it was not stopped before. Each
+// request is probed right away, and then at the given interval. Unlike ContinuouslyCheckUrl, the probes do not fail
+// the test: they are recorded in the report returned by Stop, on which assertions such as AssertMaxDowntime can be
+// made.
+func StartDowntimeMonitorE(t testing.TestingT, options *DowntimeMonitorOptions) (*DowntimeMonitor, error) {
+	if len(options.Requests) == 0 {
+		return nil, errors.New("no request to probe in the downtime monitor options")
+	}
+
+	interval := options.Interval
+	if interval < 0 {
+		return nil, fmt.Errorf("negative downtime monitor interval: %s", interval)
+	}
+	if interval == 0 {
+		interval = time.Second
+	}
+	validateResponse := options.ValidateResponse
+	if validateResponse == nil {
+		validateResponse = func(resp *Response) error {
+			if resp.StatusCode != 200 {
+				return fmt.Errorf("got a non-200 response (%d)", resp.StatusCode)
+			}
+			return nil
+		}
+	}
+
+	monitor := &DowntimeMonitor{stop: make(chan struct{}), t: t}
+	for _, requestOptions := range options.Requests {
+		// The body is sent again with every probe
+		requestBody, err := readRequestBodyE(requestOptions)
+		if err != nil {
+			return nil, err
+		}
+		if requestOptions.Method == "" {
+			requestOptions.Method = "GET"
+		}
+		if requestOptions.Timeout == 0 {
+			requestOptions.Timeout = 10
+		}
+		monitor.targets = append(monitor.targets, &downtimeTarget{
+			options:     requestOptions,
+			requestBody: requestBody,
+			client:      newResponseClient(requestOptions),
+		})
+	}
+
+	monitor.start = time.Now()
+	for _, target := range monitor.targets {
+		monitor.wg.Add(1)
+		go monitor.run(target, interval, validateResponse)
+	}
+
+	// The goroutines must not log once the test completed
+	if cleaner, ok := t.(interface{ Cleanup(func()) }); ok {
+		cleaner.Cleanup(func() { monitor.Stop() })
+	}
+
+	return monitor, nil
+}
+
+// run probes the request of the given target at the given interval, until the monitor is stopped.
+func (monitor *DowntimeMonitor) run(target *downtimeTarget, interval time.Duration, validateResponse func(*Response) error) {
+	defer monitor.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		probe := target.probe(validateResponse)
+		target.probes = append(target.probes, probe)
+		if !probe.Up() {
+			logger.Logf(monitor.t, "Downtime probe of %s %s failed: %v", target.options.Method, target.options.Url, probe.Err)
+		}
+
+		select {
+		case <-monitor.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe makes the request of the target once, and validates its response.
+func (target *downtimeTarget) probe(validateResponse func(*Response) error) Probe {
+	options := target.options
+	options.Body = bytes.NewReader(target.requestBody)
+
+	start := time.Now()
+	resp, err := doForResponseE(target.client, options)
+	latency := time.Since(start)
+	if err != nil {
+		return Probe{Time: start, Latency: latency, StatusCode: -1, Err: err}
+	}
+	return Probe{Time: start, Latency: latency, StatusCode: resp.StatusCode, Err: validateResponse(resp)}
+}
+
+// Stop stops probing, waits for the probes in flight to complete, and returns the report of the monitor. The report
+// is logged. Calling Stop again returns the same report.
+func (monitor *DowntimeMonitor) Stop() *DowntimeReport {
+	monitor.stopOnce.Do(func() {
+		close(monitor.stop)
+		monitor.wg.Wait()
+
+		monitor.report = &DowntimeReport{Start: monitor.start, End: time.Now()}
+		for _, target := range monitor.targets {
+			target.client.CloseIdleConnections()
+			targetReport := newTargetReport(target.options, target.probes, monitor.report.End)
+			logger.Logf(
+				monitor.t, "Downtime report of %s %s: %d probes, %.2f%% availability, %d outages, longest outage %s, latency p50 %s, p90 %s, p99 %s, max %s",
+				targetReport.Method, targetReport.Url, len(targetReport.Probes), targetReport.Availability,
+				len(targetReport.Outages), targetReport.LongestOutage.Duration(), targetReport.Latency.P50,
+				targetReport.Latency.P90, targetReport.Latency.P99, targetReport.Latency.Max,
+			)
+			monitor.report.Targets = append(monitor.report.Targets, targetReport)
+		}
+	})
+	return monitor.report
+}
+
+// newTargetReport computes the report of a request from its probes, given the time the monitor was stopped.
+func newTargetReport(options HttpDoOptions, probes []Probe, end time.Time) TargetReport {
+	report := TargetReport{Method: options.Method, Url: options.Url, Probes: probes, Outages: []Outage{}}
+
+	up := 0
+	latencies := []time.Duration{}
+	var outage *Outage
+	for _, probe := range probes {
+		if probe.StatusCode != -1 {
+			latencies = append(latencies, probe.Latency)
+		}
+
+		if probe.Up() {
+			up++
+			if outage != nil {
+				outage.End = probe.Time
+				report.Outages = append(report.Outages, *outage)
+				outage = nil
+			}
+			continue
+		}
+
+		if outage == nil {
+			outage = &Outage{Start: probe.Time, FirstErr: probe.Err}
+		}
+		outage.Probes++
+	}
+	if outage != nil {
+		outage.End = end
+		report.Outages = append(report.Outages, *outage)
+	}
+
+	if len(probes) > 0 {
+		report.Availability = 100 * float64(up) / float64(len(probes))
+	}
+	for _, outage := range report.Outages {
+		if outage.Duration() > report.LongestOutage.Duration() {
+			report.LongestOutage = outage
+		}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.Latency = LatencyPercentiles{
+		P50: latencyPercentile(latencies, 50),
+		P90: latencyPercentile(latencies, 90),
+		P99: latencyPercentile(latencies, 99),
+		Max: latencyPercentile(latencies, 100),
+	}
+
+	return report
+}
+
+// latencyPercentile returns the given percentile of the given sorted latencies, using the nearest-rank method, or zero
+// if there is none.
+func latencyPercentile(sortedLatencies []time.Duration, percentile float64) time.Duration {
+	if len(sortedLatencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sortedLatencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sortedLatencies[rank-1]
+}
+
+// Availability returns the percentage of all the probes of the monitor that found the service up, e.g. 99.5.
+func (report *DowntimeReport) Availability() float64 {
+	up, total := 0, 0
+	for _, target := range report.Targets {
+		for _, probe := range target.Probes {
+			total++
+			if probe.Up() {
+				up++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(up) / float64(total)
+}
+
+// LongestOutage returns the longest outage of any of the requests of the monitor, or a zero outage if there was none.
+func (report *DowntimeReport) LongestOutage() Outage {
+	longestOutage := Outage{}
+	for _, target := range report.Targets {
+		if target.LongestOutage.Duration() > longestOutage.Duration() {
+			longestOutage = target.LongestOutage
+		}
+	}
+	return longestOutage
+}
+
+// AssertMaxDowntime checks that no outage of any of the requests of the monitor lasted longer than the given duration.
+// Use zero to check that every single probe found the service up. This will fail the test if an outage lasted longer.
+func (report *DowntimeReport) AssertMaxDowntime(t testing.TestingT, maxDowntime time.Duration) {
+	require.NoError(t, report.AssertMaxDowntimeE(maxDowntime))
+}
+
+// AssertMaxDowntimeE checks that no outage of any of the requests of the monitor lasted longer than the given
+// duration, and returns a DowntimeExceededError for the first request that had a longer one. Use zero to check that
+// every single probe found the service up.
+func (report *DowntimeReport) AssertMaxDowntimeE(maxDowntime time.Duration) error {
+	for _, target := range report.Targets {
+		if len(target.Outages) > 0 && (maxDowntime == 0 || target.LongestOutage.Duration() > maxDowntime) {
+			return DowntimeExceededError{Url: target.Url, Outage: target.LongestOutage, MaxDowntime: maxDowntime}
+		}
+	}
+	return nil
+}
+
+// AssertMinAvailability checks that each request of the monitor was available at least the given percentage of the
+// time, e.g. 99.5, as measured by its probes. This will fail the test if a request was available less.
+func (report *DowntimeReport) AssertMinAvailability(t testing.TestingT, minAvailability float64) {
+	require.NoError(t, report.AssertMinAvailabilityE(minAvailability))
+}
+
+// AssertMinAvailabilityE checks that each request of the monitor was available at least the given percentage of the
+// time, e.g. 99.5, as measured by its probes, and returns an AvailabilityTooLowError for the first request that was
+// available less.
+func (report *DowntimeReport) AssertMinAvailabilityE(minAvailability float64) error {
+	for _, target := range report.Targets {
+		if target.Availability < minAvailability {
+			return AvailabilityTooLowError{Url: target.Url, Availability: target.Availability, MinAvailability: minAvailability}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package http_helper
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTargetReport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	down := errors.New("down")
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	probes := []Probe{
+		{Time: at(0), Latency: 10 * time.Millisecond, StatusCode: 200},
+		{Time: at(1), Latency: 20 * time.Millisecond, StatusCode: 503, Err: down},
+		{Time: at(2), Latency: 30 * time.Millisecond, StatusCode: -1, Err: down},
+		{Time: at(3), Latency: 40 * time.Millisecond, StatusCode: 200},
+		{Time: at(4), Latency: 50 * time.Millisecond, StatusCode: 200},
+		{Time: at(5), Latency: 60 * time.Millisecond, StatusCode: 503, Err: down},
+	}
+
+	report := newTargetReport(HttpDoOptions{Method: "GET", Url: "http://example.com"}, probes, at(7))
+	assert.Equal(t, 50.0, report.Availability)
+	require.Len(t, report.Outages, 2)
+	assert.Equal(t, Outage{Start: at(1), End: at(3), Probes: 2, FirstErr: down}, report.Outages[0])
+	assert.Equal(t, Outage{Start: at(5), End: at(7), Probes: 1, FirstErr: down}, report.Outages[1])
+	assert.Equal(t, 2*time.Second, report.LongestOutage.Duration())
+	// The failed request has no response, so its latency is not counted
+	assert.Equal(t, LatencyPercentiles{
+		P50: 40 * time.Millisecond,
+		P90: 60 * time.Millisecond,
+		P99: 60 * time.Millisecond,
+		Max: 60 * time.Millisecond,
+	}, report.Latency)
+
+	downtimeReport := &DowntimeReport{Start: start, End: at(7), Targets: []TargetReport{report}}
+	assert.Equal(t, 50.0, downtimeReport.Availability())
+	assert.NoError(t, downtimeReport.AssertMaxDowntimeE(2*time.Second))
+	require.ErrorAs(t, downtimeReport.AssertMaxDowntimeE(time.Second), &DowntimeExceededError{})
+	require.ErrorAs(t, downtimeReport.AssertMaxDowntimeE(0), &DowntimeExceededError{})
+	assert.NoError(t, downtimeReport.AssertMinAvailabilityE(50))
+	require.ErrorAs(t, downtimeReport.AssertMinAvailabilityE(99.9), &AvailabilityTooLowError{})
+}
+
+func TestDowntimeMonitor(t *testing.T) {
+	t.Parallel()
+
+	var unavailable atomic.Bool
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		bodyCopyHandler(w, r)
+	})
+	defer ts.Close()
+	healthy := getTestServerForFunction(bodyCopyHandler)
+	defer healthy.Close()
+
+	monitor := StartDowntimeMonitor(t, &DowntimeMonitorOptions{
+		Requests: []HttpDoOptions{
+			{Url: ts.URL},
+			{Method: "POST", Url: healthy.URL, Body: strings.NewReader("ping")},
+		},
+		Interval: 20 * time.Millisecond,
+		ValidateResponse: func(resp *Response) error {
+			if resp.StatusCode != 200 {
+				return errors.New("service unavailable")
+			}
+			if resp.Url == healthy.URL && resp.BodyString() != "ping" {
+				return errors.New("unexpected body")
+			}
+			return nil
+		},
+	})
+	time.Sleep(200 * time.Millisecond)
+	unavailable.Store(true)
+	time.Sleep(200 * time.Millisecond)
+	unavailable.Store(false)
+	time.Sleep(200 * time.Millisecond)
+	report := monitor.Stop()
+	assert.Same(t, report, monitor.Stop())
+
+	require.Len(t, report.Targets, 2)
+	target := report.Targets[0]
+	assert.Equal(t, "GET", target.Method)
+	require.NotEmpty(t, target.Outages)
+	assert.True(t, target.Availability < 100)
+	assert.True(t, target.LongestOutage.Duration() >= 100*time.Millisecond)
+	assert.True(t, target.Latency.Max > 0)
+
+	healthyTarget := report.Targets[1]
+	assert.Equal(t, 100.0, healthyTarget.Availability)
+	assert.Empty(t, healthyTarget.Outages)
+	assert.True(t, len(healthyTarget.Probes) > 1)
+
+	report.AssertMaxDowntime(t, 5*time.Second)
+	require.ErrorAs(t, report.AssertMaxDowntimeE(50*time.Millisecond), &DowntimeExceededError{})
+}
+
+func TestDowntimeMonitorReusesConnections(t *testing.T) {
+	t.Parallel()
+
+	var connections atomic.Int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(bodyCopyHandler))
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	monitor := StartDowntimeMonitor(t, &DowntimeMonitorOptions{
+		Requests: []HttpDoOptions{{Url: ts.URL}},
+		Interval: 10 * time.Millisecond,
+	})
+	time.Sleep(200 * time.Millisecond)
+	report := monitor.Stop()
+
+	require.True(t, len(report.Targets[0].Probes) > 1)
+	assert.Equal(t, 100.0, report.Targets[0].Availability)
+	assert.Equal(t, int32(1), connections.Load())
+}
+
+func TestDowntimeMonitorNegativeInterval(t *testing.T) {
+	t.Parallel()
+
+	_, err := StartDowntimeMonitorE(t, &DowntimeMonitorOptions{
+		Requests: []HttpDoOptions{{Url: "http://localhost"}},
+		Interval: -time.Second,
+	})
+	assert.Error(t, err)
+}
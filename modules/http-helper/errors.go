@@ -1,6 +1,10 @@
 package http_helper
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // ValidationFunctionFailed is an error that occurs if a validation function fails.
 type ValidationFunctionFailed struct {
@@ -12,3 +16,67 @@ type ValidationFunctionFailed struct {
 func (err ValidationFunctionFailed) Error() string {
 	return fmt.Sprintf("Validation failed for URL %s. Response status: %d. Response body:\n%s", err.Url, err.Status, err.Body)
 }
+
+// ResponseValidationFailed is an error that occurs if a validation function rejects a response.
+type ResponseValidationFailed struct {
+	Url    string
+	Status int
+	Cause  error
+}
+
+func (err ResponseValidationFailed) Error() string {
+	return fmt.Sprintf("Validation failed for URL %s. Response status: %d. Reason: %v", err.Url, err.Status, err.Cause)
+}
+
+func (err ResponseValidationFailed) Unwrap() error {
+	return err.Cause
+}
+
+// ResponseBodyNotJSONError is an error that occurs if the body of a response is expected to be JSON, but is not.
+type ResponseBodyNotJSONError struct {
+	Url   string
+	Cause error
+}
+
+func (err ResponseBodyNotJSONError) Error() string {
+	return fmt.Sprintf("Body of the response of URL %s is not valid JSON: %v", err.Url, err.Cause)
+}
+
+func (err ResponseBodyNotJSONError) Unwrap() error {
+	return err.Cause
+}
+
+// JSONSchemaValidationError is an error that occurs if the body of a response does not match a JSON schema.
+type JSONSchemaValidationError struct {
+	Url        string
+	Violations []string
+}
+
+func (err JSONSchemaValidationError) Error() string {
+	return fmt.Sprintf("Body of the response of URL %s does not match the JSON schema:\n%s", err.Url, strings.Join(err.Violations, "\n"))
+}
+
+// DowntimeExceededError is an error that occurs if a downtime monitor recorded an outage longer than allowed.
+type DowntimeExceededError struct {
+	Url         string
+	Outage      Outage
+	MaxDowntime time.Duration
+}
+
+func (err DowntimeExceededError) Error() string {
+	return fmt.Sprintf(
+		"URL %s was down for %s from %s (%d failed probes), which exceeds the maximum downtime of %s. First error: %v",
+		err.Url, err.Outage.Duration(), err.Outage.Start.Format(time.RFC3339), err.Outage.Probes, err.MaxDowntime, err.Outage.FirstErr,
+	)
+}
+
+// AvailabilityTooLowError is an error that occurs if a downtime monitor recorded a lower availability than required.
+type AvailabilityTooLowError struct {
+	Url             string
+	Availability    float64
+	MinAvailability float64
+}
+
+func (err AvailabilityTooLowError) Error() string {
+	return fmt.Sprintf("URL %s was available %.2f%% of the time, less than the required %.2f%%", err.Url, err.Availability, err.MinAvailability)
+}
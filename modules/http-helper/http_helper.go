@@ -28,6 +28,9 @@ type HttpDoOptions struct {
 	Headers   map[string]string
 	TlsConfig *tls.Config
 	Timeout   int
+
+	// If true, redirects are not followed, and the redirect response itself is returned
+	DisableRedirects bool
 }
 
 // HttpGet performs an HTTP GET, with an optional pointer to a custom TLS configuration, on the given URL and
@@ -260,32 +263,11 @@ func HTTPDoE(
 func HTTPDoWithOptionsE(
 	t testing.TestingT, options HttpDoOptions,
 ) (int, string, error) {
-	logger.Logf(t, "Making an HTTP %s call to URL %s", options.Method, options.Url)
-
-	tr := &http.Transport{
-		TLSClientConfig: options.TlsConfig,
-	}
-
-	client := http.Client{
-		// By default, Go does not impose a timeout, so an HTTP connection attempt can hang for a LONG time.
-		Timeout:   time.Duration(options.Timeout) * time.Second,
-		Transport: tr,
-	}
-
-	req := newRequest(options.Method, options.Url, options.Body, options.Headers)
-	resp, err := client.Do(req)
-	if err != nil {
-		return -1, "", err
-	}
-
-	defer resp.Body.Close()
-	respBody, err := io.ReadAll(resp.Body)
-
+	resp, err := HTTPDoForResponseE(t, options)
 	if err != nil {
 		return -1, "", err
 	}
-
-	return resp.StatusCode, strings.TrimSpace(string(respBody)), nil
+	return resp.StatusCode, resp.BodyString(), nil
 }
 
 // HTTPDoWithRetry repeatedly performs the given HTTP method on the given URL until the given status code and body are
@@ -346,19 +328,11 @@ func HTTPDoWithRetryWithOptionsE(
 	t testing.TestingT, options HttpDoOptions, expectedStatus int,
 	retries int, sleepBetweenRetries time.Duration,
 ) (string, error) {
-	var data []byte
-	if options.Body != nil {
-		// The request body is closed after a request is complete.
-		// Read the underlying data and cache it, so we can reuse for retried requests.
-		b, err := io.ReadAll(options.Body)
-		if err != nil {
-			return "", err
-		}
-		data = b
+	data, err := readRequestBodyE(options)
+	if err != nil {
+		return "", err
 	}
 
-	options.Body = nil
-
 	out, err := retry.DoWithRetryE(
 		t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), retries,
 		sleepBetweenRetries, func() (string, error) {
@@ -537,10 +511,19 @@ func HTTPDoWithCustomValidationWithOptionsE(t testing.TestingT, options HttpDoOp
 	return nil
 }
 
-func newRequest(method string, url string, body io.Reader, headers map[string]string) *http.Request {
+// readRequestBodyE reads the body of the request of the given options, so that it can be sent again when the request
+// is retried, as the request body is closed after a request is complete.
+func readRequestBodyE(options HttpDoOptions) ([]byte, error) {
+	if options.Body == nil {
+		return nil, nil
+	}
+	return io.ReadAll(options.Body)
+}
+
+func newRequestE(method string, url string, body io.Reader, headers map[string]string) (*http.Request, error) {
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	for k, v := range headers {
 		switch k {
@@ -550,5 +533,5 @@ func newRequest(method string, url string, body io.Reader, headers map[string]st
 			req.Header.Add(k, v)
 		}
 	}
-	return req
+	return req, nil
 }
@@ -0,0 +1,375 @@
+package http_helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Response is the response to an HTTP request, with everything that is needed to make assertions on it.
+type Response struct {
+	// Status code of the response, e.g. 200
+	StatusCode int
+
+	// Protocol of the response, e.g. HTTP/1.1 or HTTP/2.0
+	Proto string
+
+	// URL the response was returned for, which differs from the URL of the request if it was redirected
+	Url string
+
+	// Headers and cookies returned by the server
+	Header  http.Header
+	Cookies []*http.Cookie
+
+	// Raw body of the response. Use BodyString to get it as a trimmed string, as returned by the other functions of
+	// this package.
+	Body []byte
+
+	// Redirects that were followed to get to the response, in order
+	Redirects []Redirect
+
+	// State of the TLS connection the response was received on. Nil for plain HTTP.
+	TLS *tls.ConnectionState
+
+	// Time spent in each phase of the request
+	Timing Timing
+}
+
+// Redirect is a redirect response that was followed.
+type Redirect struct {
+	// URL that returned the redirect
+	Url string
+
+	// Status code of the redirect, e.g. 301 or 302
+	StatusCode int
+
+	// URL the redirect pointed to
+	Location string
+
+	// Headers and cookies returned with the redirect
+	Header  http.Header
+	Cookies []*http.Cookie
+}
+
+// Timing is the time spent in each phase of a request. The connection phases are those of the last request, when it
+// was redirected, and are zero if the request reused a connection.
+type Timing struct {
+	DNSLookup    time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+
+	// Time from the start of the request until the first byte of the response, including the redirects
+	TimeToFirstByte time.Duration
+
+	// Time from the start of the request until the whole body of the response was read, including the redirects
+	Total time.Duration
+}
+
+// BodyString returns the body of the response as a string, with leading and trailing white space removed.
+func (resp *Response) BodyString() string {
+	return strings.TrimSpace(string(resp.Body))
+}
+
+// GetHeader returns the first value of the given header of the response, or an empty string if the header is not set.
+// The name is case insensitive.
+func (resp *Response) GetHeader(name string) string {
+	return resp.Header.Get(name)
+}
+
+// GetCookie returns the cookie with the given name that the response sets, or nil if it sets no such cookie.
+func (resp *Response) GetCookie(name string) *http.Cookie {
+	for _, cookie := range resp.Cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSONPath queries the JSON body of the response with the given JSONPath string, using the kubectl syntax,
+// e.g. {.items[*].name}, and unmarshals the result into the given go object. Note that the output will always be a
+// list, as with k8s.UnmarshalJSONPath. This will fail the test if there is an error.
+func (resp *Response) UnmarshalJSONPath(t testing.TestingT, jsonpathStr string, output interface{}) {
+	require.NoError(t, resp.UnmarshalJSONPathE(jsonpathStr, output))
+}
+
+// UnmarshalJSONPathE queries the JSON body of the response with the given JSONPath string, using the kubectl syntax,
+// e.g. {.items[*].name}, and unmarshals the result into the given go object. Note that the output will always be a
+// list: if the path maps to a single value, the output is a list of that single element.
+func (resp *Response) UnmarshalJSONPathE(jsonpathStr string, output interface{}) error {
+	var blob interface{}
+	if err := json.Unmarshal(resp.Body, &blob); err != nil {
+		return ResponseBodyNotJSONError{Url: resp.Url, Cause: err}
+	}
+
+	jsonpathParser := jsonpath.New("response")
+	jsonpathParser.EnableJSONOutput(true)
+	if err := jsonpathParser.Parse(jsonpathStr); err != nil {
+		return err
+	}
+	outputJSONBuffer := new(bytes.Buffer)
+	if err := jsonpathParser.Execute(outputJSONBuffer, blob); err != nil {
+		return err
+	}
+	return json.Unmarshal(outputJSONBuffer.Bytes(), output)
+}
+
+// ValidateJSONSchema validates the JSON body of the response against the given JSON schema. This will fail the test
+// if the body does not match the schema.
+func (resp *Response) ValidateJSONSchema(t testing.TestingT, schema string) {
+	require.NoError(t, resp.ValidateJSONSchemaE(schema))
+}
+
+// ValidateJSONSchemaE validates the JSON body of the response against the given JSON schema, and returns a
+// JSONSchemaValidationError listing the violations if the body does not match it. The schema is interpreted as in
+// OpenAPI, which supports the keywords of JSON schema draft 4, such as type, required, properties, items, enum and
+// pattern.
+func (resp *Response) ValidateJSONSchemaE(schema string) error {
+	var parsedSchema spec.Schema
+	if err := json.Unmarshal([]byte(schema), &parsedSchema); err != nil {
+		return fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	var blob interface{}
+	if err := json.Unmarshal(resp.Body, &blob); err != nil {
+		return ResponseBodyNotJSONError{Url: resp.Url, Cause: err}
+	}
+
+	result := validate.NewSchemaValidator(&parsedSchema, nil, "", strfmt.Default).Validate(blob)
+	if result.IsValid() {
+		return nil
+	}
+	violations := []string{}
+	for _, err := range result.Errors {
+		violations = append(violations, err.Error())
+	}
+	return JSONSchemaValidationError{Url: resp.Url, Violations: violations}
+}
+
+// HTTPDoForResponse performs the given HTTP method on the given URL and returns the full response, including its
+// headers, cookies, redirects, TLS state and timing. If there's any error, fail the test.
+func HTTPDoForResponse(t testing.TestingT, options HttpDoOptions) *Response {
+	resp, err := HTTPDoForResponseE(t, options)
+	require.NoError(t, err)
+	return resp
+}
+
+// HTTPDoForResponseE performs the given HTTP method on the given URL and returns the full response, including its
+// headers, cookies, redirects, TLS state and timing, or any error.
+func HTTPDoForResponseE(t testing.TestingT, options HttpDoOptions) (*Response, error) {
+	logger.Logf(t, "Making an HTTP %s call to URL %s", options.Method, options.Url)
+
+	client := newResponseClient(options)
+	defer client.CloseIdleConnections()
+	return doForResponseE(client, options)
+}
+
+// redirectsContextKey is the key of the context value in which the client returned by newResponseClient records the
+// redirects of a request.
+type redirectsContextKey struct{}
+
+// newResponseClient returns a client configured by the given options, to make requests with doForResponseE. The
+// client can be reused for several requests, e.g. to probe the same URL again.
+func newResponseClient(options HttpDoOptions) *http.Client {
+	tr := &http.Transport{
+		TLSClientConfig: options.TlsConfig,
+	}
+	return &http.Client{
+		// By default, Go does not impose a timeout, so an HTTP connection attempt can hang for a LONG time.
+		Timeout:   time.Duration(options.Timeout) * time.Second,
+		Transport: tr,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if options.DisableRedirects {
+				return http.ErrUseLastResponse
+			}
+			// Same limit as the default policy of the client
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if redirects, ok := req.Context().Value(redirectsContextKey{}).(*[]Redirect); ok {
+				*redirects = append(*redirects, Redirect{
+					Url:        via[len(via)-1].URL.String(),
+					StatusCode: req.Response.StatusCode,
+					Location:   req.URL.String(),
+					Header:     req.Response.Header,
+					Cookies:    req.Response.Cookies(),
+				})
+			}
+			return nil
+		},
+	}
+}
+
+// doForResponseE makes the request described by the given options with the given client, which must have been
+// returned by newResponseClient, and returns the full response.
+func doForResponseE(client *http.Client, options HttpDoOptions) (*Response, error) {
+	req, err := newRequestE(options.Method, options.Url, options.Body, options.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	redirects := []Redirect{}
+	tracer := &requestTracer{start: time.Now()}
+	ctx := context.WithValue(req.Context(), redirectsContextKey{}, &redirects)
+	req = req.WithContext(httptrace.WithClientTrace(ctx, tracer.clientTrace()))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		Url:        resp.Request.URL.String(),
+		Header:     resp.Header,
+		Cookies:    resp.Cookies(),
+		Body:       respBody,
+		Redirects:  redirects,
+		TLS:        resp.TLS,
+		Timing:     tracer.timing(),
+	}, nil
+}
+
+// requestTracer records the time spent in each phase of a request.
+type requestTracer struct {
+	// The hooks of the trace may be called from different goroutines
+	mutex sync.Mutex
+
+	start             time.Time
+	dnsStart          time.Time
+	connectStart      time.Time
+	tlsHandshakeStart time.Time
+	result            Timing
+}
+
+func (tracer *requestTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tracer.record(func() { tracer.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tracer.record(func() { tracer.result.DNSLookup = time.Since(tracer.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			tracer.record(func() { tracer.connectStart = time.Now() })
+		},
+		ConnectDone: func(string, string, error) {
+			tracer.record(func() { tracer.result.Connect = time.Since(tracer.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			tracer.record(func() { tracer.tlsHandshakeStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tracer.record(func() { tracer.result.TLSHandshake = time.Since(tracer.tlsHandshakeStart) })
+		},
+		GotFirstResponseByte: func() {
+			tracer.record(func() { tracer.result.TimeToFirstByte = time.Since(tracer.start) })
+		},
+	}
+}
+
+func (tracer *requestTracer) record(update func()) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	update()
+}
+
+// timing returns the timing of the request, which is complete once its response body was read.
+func (tracer *requestTracer) timing() Timing {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	result := tracer.result
+	result.Total = time.Since(tracer.start)
+	return result
+}
+
+// HTTPDoWithResponseValidation performs the given HTTP method on the given URL and validates the full response using
+// the given function, which returns an error describing why the response is not valid. If the request or the
+// validation fails, fail the test.
+func HTTPDoWithResponseValidation(t testing.TestingT, options HttpDoOptions, validateResponse func(*Response) error) *Response {
+	resp, err := HTTPDoWithResponseValidationE(t, options, validateResponse)
+	require.NoError(t, err)
+	return resp
+}
+
+// HTTPDoWithResponseValidationE performs the given HTTP method on the given URL and validates the full response using
+// the given function, which returns an error describing why the response is not valid. If the validation fails, the
+// response is returned along with a ResponseValidationFailed error.
+func HTTPDoWithResponseValidationE(t testing.TestingT, options HttpDoOptions, validateResponse func(*Response) error) (*Response, error) {
+	resp, err := HTTPDoForResponseE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateResponse(resp); err != nil {
+		return resp, ResponseValidationFailed{Url: options.Url, Status: resp.StatusCode, Cause: err}
+	}
+
+	return resp, nil
+}
+
+// HTTPDoWithRetryWithResponseValidation repeatedly performs the given HTTP method on the given URL until the given
+// validation function accepts the full response or max retries has been exceeded, and returns the accepted response.
+// If max retries has been exceeded, fail the test.
+func HTTPDoWithRetryWithResponseValidation(
+	t testing.TestingT, options HttpDoOptions, retries int, sleepBetweenRetries time.Duration,
+	validateResponse func(*Response) error,
+) *Response {
+	resp, err := HTTPDoWithRetryWithResponseValidationE(t, options, retries, sleepBetweenRetries, validateResponse)
+	require.NoError(t, err)
+	return resp
+}
+
+// HTTPDoWithRetryWithResponseValidationE repeatedly performs the given HTTP method on the given URL until the given
+// validation function accepts the full response or max retries has been exceeded, and returns the accepted response.
+// This makes it possible to wait for conditions that the status code and body do not reflect, such as the headers set
+// by a CDN, CORS headers or the cookies of an authentication flow.
+func HTTPDoWithRetryWithResponseValidationE(
+	t testing.TestingT, options HttpDoOptions, retries int, sleepBetweenRetries time.Duration,
+	validateResponse func(*Response) error,
+) (*Response, error) {
+	data, err := readRequestBodyE(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *Response
+	_, err = retry.DoWithRetryE(
+		t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), retries,
+		sleepBetweenRetries, func() (string, error) {
+			options.Body = bytes.NewReader(data)
+			validResp, err := HTTPDoWithResponseValidationE(t, options, validateResponse)
+			if err != nil {
+				return "", err
+			}
+			resp = validResp
+			return "", nil
+		})
+
+	return resp, err
+}
@@ -0,0 +1,174 @@
+package http_helper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPDoForResponseHeadersAndCookies(t *testing.T) {
+	t.Parallel()
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1a2b3c", HttpOnly: true})
+		w.Write([]byte("  Hello, Terratest!\n"))
+	})
+	defer ts.Close()
+
+	resp := HTTPDoForResponse(t, HttpDoOptions{
+		Method:  "GET",
+		Url:     ts.URL,
+		Headers: map[string]string{"Origin": "https://example.com"},
+		Timeout: 10,
+	})
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "HTTP/1.1", resp.Proto)
+	assert.Equal(t, "https://example.com", resp.GetHeader("access-control-allow-origin"))
+	require.NotNil(t, resp.GetCookie("session"))
+	assert.Equal(t, "1a2b3c", resp.GetCookie("session").Value)
+	assert.True(t, resp.GetCookie("session").HttpOnly)
+	assert.Nil(t, resp.GetCookie("missing"))
+	assert.Equal(t, "Hello, Terratest!", resp.BodyString())
+	assert.Empty(t, resp.Redirects)
+	assert.Nil(t, resp.TLS)
+	assert.True(t, resp.Timing.Total >= resp.Timing.TimeToFirstByte)
+	assert.True(t, resp.Timing.TimeToFirstByte > 0)
+}
+
+func TestHTTPDoForResponseRedirects(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "login", Value: "done"})
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("final"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp := HTTPDoForResponse(t, HttpDoOptions{Method: "GET", Url: ts.URL + "/old", Timeout: 10})
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, ts.URL+"/final", resp.Url)
+	assert.Equal(t, "final", resp.BodyString())
+	require.Len(t, resp.Redirects, 2)
+	assert.Equal(t, Redirect{
+		Url:        ts.URL + "/old",
+		StatusCode: http.StatusMovedPermanently,
+		Location:   ts.URL + "/new",
+		Header:     resp.Redirects[0].Header,
+		Cookies:    []*http.Cookie{},
+	}, resp.Redirects[0])
+	assert.Equal(t, http.StatusFound, resp.Redirects[1].StatusCode)
+	assert.Equal(t, ts.URL+"/final", resp.Redirects[1].Location)
+	require.Len(t, resp.Redirects[1].Cookies, 1)
+	assert.Equal(t, "login", resp.Redirects[1].Cookies[0].Name)
+
+	resp = HTTPDoForResponse(t, HttpDoOptions{Method: "GET", Url: ts.URL + "/old", Timeout: 10, DisableRedirects: true})
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "/new", resp.GetHeader("Location"))
+	assert.Empty(t, resp.Redirects)
+}
+
+func TestHTTPDoForResponseTLS(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewTLSServer(http.HandlerFunc(bodyCopyHandler))
+	defer ts.Close()
+
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+	resp := HTTPDoForResponse(t, HttpDoOptions{Method: "GET", Url: ts.URL, TlsConfig: tlsConfig, Timeout: 10})
+	require.NotNil(t, resp.TLS)
+	assert.True(t, resp.TLS.HandshakeComplete)
+	require.NotEmpty(t, resp.TLS.PeerCertificates)
+	assert.True(t, resp.Timing.TLSHandshake > 0)
+}
+
+func TestResponseJSONAssertions(t *testing.T) {
+	t.Parallel()
+
+	resp := &Response{Url: "http://example.com", Body: []byte(`{"items": [{"name": "a", "size": 1}, {"name": "b", "size": 2}]}`)}
+
+	var names []string
+	resp.UnmarshalJSONPath(t, "{.items[*].name}", &names)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	var sizes []int
+	resp.UnmarshalJSONPath(t, "{.items[1].size}", &sizes)
+	assert.Equal(t, []int{2}, sizes)
+
+	schema := `{
+		"type": "object",
+		"required": ["items"],
+		"properties": {
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["name", "size"],
+					"properties": {"name": {"type": "string"}, "size": {"type": "integer", "minimum": 1}}
+				}
+			}
+		}
+	}`
+	resp.ValidateJSONSchema(t, schema)
+
+	invalidResp := &Response{Url: "http://example.com", Body: []byte(`{"items": [{"name": 1, "size": 0}]}`)}
+	err := invalidResp.ValidateJSONSchemaE(schema)
+	var schemaErr JSONSchemaValidationError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Len(t, schemaErr.Violations, 2)
+
+	notJSONResp := &Response{Url: "http://example.com", Body: []byte("Hello, Terratest!")}
+	require.ErrorAs(t, notJSONResp.ValidateJSONSchemaE(schema), &ResponseBodyNotJSONError{})
+	require.ErrorAs(t, notJSONResp.UnmarshalJSONPathE("{.items}", &names), &ResponseBodyNotJSONError{})
+}
+
+func TestHTTPDoWithRetryWithResponseValidation(t *testing.T) {
+	t.Parallel()
+	requests := 0
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 2 {
+			w.Header().Set("X-Cache", "HIT")
+		} else {
+			w.Header().Set("X-Cache", "MISS")
+		}
+		bodyCopyHandler(w, r)
+	})
+	defer ts.Close()
+
+	expectCacheHit := func(resp *Response) error {
+		if resp.GetHeader("X-Cache") != "HIT" {
+			return fmt.Errorf("expected a cache hit, got %s", resp.GetHeader("X-Cache"))
+		}
+		return nil
+	}
+
+	options := HttpDoOptions{Method: "POST", Url: ts.URL, Body: strings.NewReader("TEST_CONTENT"), Timeout: 10}
+	_, err := HTTPDoWithResponseValidationE(t, options, expectCacheHit)
+	var validationErr ResponseValidationFailed
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 200, validationErr.Status)
+
+	options.Body = strings.NewReader("TEST_CONTENT")
+	resp := HTTPDoWithRetryWithResponseValidation(t, options, 5, 10*time.Millisecond, expectCacheHit)
+	assert.Equal(t, "TEST_CONTENT", resp.BodyString())
+	assert.Equal(t, 3, requests)
+
+	_, err = HTTPDoWithRetryWithResponseValidationE(t, options, 1, 10*time.Millisecond, func(resp *Response) error {
+		return errors.New("never valid")
+	})
+	require.Error(t, err)
+}
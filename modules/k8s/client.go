@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -25,6 +26,31 @@ func GetKubernetesClientE(t testing.TestingT) (*kubernetes.Clientset, error) {
 
 // GetKubernetesClientFromOptionsE returns a Kubernetes API client given a configured KubectlOptions object.
 func GetKubernetesClientFromOptionsE(t testing.TestingT, options *KubectlOptions) (*kubernetes.Clientset, error) {
+	config, err := getRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return clientset, nil
+}
+
+// GetDynamicClientFromOptionsE returns a Kubernetes dynamic API client given a configured KubectlOptions object. The
+// dynamic client can operate on arbitrary resources, including custom resources, as unstructured objects.
+func GetDynamicClientFromOptionsE(t testing.TestingT, options *KubectlOptions) (dynamic.Interface, error) {
+	config, err := getRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// getRestConfigFromOptionsE returns the rest config to use for API clients given a configured KubectlOptions object.
+func getRestConfigFromOptionsE(t testing.TestingT, options *KubectlOptions) (*rest.Config, error) {
 	var err error
 	var config *rest.Config
 
@@ -55,10 +81,5 @@ func GetKubernetesClientFromOptionsE(t testing.TestingT, options *KubectlOptions
 		}
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
-	}
-
-	return clientset, nil
+	return config, nil
 }
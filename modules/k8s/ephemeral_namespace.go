@@ -0,0 +1,332 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	// EphemeralNamespaceLabel is the label set to "true" on every namespace created with CreateEphemeralNamespace. It is
+	// used by DeleteStaleEphemeralNamespaces to find namespaces left behind by previous test runs.
+	EphemeralNamespaceLabel = "terratest.gruntwork.io/ephemeral"
+	// EphemeralNamespaceTestNameAnnotation records the name of the test that created an ephemeral namespace.
+	EphemeralNamespaceTestNameAnnotation = "terratest.gruntwork.io/test-name"
+
+	defaultEphemeralNamespacePrefix        = "terratest"
+	defaultEphemeralNamespaceDeleteTimeout = 5 * time.Minute
+	ephemeralNamespaceResourceName         = "terratest"
+)
+
+// EphemeralNamespaceOptions configures a namespace created with CreateEphemeralNamespace.
+type EphemeralNamespaceOptions struct {
+	// NamePrefix is prepended to a random unique ID to build the namespace name. Defaults to "terratest".
+	NamePrefix string
+	// Labels are added to the namespace, in addition to EphemeralNamespaceLabel.
+	Labels map[string]string
+	// ResourceQuota, when set, is applied to the namespace as a ResourceQuota.
+	ResourceQuota *corev1.ResourceQuotaSpec
+	// LimitRange, when set, is applied to the namespace as a LimitRange.
+	LimitRange *corev1.LimitRangeSpec
+	// ServiceAccountName, when set, creates a ServiceAccount in the namespace along with a kubeconfig that authenticates
+	// as it. The ServiceAccount is granted ServiceAccountClusterRole and ServiceAccountRules within the namespace only.
+	ServiceAccountName string
+	// ServiceAccountClusterRole is the name of a ClusterRole (e.g. "edit" or "view") bound to the ServiceAccount within
+	// the namespace.
+	ServiceAccountClusterRole string
+	// ServiceAccountRules are granted to the ServiceAccount through a Role within the namespace.
+	ServiceAccountRules []rbacv1.PolicyRule
+	// DeleteTimeout is how long to wait for the namespace to finish terminating on delete. Defaults to 5 minutes.
+	DeleteTimeout time.Duration
+}
+
+// EphemeralNamespace is a uniquely named namespace that only lives for the duration of a test.
+type EphemeralNamespace struct {
+	Name string
+	// KubectlOptions targets the namespace with the same credentials that were used to create it.
+	KubectlOptions *KubectlOptions
+	// ServiceAccountKubectlOptions targets the namespace while authenticating as the scoped ServiceAccount. This is nil
+	// unless EphemeralNamespaceOptions.ServiceAccountName was set.
+	ServiceAccountKubectlOptions *KubectlOptions
+
+	deleteTimeout time.Duration
+	deleted       bool
+}
+
+// cleanupT is implemented by *testing.T and *testing.B, which can run functions when the test finishes.
+type cleanupT interface {
+	Cleanup(func())
+}
+
+// CreateEphemeralNamespace creates a uniquely named, labeled namespace and returns it. When t supports Cleanup (as
+// *testing.T does), the namespace is deleted when the test finishes, and deletion waits for the namespace to terminate.
+// This will fail the test if there is an error.
+func CreateEphemeralNamespace(t testing.TestingT, options *KubectlOptions, namespaceOptions EphemeralNamespaceOptions) *EphemeralNamespace {
+	namespace, err := CreateEphemeralNamespaceE(t, options, namespaceOptions)
+	require.NoError(t, err)
+	return namespace
+}
+
+// CreateEphemeralNamespaceE creates a uniquely named, labeled namespace and returns it. When t supports Cleanup (as
+// *testing.T does), the namespace is deleted when the test finishes, and deletion waits for the namespace to terminate.
+// If any of the namespaced resources fail to be created, the namespace is deleted before returning the error.
+func CreateEphemeralNamespaceE(t testing.TestingT, options *KubectlOptions, namespaceOptions EphemeralNamespaceOptions) (*EphemeralNamespace, error) {
+	prefix := namespaceOptions.NamePrefix
+	if prefix == "" {
+		prefix = defaultEphemeralNamespacePrefix
+	}
+	deleteTimeout := namespaceOptions.DeleteTimeout
+	if deleteTimeout <= 0 {
+		deleteTimeout = defaultEphemeralNamespaceDeleteTimeout
+	}
+
+	labels := map[string]string{}
+	for key, value := range namespaceOptions.Labels {
+		labels[key] = value
+	}
+	labels[EphemeralNamespaceLabel] = "true"
+
+	name := fmt.Sprintf("%s-%s", prefix, strings.ToLower(random.UniqueId()))
+	// Annotation values can hold any string, unlike label values, so the full test name is kept there.
+	err := CreateNamespaceWithMetadataE(t, options, metav1.ObjectMeta{
+		Name:        name,
+		Labels:      labels,
+		Annotations: map[string]string{EphemeralNamespaceTestNameAnnotation: t.Name()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Logf(t, "Created ephemeral namespace %s", name)
+
+	namespaceKubectlOptions := &KubectlOptions{
+		ContextName:   options.ContextName,
+		ConfigPath:    options.ConfigPath,
+		Namespace:     name,
+		Env:           options.Env,
+		InClusterAuth: options.InClusterAuth,
+		RestConfig:    options.RestConfig,
+		Logger:        options.Logger,
+	}
+	namespace := &EphemeralNamespace{
+		Name:           name,
+		KubectlOptions: namespaceKubectlOptions,
+		deleteTimeout:  deleteTimeout,
+	}
+	if cleanupable, ok := t.(cleanupT); ok {
+		cleanupable.Cleanup(func() { namespace.Delete(t) })
+	}
+
+	if err := namespace.setupE(t, namespaceOptions); err != nil {
+		if deleteErr := namespace.DeleteE(t); deleteErr != nil {
+			logger.Logf(t, "Error deleting ephemeral namespace %s after failed setup: %s", name, deleteErr)
+		}
+		return nil, err
+	}
+	return namespace, nil
+}
+
+// setupE creates the optional resources of the ephemeral namespace.
+func (namespace *EphemeralNamespace) setupE(t testing.TestingT, namespaceOptions EphemeralNamespaceOptions) error {
+	clientset, err := GetKubernetesClientFromOptionsE(t, namespace.KubectlOptions)
+	if err != nil {
+		return err
+	}
+	objectMeta := metav1.ObjectMeta{Name: ephemeralNamespaceResourceName, Namespace: namespace.Name}
+
+	if namespaceOptions.ResourceQuota != nil {
+		quota := corev1.ResourceQuota{ObjectMeta: objectMeta, Spec: *namespaceOptions.ResourceQuota}
+		if _, err := clientset.CoreV1().ResourceQuotas(namespace.Name).Create(context.Background(), &quota, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	if namespaceOptions.LimitRange != nil {
+		limitRange := corev1.LimitRange{ObjectMeta: objectMeta, Spec: *namespaceOptions.LimitRange}
+		if _, err := clientset.CoreV1().LimitRanges(namespace.Name).Create(context.Background(), &limitRange, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	if namespaceOptions.ServiceAccountName == "" {
+		return nil
+	}
+
+	serviceAccountName := namespaceOptions.ServiceAccountName
+	if err := CreateServiceAccountE(t, namespace.KubectlOptions, serviceAccountName); err != nil {
+		return err
+	}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: namespace.Name}}
+	if namespaceOptions.ServiceAccountClusterRole != "" {
+		roleBinding := rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName + "-cluster-role", Namespace: namespace.Name},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: namespaceOptions.ServiceAccountClusterRole},
+		}
+		if _, err := clientset.RbacV1().RoleBindings(namespace.Name).Create(context.Background(), &roleBinding, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	if len(namespaceOptions.ServiceAccountRules) > 0 {
+		role := rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName, Namespace: namespace.Name},
+			Rules:      namespaceOptions.ServiceAccountRules,
+		}
+		if _, err := clientset.RbacV1().Roles(namespace.Name).Create(context.Background(), &role, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		roleBinding := rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName, Namespace: namespace.Name},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: serviceAccountName},
+		}
+		if _, err := clientset.RbacV1().RoleBindings(namespace.Name).Create(context.Background(), &roleBinding, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	// Request a token through the TokenRequest API, since clusters running Kubernetes 1.24 and newer no longer
+	// provision token secrets for service accounts.
+	tokenRequest, err := clientset.CoreV1().ServiceAccounts(namespace.Name).CreateToken(
+		context.Background(),
+		serviceAccountName,
+		&authenticationv1.TokenRequest{},
+		metav1.CreateOptions{},
+	)
+	if err != nil {
+		return err
+	}
+
+	kubeConfigPath, err := namespace.copyKubeConfigE(t)
+	if err != nil {
+		return err
+	}
+	serviceAccountKubectlOptions := NewKubectlOptions(namespace.Name, kubeConfigPath, namespace.Name)
+	if err := AddConfigContextForServiceAccountE(t, serviceAccountKubectlOptions, namespace.Name, serviceAccountName, tokenRequest.Status.Token); err != nil {
+		os.Remove(kubeConfigPath)
+		return err
+	}
+	namespace.ServiceAccountKubectlOptions = serviceAccountKubectlOptions
+	return nil
+}
+
+// copyKubeConfigE copies the kubeconfig of the ephemeral namespace options to a temp file whose current context is the
+// context of the options, so that contexts can be added to it without touching the original.
+func (namespace *EphemeralNamespace) copyKubeConfigE(t testing.TestingT) (string, error) {
+	configPath, err := namespace.KubectlOptions.GetConfigPath(t)
+	if err != nil {
+		return "", err
+	}
+	rawConfig, err := clientcmd.LoadFromFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	if namespace.KubectlOptions.ContextName != "" {
+		rawConfig.CurrentContext = namespace.KubectlOptions.ContextName
+	}
+
+	tmpConfig, err := os.CreateTemp("", namespace.Name)
+	if err != nil {
+		return "", err
+	}
+	tmpConfig.Close()
+	if err := clientcmd.WriteToFile(*rawConfig, tmpConfig.Name()); err != nil {
+		os.Remove(tmpConfig.Name())
+		return "", err
+	}
+	return tmpConfig.Name(), nil
+}
+
+// Delete deletes the ephemeral namespace and waits until it is fully terminated. This is a noop if the namespace was
+// already deleted. This will fail the test if there is an error.
+func (namespace *EphemeralNamespace) Delete(t testing.TestingT) {
+	require.NoError(t, namespace.DeleteE(t))
+}
+
+// DeleteE deletes the ephemeral namespace and waits until it is fully terminated, then removes the service account
+// kubeconfig, if any. This is a noop if the namespace was already deleted, and only waits if it is already terminating.
+func (namespace *EphemeralNamespace) DeleteE(t testing.TestingT) error {
+	if namespace.deleted {
+		return nil
+	}
+	// A namespace that is already terminating, e.g. because a previous call timed out, can not be deleted again
+	err := DeleteNamespaceE(t, namespace.KubectlOptions, namespace.Name)
+	if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+		return err
+	}
+	sleepBetweenRetries := 2 * time.Second
+	retries := int(namespace.deleteTimeout / sleepBetweenRetries)
+	if err := WaitUntilNamespaceDeletedE(t, namespace.KubectlOptions, namespace.Name, retries, sleepBetweenRetries); err != nil {
+		return err
+	}
+	namespace.deleted = true
+
+	if namespace.ServiceAccountKubectlOptions != nil {
+		if err := os.Remove(namespace.ServiceAccountKubectlOptions.ConfigPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteStaleEphemeralNamespaces deletes ephemeral namespaces that match the given labels and are older than maxAge,
+// e.g. because a previous test run was killed before it could clean up. Pass nil labels to consider every ephemeral
+// namespace. It returns the names of the deleted namespaces. This will fail the test if there is an error.
+func DeleteStaleEphemeralNamespaces(t testing.TestingT, options *KubectlOptions, labels map[string]string, maxAge time.Duration) []string {
+	deleted, err := DeleteStaleEphemeralNamespacesE(t, options, labels, maxAge)
+	require.NoError(t, err)
+	return deleted
+}
+
+// DeleteStaleEphemeralNamespacesE deletes ephemeral namespaces that match the given labels and are older than maxAge,
+// e.g. because a previous test run was killed before it could clean up. Pass nil labels to consider every ephemeral
+// namespace. It returns the names of the deleted namespaces. Namespaces that are already terminating are skipped, and
+// this does not wait for the deleted namespaces to terminate.
+func DeleteStaleEphemeralNamespacesE(t testing.TestingT, options *KubectlOptions, labels map[string]string, maxAge time.Duration) ([]string, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	selectorLabels := map[string]string{EphemeralNamespaceLabel: "true"}
+	for key, value := range labels {
+		selectorLabels[key] = value
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(
+		context.Background(),
+		metav1.ListOptions{LabelSelector: makeLabels(selectorLabels)},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, namespace := range namespaces.Items {
+		if namespace.Status.Phase == corev1.NamespaceTerminating || time.Since(namespace.CreationTimestamp.Time) < maxAge {
+			continue
+		}
+		logger.Logf(
+			t,
+			"Deleting stale ephemeral namespace %s created at %s by test %s",
+			namespace.Name,
+			namespace.CreationTimestamp,
+			namespace.Annotations[EphemeralNamespaceTestNameAnnotation],
+		)
+		err := clientset.CoreV1().Namespaces().Delete(context.Background(), namespace.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return deleted, err
+		}
+		deleted = append(deleted, namespace.Name)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,102 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+func TestCreateEphemeralNamespace(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "")
+	namespace := CreateEphemeralNamespace(t, options, EphemeralNamespaceOptions{
+		NamePrefix: "terratest-ephemeral",
+		Labels:     map[string]string{"team": "terratest"},
+		ResourceQuota: &corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+		},
+		LimitRange: &corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			}},
+		},
+	})
+
+	require.True(t, strings.HasPrefix(namespace.Name, "terratest-ephemeral-"))
+	require.Equal(t, namespace.Name, namespace.KubectlOptions.Namespace)
+	require.Nil(t, namespace.ServiceAccountKubectlOptions)
+
+	namespaceObject := GetNamespace(t, options, namespace.Name)
+	assert.Equal(t, "true", namespaceObject.Labels[EphemeralNamespaceLabel])
+	assert.Equal(t, "terratest", namespaceObject.Labels["team"])
+	assert.Equal(t, t.Name(), namespaceObject.Annotations[EphemeralNamespaceTestNameAnnotation])
+
+	clientset, err := GetKubernetesClientFromOptionsE(t, namespace.KubectlOptions)
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().ResourceQuotas(namespace.Name).Get(context.Background(), ephemeralNamespaceResourceName, metav1.GetOptions{})
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().LimitRanges(namespace.Name).Get(context.Background(), ephemeralNamespaceResourceName, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// Deleting waits for the namespace to be gone, and deleting again is a noop
+	namespace.Delete(t)
+	_, err = GetNamespaceE(t, options, namespace.Name)
+	require.True(t, errors.IsNotFound(err))
+	namespace.Delete(t)
+}
+
+func TestCreateEphemeralNamespaceWithServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "")
+	namespace := CreateEphemeralNamespace(t, options, EphemeralNamespaceOptions{
+		ServiceAccountName:        "terratest-sa",
+		ServiceAccountClusterRole: "view",
+	})
+	require.NotNil(t, namespace.ServiceAccountKubectlOptions)
+
+	// The service account can read, but not write, within its namespace and cannot read other namespaces
+	saOptions := namespace.ServiceAccountKubectlOptions
+	assert.True(t, CanIDo(t, saOptions, authv1.ResourceAttributes{Namespace: namespace.Name, Verb: "list", Resource: "pods"}))
+	assert.False(t, CanIDo(t, saOptions, authv1.ResourceAttributes{Namespace: namespace.Name, Verb: "create", Resource: "pods"}))
+	assert.False(t, CanIDo(t, saOptions, authv1.ResourceAttributes{Namespace: "kube-system", Verb: "list", Resource: "pods"}))
+}
+
+func TestDeleteStaleEphemeralNamespaces(t *testing.T) {
+	t.Parallel()
+
+	// Scope the janitor to a unique label so that namespaces of tests running in parallel are left alone
+	labels := map[string]string{"terratest-janitor-test": strings.ToLower(random.UniqueId())}
+	options := NewKubectlOptions("", "", "")
+	namespace := CreateEphemeralNamespace(t, options, EphemeralNamespaceOptions{Labels: labels})
+
+	// A fresh namespace is not stale
+	deleted := DeleteStaleEphemeralNamespaces(t, options, labels, time.Hour)
+	assert.Empty(t, deleted)
+
+	deleted = DeleteStaleEphemeralNamespaces(t, options, labels, 0)
+	assert.Equal(t, []string{namespace.Name}, deleted)
+	WaitUntilNamespaceDeleted(t, options, namespace.Name, 60, 2*time.Second)
+}
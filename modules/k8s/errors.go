@@ -281,3 +281,77 @@ type JSONPathMalformedJSONPathResultErr struct {
 func (err JSONPathMalformedJSONPathResultErr) Error() string {
 	return fmt.Sprintf("Error unmarshaling json path output: %s", err.underlyingErr)
 }
+
+// StatefulSetNotAvailable is returned when a Kubernetes statefulset has no pod available to accept traffic, or has not
+// finished rolling out.
+type StatefulSetNotAvailable struct {
+	statefulSet *appsv1.StatefulSet
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err StatefulSetNotAvailable) Error() string {
+	replicas := int32(1)
+	if err.statefulSet.Spec.Replicas != nil {
+		replicas = *err.statefulSet.Spec.Replicas
+	}
+	return fmt.Sprintf(
+		"StatefulSet %s is not available, %d/%d replicas ready and %d/%d replicas updated",
+		err.statefulSet.Name,
+		err.statefulSet.Status.ReadyReplicas,
+		replicas,
+		err.statefulSet.Status.UpdatedReplicas,
+		replicas,
+	)
+}
+
+// NewStatefulSetNotAvailableError returns a StatefulSetNotAvailable struct when the pods of the statefulset are not
+// available
+func NewStatefulSetNotAvailableError(statefulSet *appsv1.StatefulSet) StatefulSetNotAvailable {
+	return StatefulSetNotAvailable{statefulSet}
+}
+
+// NoAvailablePodForSelector is returned when none of the pods matching a label selector are available to accept traffic.
+type NoAvailablePodForSelector struct {
+	Selector string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err NoAvailablePodForSelector) Error() string {
+	return fmt.Sprintf("No available pod matches label selector %s", err.Selector)
+}
+
+// NamespaceNotDeleted is returned when a Kubernetes namespace still exists, e.g. because it is still terminating.
+type NamespaceNotDeleted struct {
+	namespace *corev1.Namespace
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err NamespaceNotDeleted) Error() string {
+	return fmt.Sprintf("Namespace %s is not deleted yet, phase: %s", err.namespace.Name, err.namespace.Status.Phase)
+}
+
+// NewNamespaceNotDeletedError returns a NamespaceNotDeleted struct when the given namespace still exists
+func NewNamespaceNotDeletedError(namespace *corev1.Namespace) NamespaceNotDeleted {
+	return NamespaceNotDeleted{namespace}
+}
+
+// UnknownLocalClusterProvider is returned when a local cluster is requested with a provider that is not supported.
+type UnknownLocalClusterProvider struct {
+	Provider LocalClusterProvider
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err UnknownLocalClusterProvider) Error() string {
+	return fmt.Sprintf("Local cluster provider %s is unknown", err.Provider)
+}
+
+// SubjectWithoutUser is returned when an operation needs to impersonate a subject, but the subject has no user, as with
+// GroupSubject. Kubernetes does not allow impersonating groups without a user.
+type SubjectWithoutUser struct {
+	Subject RBACSubject
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err SubjectWithoutUser) Error() string {
+	return fmt.Sprintf("Cannot impersonate %s: Kubernetes does not allow impersonating groups without a user, use UserSubject instead", err.Subject)
+}
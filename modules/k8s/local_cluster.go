@@ -0,0 +1,276 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// LocalClusterProvider is the tool used to run a throwaway Kubernetes cluster in Docker containers.
+type LocalClusterProvider string
+
+const (
+	// LocalClusterProviderKind runs the cluster with kind (https://kind.sigs.k8s.io).
+	LocalClusterProviderKind LocalClusterProvider = "kind"
+	// LocalClusterProviderK3d runs the cluster with k3d (https://k3d.io).
+	LocalClusterProviderK3d LocalClusterProvider = "k3d"
+)
+
+const defaultLocalClusterWaitTimeout = 5 * time.Minute
+
+// LocalClusterOptions configures a cluster created with CreateLocalCluster.
+type LocalClusterOptions struct {
+	// Provider is the tool used to run the cluster. Defaults to LocalClusterProviderKind.
+	Provider LocalClusterProvider
+	// Name of the cluster. Defaults to "terratest-" followed by a random unique ID.
+	Name string
+	// Nodes is the total number of nodes in the cluster: one control plane (server) node, plus Nodes-1 worker (agent)
+	// nodes. Defaults to 1.
+	Nodes int
+	// KubernetesVersion is the version of Kubernetes to run, e.g. "v1.28.0". It is used to pick the node image of the
+	// provider (kindest/node or rancher/k3s). Defaults to the default version of the provider.
+	KubernetesVersion string
+	// NodeImage overrides the node image derived from KubernetesVersion.
+	NodeImage string
+	// Images are local Docker images (e.g. built with docker.Build) to load into every node of the cluster once it is
+	// up, so that pods can use them without a registry.
+	Images []string
+	// WaitTimeout is how long to wait for the cluster to become ready. Defaults to 5 minutes.
+	WaitTimeout time.Duration
+	// Env holds additional environment variables to pass to the provider CLI.
+	Env map[string]string
+	// Logger to use. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// LocalCluster is a throwaway Kubernetes cluster running in Docker containers on the local host.
+type LocalCluster struct {
+	Name     string
+	Provider LocalClusterProvider
+	// KubeConfigPath is the path to a kubeconfig file that only contains this cluster, so the user's kubeconfig is left
+	// untouched.
+	KubeConfigPath string
+	// KubectlOptions targets the default namespace of the cluster using KubeConfigPath.
+	KubectlOptions *KubectlOptions
+
+	env     map[string]string
+	logger  *logger.Logger
+	deleted bool
+}
+
+// CreateLocalCluster creates a kind or k3d cluster, loads the requested images into it and waits for all of its nodes
+// to be ready. When t supports Cleanup (as *testing.T does), the cluster is deleted when the test finishes. This will
+// fail the test if there is an error.
+func CreateLocalCluster(t testing.TestingT, options *LocalClusterOptions) *LocalCluster {
+	cluster, err := CreateLocalClusterE(t, options)
+	require.NoError(t, err)
+	return cluster
+}
+
+// CreateLocalClusterE creates a kind or k3d cluster, loads the requested images into it and waits for all of its nodes
+// to be ready. When t supports Cleanup (as *testing.T does), the cluster is deleted when the test finishes. If the
+// cluster fails to become ready, it is deleted before returning the error.
+func CreateLocalClusterE(t testing.TestingT, options *LocalClusterOptions) (*LocalCluster, error) {
+	provider := options.Provider
+	if provider == "" {
+		provider = LocalClusterProviderKind
+	}
+	if provider != LocalClusterProviderKind && provider != LocalClusterProviderK3d {
+		return nil, UnknownLocalClusterProvider{provider}
+	}
+	name := options.Name
+	if name == "" {
+		name = fmt.Sprintf("terratest-%s", strings.ToLower(random.UniqueId()))
+	}
+	nodes := options.Nodes
+	if nodes < 1 {
+		nodes = 1
+	}
+	waitTimeout := options.WaitTimeout
+	if waitTimeout <= 0 {
+		waitTimeout = defaultLocalClusterWaitTimeout
+	}
+
+	kubeConfigFile, err := os.CreateTemp("", name+"-kubeconfig")
+	if err != nil {
+		return nil, err
+	}
+	kubeConfigFile.Close()
+
+	cluster := &LocalCluster{
+		Name:           name,
+		Provider:       provider,
+		KubeConfigPath: kubeConfigFile.Name(),
+		env:            options.Env,
+		logger:         options.Logger,
+	}
+	cluster.KubectlOptions = NewKubectlOptions(cluster.contextName(), cluster.KubeConfigPath, "default")
+	cluster.KubectlOptions.Logger = options.Logger
+
+	cluster.logger.Logf(t, "Creating %s cluster %s with %d node(s)", provider, name, nodes)
+	if err := cluster.createE(t, nodes, localClusterNodeImage(provider, options), waitTimeout); err != nil {
+		// The provider may have created some of the node containers before failing
+		if deleteErr := cluster.DeleteE(t); deleteErr != nil {
+			cluster.logger.Logf(t, "Error deleting %s cluster %s after failed creation: %s", provider, name, deleteErr)
+		}
+		return nil, err
+	}
+	if cleanupable, ok := t.(cleanupT); ok {
+		cleanupable.Cleanup(func() { cluster.Delete(t) })
+	}
+
+	sleepBetweenRetries := 2 * time.Second
+	err = WaitUntilAllNodesReadyE(t, cluster.KubectlOptions, int(waitTimeout/sleepBetweenRetries), sleepBetweenRetries)
+	if err == nil {
+		err = cluster.LoadImagesE(t, options.Images...)
+	}
+	if err != nil {
+		if deleteErr := cluster.DeleteE(t); deleteErr != nil {
+			cluster.logger.Logf(t, "Error deleting %s cluster %s after failed creation: %s", provider, name, deleteErr)
+		}
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// createE runs the provider CLI to create the cluster and write its kubeconfig to KubeConfigPath.
+func (cluster *LocalCluster) createE(t testing.TestingT, nodes int, nodeImage string, waitTimeout time.Duration) error {
+	switch cluster.Provider {
+	case LocalClusterProviderKind:
+		kindConfigPath, err := StoreConfigToTempFileE(t, kindClusterConfig(nodes))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(kindConfigPath)
+
+		args := []string{
+			"create", "cluster",
+			"--name", cluster.Name,
+			"--kubeconfig", cluster.KubeConfigPath,
+			"--config", kindConfigPath,
+			"--wait", waitTimeout.String(),
+		}
+		if nodeImage != "" {
+			args = append(args, "--image", nodeImage)
+		}
+		return cluster.runE(t, args...)
+	default:
+		args := []string{
+			"cluster", "create", cluster.Name,
+			"--servers", "1",
+			"--agents", fmt.Sprintf("%d", nodes-1),
+			"--kubeconfig-update-default=false",
+			"--kubeconfig-switch-context=false",
+			"--wait",
+			"--timeout", waitTimeout.String(),
+		}
+		if nodeImage != "" {
+			args = append(args, "--image", nodeImage)
+		}
+		if err := cluster.runE(t, args...); err != nil {
+			return err
+		}
+		return cluster.runE(t, "kubeconfig", "write", cluster.Name, "--output", cluster.KubeConfigPath)
+	}
+}
+
+// LoadImages loads the given local Docker images into every node of the cluster. This will fail the test if there is
+// an error.
+func (cluster *LocalCluster) LoadImages(t testing.TestingT, images ...string) {
+	require.NoError(t, cluster.LoadImagesE(t, images...))
+}
+
+// LoadImagesE loads the given local Docker images into every node of the cluster, so that pods can use them with an
+// imagePullPolicy of IfNotPresent or Never. Note that images tagged latest default to an imagePullPolicy of Always.
+func (cluster *LocalCluster) LoadImagesE(t testing.TestingT, images ...string) error {
+	if len(images) == 0 {
+		return nil
+	}
+	cluster.logger.Logf(t, "Loading images %s into %s cluster %s", strings.Join(images, ", "), cluster.Provider, cluster.Name)
+	switch cluster.Provider {
+	case LocalClusterProviderKind:
+		args := append([]string{"load", "docker-image", "--name", cluster.Name}, images...)
+		return cluster.runE(t, args...)
+	default:
+		args := append([]string{"image", "import", "--cluster", cluster.Name}, images...)
+		return cluster.runE(t, args...)
+	}
+}
+
+// Delete deletes the cluster and its kubeconfig. This is a noop if the cluster was already deleted. This will fail the
+// test if there is an error.
+func (cluster *LocalCluster) Delete(t testing.TestingT) {
+	require.NoError(t, cluster.DeleteE(t))
+}
+
+// DeleteE deletes the cluster and its kubeconfig. This is a noop if the cluster was already deleted.
+func (cluster *LocalCluster) DeleteE(t testing.TestingT) error {
+	if cluster.deleted {
+		return nil
+	}
+	cluster.logger.Logf(t, "Deleting %s cluster %s", cluster.Provider, cluster.Name)
+	var err error
+	switch cluster.Provider {
+	case LocalClusterProviderKind:
+		err = cluster.runE(t, "delete", "cluster", "--name", cluster.Name, "--kubeconfig", cluster.KubeConfigPath)
+	default:
+		err = cluster.runE(t, "cluster", "delete", cluster.Name)
+	}
+	if err != nil {
+		return err
+	}
+	cluster.deleted = true
+	if err := os.Remove(cluster.KubeConfigPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// contextName returns the name of the kubeconfig context the provider creates for the cluster.
+func (cluster *LocalCluster) contextName() string {
+	return fmt.Sprintf("%s-%s", cluster.Provider, cluster.Name)
+}
+
+// runE runs the provider CLI with the given args.
+func (cluster *LocalCluster) runE(t testing.TestingT, args ...string) error {
+	command := shell.Command{
+		Command: string(cluster.Provider),
+		Args:    args,
+		Env:     cluster.env,
+		Logger:  cluster.logger,
+	}
+	return shell.RunCommandE(t, command)
+}
+
+// localClusterNodeImage returns the node image to use for the cluster, derived from the Kubernetes version unless an
+// image is explicitly set.
+func localClusterNodeImage(provider LocalClusterProvider, options *LocalClusterOptions) string {
+	if options.NodeImage != "" || options.KubernetesVersion == "" {
+		return options.NodeImage
+	}
+	version := options.KubernetesVersion
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if provider == LocalClusterProviderK3d {
+		return fmt.Sprintf("rancher/k3s:%s-k3s1", version)
+	}
+	return fmt.Sprintf("kindest/node:%s", version)
+}
+
+// kindClusterConfig returns a kind cluster config with one control plane node and nodes-1 worker nodes.
+func kindClusterConfig(nodes int) string {
+	config := "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n- role: control-plane\n"
+	for i := 1; i < nodes; i++ {
+		config += "- role: worker\n"
+	}
+	return config
+}
@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalClusterNodeImage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		provider LocalClusterProvider
+		options  LocalClusterOptions
+		expected string
+	}{
+		{"kind default", LocalClusterProviderKind, LocalClusterOptions{}, ""},
+		{"kind version", LocalClusterProviderKind, LocalClusterOptions{KubernetesVersion: "v1.28.0"}, "kindest/node:v1.28.0"},
+		{"kind version without v", LocalClusterProviderKind, LocalClusterOptions{KubernetesVersion: "1.28.0"}, "kindest/node:v1.28.0"},
+		{"k3d version", LocalClusterProviderK3d, LocalClusterOptions{KubernetesVersion: "v1.28.4"}, "rancher/k3s:v1.28.4-k3s1"},
+		{"explicit image", LocalClusterProviderK3d, LocalClusterOptions{KubernetesVersion: "v1.28.4", NodeImage: "custom:1"}, "custom:1"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, localClusterNodeImage(testCase.provider, &testCase.options))
+		})
+	}
+}
+
+func TestKindClusterConfig(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n- role: control-plane\n", kindClusterConfig(1))
+	assert.Contains(t, kindClusterConfig(3), "- role: control-plane\n- role: worker\n- role: worker\n")
+}
+
+func TestCreateLocalClusterRejectsUnknownProvider(t *testing.T) {
+	t.Parallel()
+
+	_, err := CreateLocalClusterE(t, &LocalClusterOptions{Provider: "minikube"})
+	require.Error(t, err)
+	assert.IsType(t, UnknownLocalClusterProvider{}, err)
+}
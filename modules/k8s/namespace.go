@@ -2,10 +2,15 @@ package k8s
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -79,3 +84,38 @@ func DeleteNamespaceE(t testing.TestingT, options *KubectlOptions, namespaceName
 
 	return clientset.CoreV1().Namespaces().Delete(context.Background(), namespaceName, metav1.DeleteOptions{})
 }
+
+// WaitUntilNamespaceDeleted waits until the requested namespace is fully removed from the Kubernetes cluster targeted
+// by the provided options, i.e. until all of its finalizers have run. This will fail the test if the namespace still
+// exists after retrying.
+func WaitUntilNamespaceDeleted(t testing.TestingT, options *KubectlOptions, namespaceName string, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilNamespaceDeletedE(t, options, namespaceName, retries, sleepBetweenRetries))
+}
+
+// WaitUntilNamespaceDeletedE waits until the requested namespace is fully removed from the Kubernetes cluster targeted
+// by the provided options, i.e. until all of its finalizers have run.
+func WaitUntilNamespaceDeletedE(t testing.TestingT, options *KubectlOptions, namespaceName string, retries int, sleepBetweenRetries time.Duration) error {
+	statusMsg := fmt.Sprintf("Wait for namespace %s to be deleted.", namespaceName)
+	message, err := retry.DoWithRetryE(
+		t,
+		statusMsg,
+		retries,
+		sleepBetweenRetries,
+		func() (string, error) {
+			namespace, err := GetNamespaceE(t, options, namespaceName)
+			if errors.IsNotFound(err) {
+				return "Namespace is now deleted", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			return "", NewNamespaceNotDeletedError(namespace)
+		},
+	)
+	if err != nil {
+		logger.Logf(t, "Timedout waiting for Namespace to be deleted: %s", err)
+		return err
+	}
+	logger.Logf(t, message)
+	return nil
+}
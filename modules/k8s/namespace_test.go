@@ -12,9 +12,11 @@ package k8s
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/gruntwork-io/terratest/modules/random"
@@ -59,3 +61,16 @@ func TestNamespaceWithMetadata(t *testing.T) {
 	require.Equal(t, namespace.Name, namespaceName)
 	require.Equal(t, namespace.Labels, namespaceLabels)
 }
+
+func TestWaitUntilNamespaceDeleted(t *testing.T) {
+	t.Parallel()
+
+	namespaceName := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", namespaceName)
+	CreateNamespace(t, options, namespaceName)
+	DeleteNamespace(t, options, namespaceName)
+	WaitUntilNamespaceDeleted(t, options, namespaceName, 60, 2*time.Second)
+
+	_, err := GetNamespaceE(t, options, namespaceName)
+	require.True(t, errors.IsNotFound(err))
+}
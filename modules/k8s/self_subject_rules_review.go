@@ -0,0 +1,83 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// GetEffectivePermissions returns the rules describing every action the client configured by the provided kubectl
+// options can perform in the given namespace, as evaluated by a SelfSubjectRulesReview. This will fail the test if
+// there is an error.
+func GetEffectivePermissions(t testing.TestingT, options *KubectlOptions, namespace string) *authv1.SubjectRulesReviewStatus {
+	status, err := GetEffectivePermissionsE(t, options, namespace)
+	require.NoError(t, err)
+	return status
+}
+
+// GetEffectivePermissionsE returns the rules describing every action the client configured by the provided kubectl
+// options can perform in the given namespace, as evaluated by a SelfSubjectRulesReview. Note that the list may be
+// incomplete (see the Incomplete field of the result) when the cluster uses an authorizer that cannot enumerate rules,
+// such as a webhook.
+func GetEffectivePermissionsE(t testing.TestingT, options *KubectlOptions, namespace string) (*authv1.SubjectRulesReviewStatus, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	return createSelfSubjectRulesReviewE(t, clientset, namespace)
+}
+
+// GetEffectivePermissionsForSubject returns the rules describing every action the given subject can perform in the
+// given namespace, by impersonating the subject in a SelfSubjectRulesReview. This will fail the test if there is an
+// error.
+func GetEffectivePermissionsForSubject(t testing.TestingT, options *KubectlOptions, subject RBACSubject, namespace string) *authv1.SubjectRulesReviewStatus {
+	status, err := GetEffectivePermissionsForSubjectE(t, options, subject, namespace)
+	require.NoError(t, err)
+	return status
+}
+
+// GetEffectivePermissionsForSubjectE returns the rules describing every action the given subject can perform in the
+// given namespace, by impersonating the subject in a SelfSubjectRulesReview. The client configured by the provided
+// kubectl options must be allowed to impersonate the subject. As Kubernetes does not allow impersonating groups without
+// a user, this returns a SubjectWithoutUser error for subjects that have no user, such as the ones of GroupSubject.
+func GetEffectivePermissionsForSubjectE(t testing.TestingT, options *KubectlOptions, subject RBACSubject, namespace string) (*authv1.SubjectRulesReviewStatus, error) {
+	if subject.User == "" {
+		return nil, SubjectWithoutUser{Subject: subject}
+	}
+
+	config, err := getRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	impersonatedConfig := rest.CopyConfig(config)
+	impersonatedConfig.Impersonate = rest.ImpersonationConfig{UserName: subject.User, Groups: subject.Groups}
+	clientset, err := kubernetes.NewForConfig(impersonatedConfig)
+	if err != nil {
+		return nil, err
+	}
+	logger.Logf(t, "Impersonating %s to review effective permissions in namespace %s", subject, namespace)
+	return createSelfSubjectRulesReviewE(t, clientset, namespace)
+}
+
+// createSelfSubjectRulesReviewE submits a SelfSubjectRulesReview for the given namespace with the given client.
+func createSelfSubjectRulesReviewE(t testing.TestingT, clientset *kubernetes.Clientset, namespace string) (*authv1.SubjectRulesReviewStatus, error) {
+	review := authv1.SelfSubjectRulesReview{
+		Spec: authv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}
+	resp, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(context.Background(), &review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if resp.Status.Incomplete {
+		logger.Logf(t, "Rules review for namespace %s is incomplete: %s", namespace, resp.Status.EvaluationError)
+	}
+	return &resp.Status, nil
+}
@@ -0,0 +1,16 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEffectivePermissionsForSubjectRequiresUser(t *testing.T) {
+	t.Parallel()
+
+	// The subject is checked before connecting to the cluster
+	options := NewKubectlOptions("", "", "")
+	_, err := GetEffectivePermissionsForSubjectE(t, options, GroupSubject("system:authenticated"), "default")
+	require.ErrorAs(t, err, &SubjectWithoutUser{})
+}
@@ -0,0 +1,438 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// DefaultFieldManager is the field manager used for server-side apply when ServerSideApplyOptions does not set one.
+const DefaultFieldManager = "terratest"
+
+// ApplyOperation describes the change that server-side apply made (or would make) to an object.
+type ApplyOperation string
+
+const (
+	// ApplyOperationCreated means the object did not exist and was created.
+	ApplyOperationCreated ApplyOperation = "created"
+	// ApplyOperationConfigured means the object existed and was modified.
+	ApplyOperationConfigured ApplyOperation = "configured"
+	// ApplyOperationUnchanged means the object existed and was left as is.
+	ApplyOperationUnchanged ApplyOperation = "unchanged"
+)
+
+// ServerSideApplyOptions configures server-side apply calls.
+type ServerSideApplyOptions struct {
+	// FieldManager is the name of the actor that owns the applied fields. Defaults to DefaultFieldManager.
+	FieldManager string
+	// Force makes the apply take ownership of fields that are owned by other field managers instead of failing with a
+	// conflict.
+	Force bool
+}
+
+// AppliedObject is an object that was sent to the cluster with server-side apply.
+type AppliedObject struct {
+	GroupVersionKind     schema.GroupVersionKind
+	GroupVersionResource schema.GroupVersionResource
+	Namespace            string
+	Name                 string
+	UID                  types.UID
+	Operation            ApplyOperation
+	// Object is the object as returned by the API server after the apply.
+	Object *unstructured.Unstructured
+}
+
+// String returns the object reference in the same format as kubectl, e.g. deployment.apps/nginx.
+func (object AppliedObject) String() string {
+	return formatObjectReference(object.GroupVersionKind, object.Name)
+}
+
+// ObjectDiff is the difference between the live state of an object and the state it would have after server-side apply.
+type ObjectDiff struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+	// Operation is the change that applying the object would make.
+	Operation ApplyOperation
+	// Diff is a unified diff between the live object and the merged object, in YAML. It is empty when the object would be
+	// unchanged.
+	Diff string
+}
+
+// ServerSideApply will take in a file path and apply it to the cluster targeted by KubectlOptions using server-side
+// apply, returning the applied objects. If there are any errors, fail the test immediately.
+func ServerSideApply(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configPath string) []AppliedObject {
+	objects, err := ServerSideApplyE(t, options, applyOptions, configPath)
+	require.NoError(t, err)
+	return objects
+}
+
+// ServerSideApplyE will take in a file path and apply it to the cluster targeted by KubectlOptions using server-side
+// apply, returning the applied objects.
+func ServerSideApplyE(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configPath string) ([]AppliedObject, error) {
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return ServerSideApplyFromStringE(t, options, applyOptions, string(configData))
+}
+
+// ServerSideApplyFromKustomize will take in a kustomization directory path, render it with `kubectl kustomize` and
+// apply it to the cluster targeted by KubectlOptions using server-side apply, returning the applied objects. If there
+// are any errors, fail the test immediately.
+func ServerSideApplyFromKustomize(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configPath string) []AppliedObject {
+	objects, err := ServerSideApplyFromKustomizeE(t, options, applyOptions, configPath)
+	require.NoError(t, err)
+	return objects
+}
+
+// ServerSideApplyFromKustomizeE will take in a kustomization directory path, render it with `kubectl kustomize` and
+// apply it to the cluster targeted by KubectlOptions using server-side apply, returning the applied objects.
+func ServerSideApplyFromKustomizeE(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configPath string) ([]AppliedObject, error) {
+	configData, err := RunKubectlAndGetOutputE(t, options, "kustomize", configPath)
+	if err != nil {
+		return nil, err
+	}
+	return ServerSideApplyFromStringE(t, options, applyOptions, configData)
+}
+
+// ServerSideApplyFromString will take in a kubernetes resource config as a string and apply it on the cluster specified
+// by the provided kubectl options using server-side apply, returning the applied objects. If there are any errors,
+// fail the test immediately.
+func ServerSideApplyFromString(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configData string) []AppliedObject {
+	objects, err := ServerSideApplyFromStringE(t, options, applyOptions, configData)
+	require.NoError(t, err)
+	return objects
+}
+
+// ServerSideApplyFromStringE will take in a kubernetes resource config as a string and apply it on the cluster
+// specified by the provided kubectl options using server-side apply, returning the applied objects. Objects are applied
+// in the order they appear in the config, and objects without a namespace are applied to the namespace of the options.
+func ServerSideApplyFromStringE(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configData string) ([]AppliedObject, error) {
+	objects, err := DecodeUnstructuredObjectsE(configData)
+	if err != nil {
+		return nil, err
+	}
+	client, mapper, err := getDynamicClientAndMapperE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []AppliedObject{}
+	for _, object := range objects {
+		resource, namespace, err := resolveObjectResourceE(mapper, options, object)
+		if err != nil {
+			return applied, err
+		}
+
+		result, err := serverSideApplyObjectE(client, resource, namespace, object, applyOptions, false)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, result)
+		logger.Logf(t, "%s %s", result, result.Operation)
+	}
+	return applied, nil
+}
+
+// DeleteAppliedObjects deletes the given objects from the cluster in the reverse order they were applied. Objects that
+// no longer exist are ignored. If there are any errors, fail the test immediately.
+func DeleteAppliedObjects(t testing.TestingT, options *KubectlOptions, objects []AppliedObject) {
+	require.NoError(t, DeleteAppliedObjectsE(t, options, objects))
+}
+
+// DeleteAppliedObjectsE deletes the given objects from the cluster in the reverse order they were applied. Objects that
+// no longer exist are ignored. Only the object with the recorded UID is deleted, so an object recreated by someone else
+// under the same name is left alone.
+func DeleteAppliedObjectsE(t testing.TestingT, options *KubectlOptions, objects []AppliedObject) error {
+	client, err := GetDynamicClientFromOptionsE(t, options)
+	if err != nil {
+		return err
+	}
+	for i := len(objects) - 1; i >= 0; i-- {
+		object := objects[i]
+		uid := object.UID
+		deleteOptions := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}}
+		err := client.Resource(object.GroupVersionResource).Namespace(object.Namespace).Delete(context.Background(), object.Name, deleteOptions)
+		if errors.IsNotFound(err) || errors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		logger.Logf(t, "%s deleted", object)
+	}
+	return nil
+}
+
+// Diff will take in a file path and return the changes that server-side applying it would make to the cluster, in the
+// same way as `kubectl diff`. If there are any errors, fail the test immediately.
+func Diff(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configPath string) []ObjectDiff {
+	diffs, err := DiffE(t, options, applyOptions, configPath)
+	require.NoError(t, err)
+	return diffs
+}
+
+// DiffE will take in a file path and return the changes that server-side applying it would make to the cluster, in the
+// same way as `kubectl diff`.
+func DiffE(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configPath string) ([]ObjectDiff, error) {
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return DiffFromStringE(t, options, applyOptions, string(configData))
+}
+
+// DiffFromString will take in a kubernetes resource config as a string and return the changes that server-side
+// applying it would make to the cluster. If there are any errors, fail the test immediately.
+func DiffFromString(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configData string) []ObjectDiff {
+	diffs, err := DiffFromStringE(t, options, applyOptions, configData)
+	require.NoError(t, err)
+	return diffs
+}
+
+// DiffFromStringE will take in a kubernetes resource config as a string and return the changes that server-side
+// applying it would make to the cluster. The changes are computed with a server-side dry run, so admission webhooks and
+// defaulting are taken into account and nothing is persisted.
+func DiffFromStringE(t testing.TestingT, options *KubectlOptions, applyOptions ServerSideApplyOptions, configData string) ([]ObjectDiff, error) {
+	objects, err := DecodeUnstructuredObjectsE(configData)
+	if err != nil {
+		return nil, err
+	}
+	client, mapper, err := getDynamicClientAndMapperE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []ObjectDiff{}
+	for _, object := range objects {
+		resource, namespace, err := resolveObjectResourceE(mapper, options, object)
+		if err != nil {
+			return diffs, err
+		}
+
+		live, err := client.Resource(resource).Namespace(namespace).Get(context.Background(), object.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return diffs, err
+		}
+
+		merged, err := serverSideApplyObjectE(client, resource, namespace, object, applyOptions, true)
+		if err != nil {
+			return diffs, err
+		}
+
+		diff, err := diffObjectsE(object.GroupVersionKind(), object.GetName(), live, merged.Object)
+		if err != nil {
+			return diffs, err
+		}
+		// A dry run never bumps the resource version, so the operation is derived from the diff instead.
+		operation := ApplyOperationConfigured
+		if live == nil {
+			operation = ApplyOperationCreated
+		} else if diff == "" {
+			operation = ApplyOperationUnchanged
+		}
+		diffs = append(diffs, ObjectDiff{
+			GroupVersionKind: object.GroupVersionKind(),
+			Namespace:        namespace,
+			Name:             object.GetName(),
+			Operation:        operation,
+			Diff:             diff,
+		})
+	}
+	return diffs, nil
+}
+
+// DecodeUnstructuredObjectsE decodes a YAML or JSON config that may contain multiple documents into unstructured
+// objects. Empty documents are skipped and List objects are expanded into their items.
+func DecodeUnstructuredObjectsE(configData string) ([]*unstructured.Unstructured, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(configData), 4096)
+	objects := []*unstructured.Unstructured{}
+	for {
+		document := map[string]interface{}{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(document) == 0 {
+			continue
+		}
+
+		object := &unstructured.Unstructured{Object: document}
+		if !object.IsList() {
+			objects = append(objects, object)
+			continue
+		}
+		list, err := object.ToList()
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+}
+
+// getDynamicClientAndMapperE returns a dynamic client along with a REST mapper that can resolve the resource of any
+// kind served by the cluster.
+func getDynamicClientAndMapperE(t testing.TestingT, options *KubectlOptions) (dynamic.Interface, *restmapper.DeferredDiscoveryRESTMapper, error) {
+	config, err := getRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	return client, mapper, nil
+}
+
+// resolveObjectResourceE returns the resource and namespace to use when sending the given object to the cluster.
+func resolveObjectResourceE(mapper *restmapper.DeferredDiscoveryRESTMapper, options *KubectlOptions, object *unstructured.Unstructured) (schema.GroupVersionResource, string, error) {
+	gvk := object.GroupVersionKind()
+	if object.GetName() == "" {
+		return schema.GroupVersionResource{}, "", fmt.Errorf("%s object is missing metadata.name", gvk.Kind)
+	}
+
+	// Mappings are discovered lazily and cached, so reset the cache whenever an object kind is unknown in case it was
+	// just created by a CRD earlier in the config.
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		mapper.Reset()
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return schema.GroupVersionResource{}, "", err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return mapping.Resource, "", nil
+	}
+	namespace := object.GetNamespace()
+	if namespace == "" {
+		namespace = options.Namespace
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	object.SetNamespace(namespace)
+	return mapping.Resource, namespace, nil
+}
+
+// serverSideApplyObjectE applies a single object with server-side apply and determines whether the object was created,
+// configured or left unchanged by comparing the resource version before and after the apply.
+func serverSideApplyObjectE(
+	client dynamic.Interface,
+	resource schema.GroupVersionResource,
+	namespace string,
+	object *unstructured.Unstructured,
+	applyOptions ServerSideApplyOptions,
+	dryRun bool,
+) (AppliedObject, error) {
+	resourceClient := client.Resource(resource).Namespace(namespace)
+
+	existing, err := resourceClient.Get(context.Background(), object.GetName(), metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return AppliedObject{}, err
+	}
+	exists := err == nil
+
+	fieldManager := applyOptions.FieldManager
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	metaApplyOptions := metav1.ApplyOptions{FieldManager: fieldManager, Force: applyOptions.Force}
+	if dryRun {
+		metaApplyOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	result, err := resourceClient.Apply(context.Background(), object.GetName(), object, metaApplyOptions)
+	if err != nil {
+		return AppliedObject{}, err
+	}
+
+	operation := ApplyOperationCreated
+	if exists {
+		operation = ApplyOperationConfigured
+		if existing.GetResourceVersion() == result.GetResourceVersion() {
+			operation = ApplyOperationUnchanged
+		}
+	}
+
+	return AppliedObject{
+		GroupVersionKind:     result.GroupVersionKind(),
+		GroupVersionResource: resource,
+		Namespace:            namespace,
+		Name:                 result.GetName(),
+		UID:                  result.GetUID(),
+		Operation:            operation,
+		Object:               result,
+	}, nil
+}
+
+// diffObjectsE returns a unified diff between the YAML representations of the live and merged objects. Managed fields
+// are left out, as `kubectl diff` does, since they change on every apply.
+func diffObjectsE(gvk schema.GroupVersionKind, name string, live *unstructured.Unstructured, merged *unstructured.Unstructured) (string, error) {
+	liveYAML, err := objectToDiffableYAMLE(live)
+	if err != nil {
+		return "", err
+	}
+	mergedYAML, err := objectToDiffableYAMLE(merged)
+	if err != nil {
+		return "", err
+	}
+	reference := formatObjectReference(gvk, name)
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(liveYAML),
+		B:        difflib.SplitLines(mergedYAML),
+		FromFile: "live/" + reference,
+		ToFile:   "merged/" + reference,
+		Context:  3,
+	})
+}
+
+// objectToDiffableYAMLE renders the object as YAML without its managed fields. A nil object renders as an empty string.
+func objectToDiffableYAMLE(object *unstructured.Unstructured) (string, error) {
+	if object == nil {
+		return "", nil
+	}
+	object = object.DeepCopy()
+	object.SetManagedFields(nil)
+	out, err := yaml.Marshal(object.Object)
+	return string(out), err
+}
+
+// formatObjectReference formats an object reference the way kubectl does, e.g. deployment.apps/nginx.
+func formatObjectReference(gvk schema.GroupVersionKind, name string) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(gvk.GroupKind().String()), name)
+}
@@ -0,0 +1,94 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+func TestDecodeUnstructuredObjectsSkipsEmptyDocumentsAndExpandsLists(t *testing.T) {
+	t.Parallel()
+
+	objects, err := DecodeUnstructuredObjectsE(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: second
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: third
+`)
+	require.NoError(t, err)
+	require.Len(t, objects, 3)
+	assert.Equal(t, "first", objects[0].GetName())
+	assert.Equal(t, "second", objects[1].GetName())
+	assert.Equal(t, "Secret", objects[2].GetKind())
+}
+
+func TestServerSideApplyReportsOperations(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleDeploymentYAMLTemplate, uniqueID)
+	applyOptions := ServerSideApplyOptions{FieldManager: "terratest-test"}
+
+	applied := ServerSideApplyFromString(t, options, applyOptions, configData)
+	defer DeleteAppliedObjects(t, options, applied)
+	require.Len(t, applied, 2)
+	for _, object := range applied {
+		assert.Equal(t, ApplyOperationCreated, object.Operation)
+		assert.NotEmpty(t, object.UID)
+	}
+	assert.Equal(t, "namespace/"+uniqueID, applied[0].String())
+	assert.Equal(t, "deployment.apps/nginx-deployment", applied[1].String())
+	assert.Equal(t, uniqueID, applied[1].Namespace)
+	WaitUntilDeploymentAvailable(t, options, "nginx-deployment", 60, 1*time.Second)
+
+	reapplied := ServerSideApplyFromString(t, options, applyOptions, configData)
+	for i, object := range reapplied {
+		assert.Equal(t, ApplyOperationUnchanged, object.Operation)
+		assert.Equal(t, applied[i].UID, object.UID)
+	}
+
+	scaledConfigData := strings.Replace(configData, "replicas: 2", "replicas: 1", 1)
+	diffs := DiffFromString(t, options, applyOptions, scaledConfigData)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, ApplyOperationUnchanged, diffs[0].Operation)
+	assert.Empty(t, diffs[0].Diff)
+	assert.Equal(t, ApplyOperationConfigured, diffs[1].Operation)
+	assert.Contains(t, diffs[1].Diff, "-  replicas: 2")
+	assert.Contains(t, diffs[1].Diff, "+  replicas: 1")
+
+	// The diff is a dry run, so nothing changed on the cluster until the config is applied
+	deployment := GetDeployment(t, options, "nginx-deployment")
+	assert.Equal(t, int32(2), *deployment.Spec.Replicas)
+
+	configured := ServerSideApplyFromString(t, options, applyOptions, scaledConfigData)
+	assert.Equal(t, ApplyOperationConfigured, configured[1].Operation)
+}
@@ -0,0 +1,131 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetStatefulSetEReturnsError(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "")
+	_, err := GetStatefulSetE(t, options, "nginx-statefulset")
+	require.Error(t, err)
+}
+
+func TestGetStatefulSets(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleStatefulSetYAMLTemplate, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	statefulSet := GetStatefulSet(t, options, "nginx-statefulset")
+	require.Equal(t, statefulSet.Name, "nginx-statefulset")
+	require.Equal(t, statefulSet.Namespace, uniqueID)
+
+	statefulSets := ListStatefulSets(t, options, metav1.ListOptions{})
+	require.Equal(t, len(statefulSets), 1)
+}
+
+func TestWaitUntilStatefulSetAvailable(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleStatefulSetYAMLTemplate, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	WaitUntilStatefulSetAvailable(t, options, "nginx-statefulset", 60, 1*time.Second)
+}
+
+func TestIsStatefulSetAvailable(t *testing.T) {
+	t.Parallel()
+
+	replicas := int32(2)
+	testCases := []struct {
+		title          string
+		status         appsv1.StatefulSetStatus
+		strategy       appsv1.StatefulSetUpdateStrategyType
+		partition      int32
+		expectedResult bool
+	}{
+		{
+			title:          "RolledOut",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 2, CurrentRevision: "v2", UpdateRevision: "v2"},
+			expectedResult: true,
+		},
+		{
+			title:          "GenerationNotObserved",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, UpdatedReplicas: 2, CurrentRevision: "v1", UpdateRevision: "v1"},
+			expectedResult: false,
+		},
+		{
+			title:          "PodsNotReady",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 2, CurrentRevision: "v2", UpdateRevision: "v2"},
+			expectedResult: false,
+		},
+		{
+			title:          "RollingUpdateInProgress",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "v1", UpdateRevision: "v2"},
+			expectedResult: false,
+		},
+		{
+			title:          "OnDeleteNotUpdated",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 0, CurrentRevision: "v1", UpdateRevision: "v2"},
+			strategy:       appsv1.OnDeleteStatefulSetStrategyType,
+			expectedResult: true,
+		},
+		{
+			title:          "PartitionedRollingUpdateComplete",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "v1", UpdateRevision: "v2"},
+			strategy:       appsv1.RollingUpdateStatefulSetStrategyType,
+			partition:      1,
+			expectedResult: true,
+		},
+		{
+			title:          "PartitionedRollingUpdateInProgress",
+			status:         appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 0, CurrentRevision: "v1", UpdateRevision: "v2"},
+			strategy:       appsv1.RollingUpdateStatefulSetStrategyType,
+			partition:      1,
+			expectedResult: false,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas:       &replicas,
+					UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: tc.strategy},
+				},
+				Status: tc.status,
+			}
+			if tc.partition > 0 {
+				statefulSet.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &tc.partition}
+			}
+			require.Equal(t, tc.expectedResult, IsStatefulSetAvailable(statefulSet))
+		})
+	}
+}
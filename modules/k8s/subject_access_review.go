@@ -0,0 +1,205 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// RBACSubject is the user, group or service account whose permissions are checked with a SubjectAccessReview.
+type RBACSubject struct {
+	User   string
+	Groups []string
+}
+
+// ServiceAccountSubject returns the RBACSubject for the ServiceAccount with the given name in the given namespace,
+// including the groups Kubernetes assigns to every service account.
+func ServiceAccountSubject(namespace string, serviceAccountName string) RBACSubject {
+	return RBACSubject{
+		User:   fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccountName),
+		Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"},
+	}
+}
+
+// UserSubject returns the RBACSubject for the given user, optionally as a member of the given groups.
+func UserSubject(userName string, groups ...string) RBACSubject {
+	return RBACSubject{User: userName, Groups: groups}
+}
+
+// GroupSubject returns the RBACSubject for an anonymous member of the given groups. Such a subject can be checked with
+// SubjectAccessReviews, as with CanSubjectDo and CheckRBACMatrixE, but not impersonated, as with
+// GetEffectivePermissionsForSubject, since Kubernetes does not allow impersonating groups without a user.
+func GroupSubject(groups ...string) RBACSubject {
+	return RBACSubject{Groups: groups}
+}
+
+// String returns a human readable representation of the subject for logging.
+func (subject RBACSubject) String() string {
+	if len(subject.Groups) == 0 {
+		return fmt.Sprintf("user %s", subject.User)
+	}
+	return fmt.Sprintf("user %s in groups [%s]", subject.User, strings.Join(subject.Groups, ", "))
+}
+
+// RBACExpectation is a single row of an RBAC matrix: the action to check and whether it is expected to be allowed.
+type RBACExpectation struct {
+	Action  authv1.ResourceAttributes
+	Allowed bool
+}
+
+// RBACMismatch is an RBACExpectation that did not match the permissions of the subject.
+type RBACMismatch struct {
+	Expectation RBACExpectation
+	// Reason is the explanation given by the authorizer, if any.
+	Reason string
+}
+
+// String returns a human readable representation of the mismatch.
+func (mismatch RBACMismatch) String() string {
+	action := mismatch.Expectation.Action
+	expected, actual := "allowed", "denied"
+	if !mismatch.Expectation.Allowed {
+		expected, actual = actual, expected
+	}
+	return fmt.Sprintf(
+		"expected %s to be %s but it was %s (reason: %q)",
+		formatResourceAttributes(action),
+		expected,
+		actual,
+		mismatch.Reason,
+	)
+}
+
+// CanSubjectDo returns whether or not the provided action is allowed for the given subject, as evaluated by a
+// SubjectAccessReview. This will fail if there are any errors accessing the kubernetes API (but not if the action is
+// denied).
+func CanSubjectDo(t testing.TestingT, options *KubectlOptions, subject RBACSubject, action authv1.ResourceAttributes) bool {
+	allowed, err := CanSubjectDoE(t, options, subject, action)
+	require.NoError(t, err)
+	return allowed
+}
+
+// CanSubjectDoE returns whether or not the provided action is allowed for the given subject, as evaluated by a
+// SubjectAccessReview. The client configured by the provided kubectl options must be allowed to create
+// SubjectAccessReviews. This will return an error if there are problems accessing the kubernetes API (but not if the
+// action is simply denied).
+func CanSubjectDoE(t testing.TestingT, options *KubectlOptions, subject RBACSubject, action authv1.ResourceAttributes) (bool, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return false, err
+	}
+	resp, err := createSubjectAccessReviewE(clientset, subject, action)
+	if err != nil {
+		return false, err
+	}
+	if !resp.Status.Allowed {
+		logger.Logf(t, "Denied action %s on resource %s with name '%s' for %s for reason %s", action.Verb, action.Resource, action.Name, subject, resp.Status.Reason)
+	}
+	return resp.Status.Allowed, nil
+}
+
+// AssertRBACMatrix checks every expectation of the matrix for the given subject in parallel and fails the test with a
+// report of every mismatch.
+func AssertRBACMatrix(t testing.TestingT, options *KubectlOptions, subject RBACSubject, expectations []RBACExpectation) {
+	mismatches, err := CheckRBACMatrixE(t, options, subject, expectations)
+	require.NoError(t, err)
+	if len(mismatches) == 0 {
+		return
+	}
+	report := []string{}
+	for _, mismatch := range mismatches {
+		report = append(report, mismatch.String())
+	}
+	t.Errorf("%d of %d RBAC expectations failed for %s:\n%s", len(mismatches), len(expectations), subject, strings.Join(report, "\n"))
+}
+
+// CheckRBACMatrixE checks every expectation of the matrix for the given subject in parallel, using SubjectAccessReviews,
+// and returns the expectations that did not match, in the order they were given. If any of the reviews fail to be
+// created, the errors are accumulated and returned as a MultiError.
+func CheckRBACMatrixE(t testing.TestingT, options *KubectlOptions, subject RBACSubject, expectations []RBACExpectation) ([]RBACMismatch, error) {
+	// The clientset is safe for concurrent use, so that the kubeconfig is only loaded once
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pre-size the results so that each goroutine only writes to its own slot
+	results := make([]*RBACMismatch, len(expectations))
+	var errorsOccurred = new(multierror.Error)
+	var errorsMutex sync.Mutex
+	var waitForReviews sync.WaitGroup
+	waitForReviews.Add(len(expectations))
+
+	for i, expectation := range expectations {
+		i := i
+		expectation := expectation
+		go func() {
+			defer waitForReviews.Done()
+			resp, err := createSubjectAccessReviewE(clientset, subject, expectation.Action)
+			if err != nil {
+				errorsMutex.Lock()
+				errorsOccurred = multierror.Append(errorsOccurred, err)
+				errorsMutex.Unlock()
+				return
+			}
+			if resp.Status.Allowed != expectation.Allowed {
+				results[i] = &RBACMismatch{Expectation: expectation, Reason: resp.Status.Reason}
+			}
+		}()
+	}
+	waitForReviews.Wait()
+
+	mismatches := []RBACMismatch{}
+	for _, result := range results {
+		if result != nil {
+			mismatches = append(mismatches, *result)
+		}
+	}
+	return mismatches, errorsOccurred.ErrorOrNil()
+}
+
+// createSubjectAccessReviewE submits a SubjectAccessReview for the given subject and action with the given client.
+func createSubjectAccessReviewE(clientset *kubernetes.Clientset, subject RBACSubject, action authv1.ResourceAttributes) (*authv1.SubjectAccessReview, error) {
+	review := authv1.SubjectAccessReview{
+		Spec: authv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &action,
+			User:               subject.User,
+			Groups:             subject.Groups,
+		},
+	}
+	resp, err := clientset.AuthorizationV1().SubjectAccessReviews().Create(context.Background(), &review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return resp, nil
+}
+
+// formatResourceAttributes formats an action in the style of `kubectl auth can-i`, e.g. "list pods -n kube-system".
+func formatResourceAttributes(action authv1.ResourceAttributes) string {
+	resource := action.Resource
+	if action.Group != "" {
+		resource = fmt.Sprintf("%s.%s", resource, action.Group)
+	}
+	if action.Subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, action.Subresource)
+	}
+	if action.Name != "" {
+		resource = fmt.Sprintf("%s/%s", resource, action.Name)
+	}
+	out := fmt.Sprintf("%s %s", action.Verb, resource)
+	if action.Namespace != "" {
+		out = fmt.Sprintf("%s -n %s", out, action.Namespace)
+	}
+	return out
+}
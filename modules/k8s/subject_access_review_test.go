@@ -0,0 +1,87 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+)
+
+func TestCheckRBACMatrixReportsEveryMismatch(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "")
+	namespace := CreateEphemeralNamespace(t, options, EphemeralNamespaceOptions{
+		ServiceAccountName:        "terratest-rbac",
+		ServiceAccountClusterRole: "view",
+	})
+	subject := ServiceAccountSubject(namespace.Name, "terratest-rbac")
+
+	listPods := authv1.ResourceAttributes{Namespace: namespace.Name, Verb: "list", Resource: "pods"}
+	createPods := authv1.ResourceAttributes{Namespace: namespace.Name, Verb: "create", Resource: "pods"}
+	listSystemPods := authv1.ResourceAttributes{Namespace: "kube-system", Verb: "list", Resource: "pods"}
+	assert.True(t, CanSubjectDo(t, options, subject, listPods))
+	assert.False(t, CanSubjectDo(t, options, subject, createPods))
+
+	AssertRBACMatrix(t, options, subject, []RBACExpectation{
+		{Action: listPods, Allowed: true},
+		{Action: createPods, Allowed: false},
+		{Action: listSystemPods, Allowed: false},
+	})
+
+	// Flip two of the expectations and make sure both are reported
+	mismatches, err := CheckRBACMatrixE(t, options, subject, []RBACExpectation{
+		{Action: listPods, Allowed: false},
+		{Action: createPods, Allowed: false},
+		{Action: listSystemPods, Allowed: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, listPods, mismatches[0].Expectation.Action)
+	assert.Equal(t, listSystemPods, mismatches[1].Expectation.Action)
+	assert.Contains(t, mismatches[1].String(), "expected list pods -n kube-system to be allowed but it was denied")
+}
+
+func TestGetEffectivePermissionsForSubject(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "")
+	namespace := CreateEphemeralNamespace(t, options, EphemeralNamespaceOptions{
+		ServiceAccountName:        "terratest-rbac",
+		ServiceAccountClusterRole: "view",
+	})
+	subject := ServiceAccountSubject(namespace.Name, "terratest-rbac")
+
+	status := GetEffectivePermissionsForSubject(t, options, subject, namespace.Name)
+	canListPods := false
+	for _, rule := range status.ResourceRules {
+		if containsString(rule.Resources, "pods") && containsString(rule.Verbs, "list") {
+			canListPods = true
+		}
+		assert.NotContains(t, rule.Verbs, "create")
+	}
+	assert.True(t, canListPods)
+
+	// The rules review of the service account itself must match the impersonated one
+	selfStatus := GetEffectivePermissions(t, namespace.ServiceAccountKubectlOptions, namespace.Name)
+	assert.ElementsMatch(t, status.ResourceRules, selfStatus.ResourceRules)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +27,14 @@ import (
 // Global lock to synchronize port selections
 var globalMutex sync.Mutex
 
+// defaultTunnelReconnectInterval is the time to wait between reconnection attempts of a tunnel that has AutoReconnect
+// enabled, when TunnelOptions.ReconnectInterval is not set.
+const defaultTunnelReconnectInterval = 1 * time.Second
+
+// tunnelStatusBufferSize is the number of status updates buffered on the status channel of a tunnel. Updates are dropped
+// when the buffer is full so that a test that never reads the channel does not block the tunnel.
+const tunnelStatusBufferSize = 100
+
 // KubeResourceType is an enum representing known resource types that can support port forwarding
 type KubeResourceType int
 
@@ -36,6 +45,11 @@ const (
 	ResourceTypeDeployment
 	// ResourceTypeService is a k8s service kind identifier
 	ResourceTypeService
+	// ResourceTypeStatefulSet is a k8s statefulset kind identifier
+	ResourceTypeStatefulSet
+	// ResourceTypeSelector identifies an arbitrary set of pods by label selector (e.g. app=nginx,tier=web). When used,
+	// the resource name of the tunnel is interpreted as the label selector.
+	ResourceTypeSelector
 )
 
 func (resourceType KubeResourceType) String() string {
@@ -46,12 +60,78 @@ func (resourceType KubeResourceType) String() string {
 		return "pod"
 	case ResourceTypeService:
 		return "svc"
+	case ResourceTypeStatefulSet:
+		return "sts"
+	case ResourceTypeSelector:
+		return "selector"
 	default:
 		// This should not happen
 		return "UNKNOWN_RESOURCE_TYPE"
 	}
 }
 
+// TunnelState is an enum representing the connection state of a port forwarding tunnel.
+type TunnelState int
+
+const (
+	// TunnelStateConnecting means the tunnel is resolving a pod and opening the port forward connection.
+	TunnelStateConnecting TunnelState = iota
+	// TunnelStateConnected means the tunnel is forwarding traffic to a pod.
+	TunnelStateConnected
+	// TunnelStateDisconnected means the tunnel lost its connection (or failed to connect) and is not forwarding traffic.
+	TunnelStateDisconnected
+	// TunnelStateClosed means the tunnel was closed with Close.
+	TunnelStateClosed
+)
+
+func (state TunnelState) String() string {
+	switch state {
+	case TunnelStateConnecting:
+		return "connecting"
+	case TunnelStateConnected:
+		return "connected"
+	case TunnelStateDisconnected:
+		return "disconnected"
+	case TunnelStateClosed:
+		return "closed"
+	default:
+		// This should not happen
+		return "UNKNOWN_TUNNEL_STATE"
+	}
+}
+
+// TunnelStatus describes a state transition of a tunnel. It is published on the channel returned by Tunnel.Status.
+type TunnelStatus struct {
+	State   TunnelState
+	PodName string
+	Time    time.Time
+	// Err is the error that caused the transition, if any.
+	Err error
+}
+
+// TunnelPort is a pair of local and remote ports forwarded by a tunnel. Use 0 for the local port to have an open port
+// on the host system selected automatically.
+type TunnelPort struct {
+	Local  int
+	Remote int
+}
+
+// TunnelOptions configures a tunnel created with NewTunnelWithOptions.
+type TunnelOptions struct {
+	ResourceType KubeResourceType
+	// ResourceName is the name of the resource to forward to, or the label selector when ResourceType is
+	// ResourceTypeSelector.
+	ResourceName string
+	Ports        []TunnelPort
+	// AutoReconnect makes the tunnel re-resolve the target pod and reconnect whenever the connection is lost, e.g.
+	// because the pod was rescheduled during a rolling upgrade.
+	AutoReconnect bool
+	// ReconnectInterval is the time to wait between reconnection attempts. Defaults to 1 second.
+	ReconnectInterval time.Duration
+	// Logger is the logger to use. Defaults to logger.Terratest.
+	Logger logger.TestLogger
+}
+
 // makeLabels is a helper to format a map of label key and value pairs into a single string for use as a selector.
 func makeLabels(labels map[string]string) string {
 	out := []string{}
@@ -63,15 +143,21 @@ func makeLabels(labels map[string]string) string {
 
 // Tunnel is the main struct that configures and manages port forwading tunnels to Kubernetes resources.
 type Tunnel struct {
-	out            io.Writer
-	localPort      int
-	remotePort     int
-	kubectlOptions *KubectlOptions
-	resourceType   KubeResourceType
-	resourceName   string
-	logger         logger.TestLogger
-	stopChan       chan struct{}
-	readyChan      chan struct{}
+	out               io.Writer
+	ports             []TunnelPort
+	kubectlOptions    *KubectlOptions
+	resourceType      KubeResourceType
+	resourceName      string
+	logger            logger.TestLogger
+	autoReconnect     bool
+	reconnectInterval time.Duration
+	stopChan          chan struct{}
+	statusChan        chan TunnelStatus
+
+	// mutex guards the fields below, as well as sends on statusChan.
+	mutex   sync.Mutex
+	state   TunnelState
+	podName string
 }
 
 // NewTunnel creates a new tunnel with NewTunnelWithLogger, setting logger.Terratest as the logger.
@@ -90,27 +176,133 @@ func NewTunnelWithLogger(
 	remote int,
 	logger logger.TestLogger,
 ) *Tunnel {
+	return NewTunnelWithOptions(kubectlOptions, TunnelOptions{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		Ports:        []TunnelPort{{Local: local, Remote: remote}},
+		Logger:       logger,
+	})
+}
+
+// NewTunnelWithOptions will create a new Tunnel struct that forwards all the ports in the given options. Any local port
+// set to 0 is replaced with an open port on the host system when the tunnel is first opened.
+func NewTunnelWithOptions(kubectlOptions *KubectlOptions, options TunnelOptions) *Tunnel {
+	tunnelLogger := options.Logger
+	if tunnelLogger == nil {
+		tunnelLogger = logger.Terratest
+	}
+	reconnectInterval := options.ReconnectInterval
+	if reconnectInterval <= 0 {
+		reconnectInterval = defaultTunnelReconnectInterval
+	}
+	ports := make([]TunnelPort, len(options.Ports))
+	copy(ports, options.Ports)
+
 	return &Tunnel{
-		out:            io.Discard,
-		localPort:      local,
-		remotePort:     remote,
-		kubectlOptions: kubectlOptions,
-		resourceType:   resourceType,
-		resourceName:   resourceName,
-		logger:         logger,
-		stopChan:       make(chan struct{}, 1),
-		readyChan:      make(chan struct{}, 1),
+		out:               io.Discard,
+		ports:             ports,
+		kubectlOptions:    kubectlOptions,
+		resourceType:      options.ResourceType,
+		resourceName:      options.ResourceName,
+		logger:            tunnelLogger,
+		autoReconnect:     options.AutoReconnect,
+		reconnectInterval: reconnectInterval,
+		stopChan:          make(chan struct{}, 1),
+		statusChan:        make(chan TunnelStatus, tunnelStatusBufferSize),
 	}
 }
 
-// Endpoint returns the tunnel endpoint
+// Endpoint returns the tunnel endpoint of the first forwarded port
 func (tunnel *Tunnel) Endpoint() string {
-	return fmt.Sprintf("localhost:%d", tunnel.localPort)
+	if len(tunnel.ports) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("localhost:%d", tunnel.ports[0].Local)
+}
+
+// EndpointForPort returns the tunnel endpoint that forwards to the given remote port, or an empty string if the tunnel
+// does not forward that port.
+func (tunnel *Tunnel) EndpointForPort(remotePort int) string {
+	for _, port := range tunnel.ports {
+		if port.Remote == remotePort {
+			return fmt.Sprintf("localhost:%d", port.Local)
+		}
+	}
+	return ""
+}
+
+// Ports returns the local and remote port pairs forwarded by the tunnel. Local ports that were requested as 0 are
+// only populated once the tunnel has been opened.
+func (tunnel *Tunnel) Ports() []TunnelPort {
+	ports := make([]TunnelPort, len(tunnel.ports))
+	copy(ports, tunnel.ports)
+	return ports
+}
+
+// State returns the current connection state of the tunnel.
+func (tunnel *Tunnel) State() TunnelState {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	return tunnel.state
+}
+
+// PodName returns the name of the pod the tunnel is currently (or was last) connected to.
+func (tunnel *Tunnel) PodName() string {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	return tunnel.podName
+}
+
+// Status returns a channel on which every state transition of the tunnel is published, so that tests can tell when
+// the tunnel was down. The channel is closed when the tunnel is closed.
+func (tunnel *Tunnel) Status() <-chan TunnelStatus {
+	return tunnel.statusChan
 }
 
 // Close disconnects a tunnel connection by closing the StopChan, thereby stopping the goroutine.
 func (tunnel *Tunnel) Close() {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	if tunnel.state == TunnelStateClosed {
+		return
+	}
 	close(tunnel.stopChan)
+	tunnel.publishStatus(TunnelStateClosed, tunnel.podName, nil)
+	close(tunnel.statusChan)
+}
+
+// setState updates the state of the tunnel and publishes the transition on the status channel. This is a noop once
+// the tunnel is closed.
+func (tunnel *Tunnel) setState(state TunnelState, podName string, err error) {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	if tunnel.state == TunnelStateClosed {
+		return
+	}
+	tunnel.publishStatus(state, podName, err)
+}
+
+// publishStatus records the new state and sends it on the status channel without blocking. The caller must hold the
+// mutex.
+func (tunnel *Tunnel) publishStatus(state TunnelState, podName string, err error) {
+	tunnel.state = state
+	if podName != "" {
+		tunnel.podName = podName
+	}
+	select {
+	case tunnel.statusChan <- TunnelStatus{State: state, PodName: tunnel.podName, Time: time.Now(), Err: err}:
+	default:
+	}
+}
+
+// isClosed returns true if Close was called on the tunnel.
+func (tunnel *Tunnel) isClosed() bool {
+	select {
+	case <-tunnel.stopChan:
+		return true
+	default:
+		return false
+	}
 }
 
 // getAttachablePodForResource will find a pod that can be port forwarded to given the provided resource type and return
@@ -123,6 +315,10 @@ func (tunnel *Tunnel) getAttachablePodForResourceE(t testing.TestingT) (string,
 		return tunnel.getAttachablePodForServiceE(t)
 	case ResourceTypeDeployment:
 		return tunnel.getAttachablePodForDeploymentE(t)
+	case ResourceTypeStatefulSet:
+		return tunnel.getAttachablePodForStatefulSetE(t)
+	case ResourceTypeSelector:
+		return tunnel.getAttachablePodForSelectorE(t)
 	default:
 		return "", UnknownKubeResourceType{tunnel.resourceType}
 	}
@@ -147,6 +343,25 @@ func (tunnel *Tunnel) getAttachablePodForDeploymentE(t testing.TestingT) (string
 	return "", DeploymentNotAvailable{deploy}
 }
 
+// getAttachablePodForStatefulSetE will find an active pod associated with the StatefulSet and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForStatefulSetE(t testing.TestingT) (string, error) {
+	statefulSet, err := GetStatefulSetE(t, tunnel.kubectlOptions, tunnel.resourceName)
+	if err != nil {
+		return "", err
+	}
+	selectorLabelsOfPods := makeLabels(statefulSet.Spec.Selector.MatchLabels)
+	statefulSetPods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: selectorLabelsOfPods})
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range statefulSetPods {
+		if IsPodAvailable(&pod) {
+			return pod.Name, nil
+		}
+	}
+	return "", StatefulSetNotAvailable{statefulSet}
+}
+
 // getAttachablePodForServiceE will find an active pod associated with the Service and return the pod name.
 func (tunnel *Tunnel) getAttachablePodForServiceE(t testing.TestingT) (string, error) {
 	service, err := GetServiceE(t, tunnel.kubectlOptions, tunnel.resourceName)
@@ -166,45 +381,111 @@ func (tunnel *Tunnel) getAttachablePodForServiceE(t testing.TestingT) (string, e
 	return "", ServiceNotAvailable{service}
 }
 
+// getAttachablePodForSelectorE will find an active pod matching the label selector and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForSelectorE(t testing.TestingT) (string, error) {
+	pods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: tunnel.resourceName})
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if IsPodAvailable(&pod) {
+			return pod.Name, nil
+		}
+	}
+	return "", NoAvailablePodForSelector{tunnel.resourceName}
+}
+
 // ForwardPort opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct. This will fail the
 // test if there is an error attempting to open the port.
 func (tunnel *Tunnel) ForwardPort(t testing.TestingT) {
 	require.NoError(t, tunnel.ForwardPortE(t))
 }
 
-// ForwardPortE opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct.
+// ForwardPortE opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct. The connection is
+// monitored in the background: when it is lost, the tunnel transitions to TunnelStateDisconnected and, if AutoReconnect
+// is enabled, re-resolves the target pod and reconnects on the same local ports until the tunnel is closed.
 func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 	tunnel.logger.Logf(
 		t,
-		"Creating a port forwarding tunnel for resource %s/%s routing local port %d to remote port %d",
+		"Creating a port forwarding tunnel for resource %s/%s routing ports %s",
 		tunnel.resourceType.String(),
 		tunnel.resourceName,
-		tunnel.localPort,
-		tunnel.remotePort,
+		formatTunnelPorts(tunnel.ports),
 	)
 
+	tunnel.setState(TunnelStateConnecting, "", nil)
+	errChan, err := tunnel.connectE(t)
+	if err != nil {
+		tunnel.setState(TunnelStateDisconnected, "", err)
+		return err
+	}
+	go tunnel.monitor(t, errChan)
+	return nil
+}
+
+// monitor waits for the port forwarding connection to end and, if configured, keeps reconnecting the tunnel until it
+// is closed.
+func (tunnel *Tunnel) monitor(t testing.TestingT, errChan <-chan error) {
+	for {
+		err := <-errChan
+		if tunnel.isClosed() {
+			return
+		}
+		if err == nil {
+			err = portforward.ErrLostConnectionToPod
+		}
+		tunnel.logger.Logf(t, "Port forwarding tunnel to pod %s was disconnected: %s", tunnel.PodName(), err)
+		tunnel.setState(TunnelStateDisconnected, "", err)
+		if !tunnel.autoReconnect {
+			return
+		}
+
+		for {
+			select {
+			case <-tunnel.stopChan:
+				return
+			case <-time.After(tunnel.reconnectInterval):
+			}
+
+			tunnel.logger.Logf(t, "Reconnecting port forwarding tunnel for resource %s/%s", tunnel.resourceType.String(), tunnel.resourceName)
+			tunnel.setState(TunnelStateConnecting, "", nil)
+			errChan, err = tunnel.connectE(t)
+			if err == nil {
+				break
+			}
+			if tunnel.isClosed() {
+				return
+			}
+			tunnel.setState(TunnelStateDisconnected, "", err)
+		}
+	}
+}
+
+// connectE resolves the pod to forward to and opens the port forwarding connection. Once the connection is ready, this
+// returns a channel that receives the result of the connection when it ends.
+func (tunnel *Tunnel) connectE(t testing.TestingT) (<-chan error, error) {
 	// Prepare a kubernetes client for the client-go library
 	clientset, err := GetKubernetesClientFromOptionsE(t, tunnel.kubectlOptions)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating a new Kubernetes client: %s", err)
-		return err
+		return nil, err
 	}
 	kubeConfigPath, err := tunnel.kubectlOptions.GetConfigPath(t)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error getting kube config path: %s", err)
-		return err
+		return nil, err
 	}
 	config, err := LoadApiClientConfigE(kubeConfigPath, tunnel.kubectlOptions.ContextName)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error loading Kubernetes config: %s", err)
-		return err
+		return nil, err
 	}
 
 	// Find the pod to port forward to
 	podName, err := tunnel.getAttachablePodForResourceE(t)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error finding available pod: %s", err)
-		return err
+		return nil, err
 	}
 	tunnel.logger.Logf(t, "Selected pod %s to open port forward to", podName)
 
@@ -225,39 +506,48 @@ func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 	transport, upgrader, err := spdy.RoundTripperFor(config)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating http client: %s", err)
-		return err
+		return nil, err
 	}
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", portForwardCreateURL)
 
-	// If the localport is 0, get an available port before continuing. We do this here instead of relying on the
+	// If any local port is 0, get an available port before continuing. We do this here instead of relying on the
 	// underlying portforwarder library, because the portforwarder library does not expose the selected local port in a
-	// machine readable manner.
+	// machine readable manner. The selected ports are stored on the tunnel, so reconnects reuse the same local ports.
 	// Synchronize on the global lock to avoid race conditions with concurrently selecting the same available port,
 	// since there is a brief moment between `GetAvailablePort` and `portforwader.ForwardPorts` where the selected port
 	// is available for selection again.
-	if tunnel.localPort == 0 {
-		tunnel.logger.Logf(t, "Requested local port is 0. Selecting an open port on host system")
-		tunnel.localPort, err = GetAvailablePortE(t)
+	if hasUnassignedLocalPort(tunnel.ports) {
+		globalMutex.Lock()
+		defer globalMutex.Unlock()
+	}
+	for i := range tunnel.ports {
+		if tunnel.ports[i].Local != 0 {
+			continue
+		}
+		tunnel.logger.Logf(t, "Requested local port for remote port %d is 0. Selecting an open port on host system", tunnel.ports[i].Remote)
+		tunnel.ports[i].Local, err = GetAvailablePortE(t)
 		if err != nil {
 			tunnel.logger.Logf(t, "Error getting available port: %s", err)
-			return err
+			return nil, err
 		}
-		tunnel.logger.Logf(t, "Selected port %d", tunnel.localPort)
-		globalMutex.Lock()
-		defer globalMutex.Unlock()
+		tunnel.logger.Logf(t, "Selected port %d", tunnel.ports[i].Local)
 	}
 
 	// Construct a new PortForwarder struct that manages the instructed port forward tunnel
-	ports := []string{fmt.Sprintf("%d:%d", tunnel.localPort, tunnel.remotePort)}
-	portforwarder, err := portforward.New(dialer, ports, tunnel.stopChan, tunnel.readyChan, tunnel.out, tunnel.out)
+	ports := []string{}
+	for _, port := range tunnel.ports {
+		ports = append(ports, fmt.Sprintf("%d:%d", port.Local, port.Remote))
+	}
+	readyChan := make(chan struct{}, 1)
+	portforwarder, err := portforward.New(dialer, ports, tunnel.stopChan, readyChan, tunnel.out, tunnel.out)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating port forwarding tunnel: %s", err)
-		return err
+		return nil, err
 	}
 
 	// Open the tunnel in a goroutine so that it is available in the background. Report errors to the main goroutine via
 	// a new channel.
-	errChan := make(chan error)
+	errChan := make(chan error, 1)
 	go func() {
 		errChan <- portforwarder.ForwardPorts()
 	}()
@@ -265,12 +555,35 @@ func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 	// Wait for an error or the tunnel to be ready
 	select {
 	case err = <-errChan:
+		if err == nil {
+			err = portforward.ErrLostConnectionToPod
+		}
 		tunnel.logger.Logf(t, "Error starting port forwarding tunnel: %s", err)
-		return err
+		return nil, err
 	case <-portforwarder.Ready:
 		tunnel.logger.Logf(t, "Successfully created port forwarding tunnel")
-		return nil
+		tunnel.setState(TunnelStateConnected, podName, nil)
+		return errChan, nil
+	}
+}
+
+// hasUnassignedLocalPort returns true if any of the given ports has a local port of 0.
+func hasUnassignedLocalPort(ports []TunnelPort) bool {
+	for _, port := range ports {
+		if port.Local == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTunnelPorts formats the port pairs of a tunnel for logging, e.g. "8080->80, 9090->9090".
+func formatTunnelPorts(ports []TunnelPort) string {
+	out := []string{}
+	for _, port := range ports {
+		out = append(out, fmt.Sprintf("%d->%d", port.Local, port.Remote))
 	}
+	return strings.Join(out, ", ")
 }
 
 // GetAvailablePort retrieves an available port on the host machine. This delegates the port selection to the golang net
@@ -18,6 +18,7 @@ import (
 
 	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
 	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTunnelOpensAPortForwardTunnelToPod(t *testing.T) {
@@ -108,6 +109,120 @@ func TestTunnelOpensAPortForwardTunnelToService(t *testing.T) {
 	)
 }
 
+func TestTunnelOpensAPortForwardTunnelToStatefulSet(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleStatefulSetYAMLTemplate, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-statefulset-0", 60, 1*time.Second)
+
+	// Open a tunnel to the statefulset from any available port locally
+	tunnel := NewTunnel(options, ResourceTypeStatefulSet, "nginx-statefulset", 0, 80)
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+	require.Equal(t, "nginx-statefulset-0", tunnel.PodName())
+
+	// Setup a TLS configuration to submit with the helper, a blank struct is acceptable
+	tlsConfig := tls.Config{}
+
+	// Try to access the nginx service on the local port, retrying until we get a good response for up to 5 minutes
+	http_helper.HttpGetWithRetryWithCustomValidation(
+		t,
+		fmt.Sprintf("http://%s", tunnel.Endpoint()),
+		&tlsConfig,
+		60,
+		5*time.Second,
+		verifyNginxWelcomePage,
+	)
+}
+
+func TestTunnelOpensAPortForwardTunnelToLabelSelectorWithMultiplePorts(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleDeploymentYAMLTemplate, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilDeploymentAvailable(t, options, "nginx-deployment", 60, 1*time.Second)
+
+	// Forward the same remote port twice to make sure every port pair is served by the tunnel
+	tunnel := NewTunnelWithOptions(options, TunnelOptions{
+		ResourceType: ResourceTypeSelector,
+		ResourceName: "app=nginx",
+		Ports:        []TunnelPort{{Local: 0, Remote: 80}, {Local: 0, Remote: 80}},
+	})
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+	require.Equal(t, TunnelStateConnected, tunnel.State())
+
+	tlsConfig := tls.Config{}
+	for _, port := range tunnel.Ports() {
+		require.NotEqual(t, 0, port.Local)
+		http_helper.HttpGetWithRetryWithCustomValidation(
+			t,
+			fmt.Sprintf("http://localhost:%d", port.Local),
+			&tlsConfig,
+			60,
+			5*time.Second,
+			verifyNginxWelcomePage,
+		)
+	}
+}
+
+func TestTunnelReconnectsWhenPodIsRescheduled(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleDeploymentYAMLTemplate, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilDeploymentAvailable(t, options, "nginx-deployment", 60, 1*time.Second)
+
+	tunnel := NewTunnelWithOptions(options, TunnelOptions{
+		ResourceType:  ResourceTypeDeployment,
+		ResourceName:  "nginx-deployment",
+		Ports:         []TunnelPort{{Local: 0, Remote: 80}},
+		AutoReconnect: true,
+	})
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+	originalPod := tunnel.PodName()
+	endpoint := tunnel.Endpoint()
+
+	// Delete the pod backing the tunnel and wait for the tunnel to report the outage and the reconnect
+	RunKubectl(t, options, "delete", "pod", originalPod)
+	sawDisconnect := false
+	timeout := time.After(2 * time.Minute)
+	for reconnected := false; !reconnected; {
+		select {
+		case status := <-tunnel.Status():
+			if status.State == TunnelStateDisconnected {
+				sawDisconnect = true
+			}
+			reconnected = sawDisconnect && status.State == TunnelStateConnected
+		case <-timeout:
+			t.Fatal("Timed out waiting for the tunnel to reconnect")
+		}
+	}
+	require.NotEqual(t, originalPod, tunnel.PodName())
+	require.Equal(t, endpoint, tunnel.Endpoint())
+
+	tlsConfig := tls.Config{}
+	http_helper.HttpGetWithRetryWithCustomValidation(
+		t,
+		fmt.Sprintf("http://%s", tunnel.Endpoint()),
+		&tlsConfig,
+		60,
+		5*time.Second,
+		verifyNginxWelcomePage,
+	)
+}
+
 func verifyNginxWelcomePage(statusCode int, body string) bool {
 	if statusCode != 200 {
 		return false
@@ -152,3 +267,36 @@ spec:
     targetPort: 80
     port: 80
 `
+
+const ExampleStatefulSetYAMLTemplate = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: nginx-statefulset
+  namespace: %s
+spec:
+  serviceName: nginx
+  replicas: 1
+  selector:
+    matchLabels:
+      app: nginx-sts
+  template:
+    metadata:
+      labels:
+        app: nginx-sts
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.15.7
+        ports:
+        - containerPort: 80
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 80
+`
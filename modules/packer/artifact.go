@@ -0,0 +1,124 @@
+package packer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Artifact is an artifact produced by a Packer build, as reported in the -machine-readable output.
+type Artifact struct {
+	// BuildName is the name of the build that produced the artifact, e.g. docker.ubuntu.
+	BuildName string
+	// Index is the position of the artifact among the artifacts of its build.
+	Index int
+	// BuilderType is the ID of the builder that produced the artifact, e.g. mitchellh.amazonebs or packer.docker.
+	BuilderType string
+	// ID is the raw artifact ID, e.g. us-east-1:ami-b481b3de or sha256:4c7f0e1d...
+	ID string
+	// String is the human readable description of the artifact, e.g. "Imported Docker image: ...".
+	String string
+	// Files are the paths of the files of the artifact, if any.
+	Files []string
+}
+
+// The Packer machine-readable output is made of lines of the format:
+//
+// <timestamp>,<target>,<type>,<data>...
+//
+// Where commas within the data are escaped. Every artifact is reported with a line per attribute, for example:
+//
+// 1456332887,amazon-ebs,artifact,0,builder-id,mitchellh.amazonebs
+// 1456332887,amazon-ebs,artifact,0,id,us-east-1:ami-b481b3de
+// 1456332887,amazon-ebs,artifact,0,string,AMIs were created:\nus-east-1: ami-b481b3de\n
+// 1456332887,amazon-ebs,artifact,0,files-count,0
+// 1456332887,amazon-ebs,artifact,0,end
+const (
+	machineReadableArtifactType = "artifact"
+	machineReadableEscapedComma = "%!(PACKER_COMMA)"
+)
+
+// ParseArtifacts parses the -machine-readable output of a Packer build and returns all the artifacts, keyed by build
+// name. This will fail the test if there is an error.
+func ParseArtifacts(t testing.TestingT, packerLogOutput string) map[string][]Artifact {
+	artifacts, err := ParseArtifactsE(t, packerLogOutput)
+	require.NoError(t, err)
+	return artifacts
+}
+
+// ParseArtifactsE parses the -machine-readable output of a Packer build and returns all the artifacts, keyed by build
+// name. The artifacts of each build are sorted by index. Lines that are not artifact lines are ignored.
+func ParseArtifactsE(t testing.TestingT, packerLogOutput string) (map[string][]Artifact, error) {
+	artifactsByBuild := map[string]map[int]*Artifact{}
+
+	for _, line := range strings.Split(packerLogOutput, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		// <timestamp>,<target>,artifact,<index>,<key>
+		if len(fields) < 5 || fields[2] != machineReadableArtifactType {
+			continue
+		}
+		if _, err := strconv.ParseInt(fields[0], 10, 64); err != nil {
+			continue
+		}
+
+		buildName := fields[1]
+		index, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, MalformedMachineReadableOutputError{Line: line, Reason: "invalid artifact index"}
+		}
+		if artifactsByBuild[buildName] == nil {
+			artifactsByBuild[buildName] = map[int]*Artifact{}
+		}
+		artifact, ok := artifactsByBuild[buildName][index]
+		if !ok {
+			artifact = &Artifact{BuildName: buildName, Index: index, Files: []string{}}
+			artifactsByBuild[buildName][index] = artifact
+		}
+
+		key, values := fields[4], fields[5:]
+		value := ""
+		if len(values) > 0 {
+			value = unescapeMachineReadableValue(values[len(values)-1])
+		}
+		switch key {
+		case "builder-id":
+			artifact.BuilderType = value
+		case "id":
+			artifact.ID = value
+		case "string":
+			artifact.String = value
+		case "file":
+			// <timestamp>,<target>,artifact,<index>,file,<file index>,<path>
+			if len(values) != 2 {
+				return nil, MalformedMachineReadableOutputError{Line: line, Reason: "expected a file index and a path"}
+			}
+			artifact.Files = append(artifact.Files, value)
+		}
+	}
+
+	artifacts := map[string][]Artifact{}
+	for buildName, byIndex := range artifactsByBuild {
+		indexes := []int{}
+		for index := range byIndex {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		for _, index := range indexes {
+			artifacts[buildName] = append(artifacts[buildName], *byIndex[index])
+		}
+	}
+	return artifacts, nil
+}
+
+// unescapeMachineReadableValue reverts the escaping of commas and new lines of the -machine-readable output.
+func unescapeMachineReadableValue(value string) string {
+	return strings.NewReplacer(
+		machineReadableEscapedComma, ",",
+		`\n`, "\n",
+		`\r`, "\r",
+	).Replace(value)
+}
@@ -0,0 +1,62 @@
+package packer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArtifactsMultipleBuilds(t *testing.T) {
+	t.Parallel()
+
+	text := `
+	1701705531,,ui,say,==> Builds finished. The artifacts of successful builds are:
+	1701705531,amazon-ebs.ubuntu,artifact-count,1
+	1701705531,amazon-ebs.ubuntu,artifact,0,builder-id,mitchellh.amazonebs
+	1701705531,amazon-ebs.ubuntu,artifact,0,id,us-east-1:ami-b481b3de%!(PACKER_COMMA)us-west-2:ami-5d2f1a3c
+	1701705531,amazon-ebs.ubuntu,artifact,0,string,AMIs were created:\nus-east-1: ami-b481b3de\nus-west-2: ami-5d2f1a3c\n
+	1701705531,amazon-ebs.ubuntu,artifact,0,files-count,0
+	1701705531,amazon-ebs.ubuntu,artifact,0,end
+	1701705531,docker.ubuntu,artifact-count,2
+	1701705531,docker.ubuntu,artifact,0,builder-id,packer.docker
+	1701705531,docker.ubuntu,artifact,0,id,sha256:4c7f0e1d2a
+	1701705531,docker.ubuntu,artifact,0,string,Imported Docker image: sha256:4c7f0e1d2a
+	1701705531,docker.ubuntu,artifact,0,files-count,0
+	1701705531,docker.ubuntu,artifact,0,end
+	1701705531,docker.ubuntu,artifact,1,builder-id,packer.post-processor.docker-tag
+	1701705531,docker.ubuntu,artifact,1,id,gruntwork/ubuntu:latest
+	1701705531,docker.ubuntu,artifact,1,files-count,2
+	1701705531,docker.ubuntu,artifact,1,file,0,manifest.json
+	1701705531,docker.ubuntu,artifact,1,file,1,layer.tar
+	1701705531,docker.ubuntu,artifact,1,end
+	`
+
+	artifacts := ParseArtifacts(t, text)
+	require.Len(t, artifacts, 2)
+
+	assert.Equal(t, []Artifact{{
+		BuildName:   "amazon-ebs.ubuntu",
+		BuilderType: "mitchellh.amazonebs",
+		ID:          "us-east-1:ami-b481b3de,us-west-2:ami-5d2f1a3c",
+		String:      "AMIs were created:\nus-east-1: ami-b481b3de\nus-west-2: ami-5d2f1a3c\n",
+		Files:       []string{},
+	}}, artifacts["amazon-ebs.ubuntu"])
+
+	require.Len(t, artifacts["docker.ubuntu"], 2)
+	assert.Equal(t, "packer.docker", artifacts["docker.ubuntu"][0].BuilderType)
+	assert.Equal(t, "sha256:4c7f0e1d2a", artifacts["docker.ubuntu"][0].ID)
+	assert.Equal(t, 1, artifacts["docker.ubuntu"][1].Index)
+	assert.Equal(t, "gruntwork/ubuntu:latest", artifacts["docker.ubuntu"][1].ID)
+	assert.Equal(t, []string{"manifest.json", "layer.tar"}, artifacts["docker.ubuntu"][1].Files)
+}
+
+func TestParseArtifactsNoArtifacts(t *testing.T) {
+	t.Parallel()
+
+	artifacts := ParseArtifacts(t, "foo\n1701705531,,ui,say,Build 'null' finished.\n")
+	assert.Empty(t, artifacts)
+
+	_, err := ParseArtifactsE(t, "1701705531,null,artifact,first,id,foo")
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package packer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	ttesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// fakePackerScript emulates packer: the build of a template named fail.json fails right away after reporting an
+// artifact, while any other build runs until it is interrupted.
+const fakePackerScript = `#!/usr/bin/env bash
+if [[ "$1" == "-version" ]]; then
+  echo "1.9.4"
+  exit 0
+fi
+template="${@: -1}"
+if [[ "$(basename "$template")" == "fail.json" ]]; then
+  echo "1701705531,null.partial,artifact,0,id,partial-image"
+  exit 1
+fi
+trap 'echo interrupted; exit 1' INT
+sleep 30 > /dev/null 2>&1 &
+wait
+`
+
+// installFakePacker puts the fake packer first in the PATH and returns the directory of the fake templates.
+func installFakePacker(t *testing.T) string {
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "packer"), []byte(fakePackerScript), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	templateDir := t.TempDir()
+	for _, name := range []string{"fail.json", "slow.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, name), []byte("{}"), 0644))
+	}
+	return templateDir
+}
+
+func TestBuildArtifactTimeout(t *testing.T) {
+	templateDir := installFakePacker(t)
+
+	start := time.Now()
+	_, err := BuildArtifactE(t, &Options{
+		Template:                   filepath.Join(templateDir, "slow.json"),
+		Timeout:                    500 * time.Millisecond,
+		DisableTemporaryPluginPath: true,
+		Logger:                     logger.Discard,
+	})
+	require.Error(t, err)
+	assert.True(t, errors.As(err, &BuildInterruptedError{}))
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestBuildArtifactsFailFast(t *testing.T) {
+	templateDir := installFakePacker(t)
+
+	var cleanedUp map[string][]Artifact
+	start := time.Now()
+	_, err := BuildArtifactsE(t, map[string]*Options{
+		"fail": {
+			Template:                   filepath.Join(templateDir, "fail.json"),
+			FailFast:                   true,
+			DisableTemporaryPluginPath: true,
+			Logger:                     logger.Discard,
+			CleanupOnFailure: func(t ttesting.TestingT, artifacts map[string][]Artifact) {
+				cleanedUp = artifacts
+			},
+		},
+		"slow": {
+			Template:                   filepath.Join(templateDir, "slow.json"),
+			DisableTemporaryPluginPath: true,
+			Logger:                     logger.Discard,
+		},
+	})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, errors.As(err, &BuildInterruptedError{}))
+	require.Len(t, cleanedUp["null.partial"], 1)
+	assert.Equal(t, "partial-image", cleanedUp["null.partial"][0].ID)
+}
+
+// deadlineT is a TestingT with the given deadline.
+type deadlineT struct {
+	*testing.T
+	deadline time.Time
+}
+
+func (t deadlineT) Deadline() (time.Time, bool) {
+	return t.deadline, true
+}
+
+func TestNewBuildContextCloseToTestDeadline(t *testing.T) {
+	t.Parallel()
+
+	for _, timeLeft := range []time.Duration{30 * time.Second, 3 * time.Minute, time.Hour} {
+		ctx, cancel := newBuildContext(deadlineT{T: t, deadline: time.Now().Add(timeLeft)}, &Options{})
+		require.NoError(t, ctx.Err(), "Time left: %s", timeLeft)
+		deadline, hasDeadline := ctx.Deadline()
+		require.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(timeLeft-interruptMargin(timeLeft)), deadline, time.Second)
+		cancel()
+	}
+
+	assert.Equal(t, 15*time.Second, interruptMargin(30*time.Second))
+	assert.Equal(t, defaultInterruptTimeout, interruptMargin(time.Hour))
+}
@@ -0,0 +1,118 @@
+package packer
+
+import (
+	"strings"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// packer fmt -check exits with this code when some of the files are not formatted
+const formatCheckFailedExitCode = 3
+
+// Init runs 'packer init' to install the plugins required by the given HCL2 Packer template. This will fail the test
+// if there is an error.
+func Init(t testing.TestingT, options *Options) {
+	require.NoError(t, InitE(t, options))
+}
+
+// InitE runs 'packer init' to install the plugins required by the given HCL2 Packer template, into the directory set
+// with the PACKER_PLUGIN_PATH variable of options.Env if any. It does nothing for JSON templates, or if the local
+// version of Packer does not support init. Note that BuildArtifactE and ValidateE already run init.
+func InitE(t testing.TestingT, options *Options) error {
+	return packerInit(t, options)
+}
+
+// Validate runs 'packer validate' to check that the given Packer template is valid. This will fail the test if the
+// template is invalid or if there is an error.
+func Validate(t testing.TestingT, options *Options) {
+	require.NoError(t, ValidateE(t, options))
+}
+
+// ValidateE runs 'packer init' and 'packer validate' to check that the given Packer template is valid, using the
+// variables, var files and build filters of the options. It returns a ValidationError with the output of Packer if the
+// template is invalid.
+func ValidateE(t testing.TestingT, options *Options) error {
+	options.Logger.Logf(t, "Running Packer to validate template %s", options.Template)
+
+	cleanup, err := preparePluginsE(t, options)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	args := append([]string{"validate"}, formatPackerVarArgs(options)...)
+	cmd := shell.Command{
+		Command:    "packer",
+		Args:       append(args, options.Template),
+		Env:        options.Env,
+		WorkingDir: options.WorkingDir,
+	}
+	output, err := shell.RunCommandAndGetOutputE(t, cmd)
+	if err == nil {
+		return nil
+	}
+	if exitCode, exitCodeErr := shell.GetExitCodeForRunCommandError(err); exitCodeErr == nil && exitCode > 0 {
+		return ValidationError{Template: options.Template, Output: output}
+	}
+	return err
+}
+
+// FormatCheck runs 'packer fmt -check' on the given Packer template and returns the files that are not formatted. This
+// will fail the test if there is an error.
+func FormatCheck(t testing.TestingT, options *Options) []string {
+	unformattedFiles, err := FormatCheckE(t, options)
+	require.NoError(t, err)
+	return unformattedFiles
+}
+
+// FormatCheckE runs 'packer fmt -check' on the given Packer template, which may be a file or a directory, and returns
+// the files that are not in the canonical HCL2 format. It returns an empty list if all the files are formatted.
+func FormatCheckE(t testing.TestingT, options *Options) ([]string, error) {
+	cmd := shell.Command{
+		Command:    "packer",
+		Args:       []string{"fmt", "-check", options.Template},
+		Env:        options.Env,
+		WorkingDir: options.WorkingDir,
+	}
+	output, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		if exitCode, exitCodeErr := shell.GetExitCodeForRunCommandError(err); exitCodeErr != nil || exitCode != formatCheckFailedExitCode {
+			return nil, err
+		}
+	}
+
+	unformattedFiles := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if file := strings.TrimSpace(line); file != "" {
+			unformattedFiles = append(unformattedFiles, file)
+		}
+	}
+	return unformattedFiles, nil
+}
+
+// Inspect runs 'packer inspect' on the given Packer template and returns its output. This will fail the test if there
+// is an error.
+func Inspect(t testing.TestingT, options *Options) string {
+	output, err := InspectE(t, options)
+	require.NoError(t, err)
+	return output
+}
+
+// InspectE runs 'packer inspect' on the given Packer template and returns its output, which describes the variables,
+// sources, builds and provisioners of the template.
+func InspectE(t testing.TestingT, options *Options) (string, error) {
+	args := []string{"inspect"}
+	for _, filePath := range options.VarFiles {
+		args = append(args, "-var-file", filePath)
+	}
+	cmd := shell.Command{
+		Command:    "packer",
+		Args:       append(args, options.Template),
+		Env:        options.Env,
+		WorkingDir: options.WorkingDir,
+	}
+	return shell.RunCommandAndGetStdOutE(t, cmd)
+}
@@ -0,0 +1,142 @@
+package packer
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/docker"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// The builder IDs of the artifacts that may reference an image in the local Docker daemon: the docker builder (when it
+// commits the container), and the docker-import and docker-tag post-processors.
+var dockerImageBuilderTypes = map[string]bool{
+	"packer.docker":                       true,
+	"packer.post-processor.docker-import": true,
+	"packer.post-processor.docker-tag":    true,
+}
+
+// DockerImage is a Docker image built by Packer.
+type DockerImage struct {
+	// ID of the image, e.g. sha256:4c7f0e1d...
+	ID string
+
+	// Tags of the image, e.g. as set by the docker-tag post-processor
+	Tags []string
+
+	// Artifacts reported by the build that produced the image
+	Artifacts []Artifact
+}
+
+// BuildDockerImage builds the given Packer template, which must have a single build with a docker builder (use Only to
+// select it), and returns the resulting image. This will fail the test if there is an error.
+func BuildDockerImage(t testing.TestingT, options *Options) *DockerImage {
+	image, err := BuildDockerImageE(t, options)
+	require.NoError(t, err)
+	return image
+}
+
+// BuildDockerImageE builds the given Packer template, which must have a single build with a docker builder (use Only
+// to select it), and returns the resulting image. The image is resolved from the artifacts of the docker builder and
+// of the docker-import and docker-tag post-processors, so the builder must commit the container, or a post-processor
+// must import it into the local Docker daemon.
+func BuildDockerImageE(t testing.TestingT, options *Options) (*DockerImage, error) {
+	artifacts, err := BuildAllArtifactsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	return resolveDockerImageE(t, options, artifacts, docker.InspectImageE)
+}
+
+// resolveDockerImageE returns the Docker image that the given artifacts reference. The artifacts of each build are
+// checked from the last one, so that the image imported by a post-processor takes precedence over the one committed by
+// the builder. The images are looked up in the local Docker daemon with inspectImage.
+func resolveDockerImageE(
+	t testing.TestingT,
+	options *Options,
+	artifacts map[string][]Artifact,
+	inspectImage func(t testing.TestingT, image string) (*docker.ImageInspect, error),
+) (*DockerImage, error) {
+	buildNames := []string{}
+	for buildName := range artifacts {
+		buildNames = append(buildNames, buildName)
+	}
+	sort.Strings(buildNames)
+
+	var image *DockerImage
+	imageBuilds := []string{}
+	for _, buildName := range buildNames {
+		buildArtifacts := artifacts[buildName]
+		for i := len(buildArtifacts) - 1; i >= 0; i-- {
+			if !dockerImageBuilderTypes[buildArtifacts[i].BuilderType] {
+				continue
+			}
+			// The artifact may not be an image, e.g. when the docker builder exports the container to a file
+			inspect, err := inspectImage(t, buildArtifacts[i].ID)
+			if err != nil {
+				continue
+			}
+			image = &DockerImage{ID: inspect.ID, Tags: inspect.RepoTags, Artifacts: buildArtifacts}
+			imageBuilds = append(imageBuilds, buildName)
+			break
+		}
+	}
+
+	switch len(imageBuilds) {
+	case 0:
+		return nil, DockerImageNotFoundError{Template: options.Template}
+	case 1:
+		options.Logger.Logf(t, "Packer build %s produced Docker image %s %v", imageBuilds[0], image.ID, image.Tags)
+		return image, nil
+	default:
+		return nil, MultipleDockerImagesError{Template: options.Template, Builds: imageBuilds}
+	}
+}
+
+// Remove removes the image and all its tags from the local Docker daemon. This will fail the test if there is an
+// error.
+func (image *DockerImage) Remove(t testing.TestingT, logger *logger.Logger) {
+	require.NoError(t, image.RemoveE(t, logger))
+}
+
+// RemoveE removes the image and all its tags from the local Docker daemon.
+func (image *DockerImage) RemoveE(t testing.TestingT, logger *logger.Logger) error {
+	// An image with several tags cannot be removed by ID, but it is removed along with its last tag
+	if len(image.Tags) == 0 {
+		return docker.DeleteImageE(t, image.ID, logger)
+	}
+	var errorsOccurred = new(multierror.Error)
+	for _, tag := range image.Tags {
+		errorsOccurred = multierror.Append(errorsOccurred, docker.DeleteImageE(t, tag, logger))
+	}
+	return errorsOccurred.ErrorOrNil()
+}
+
+// BuildAndTestDockerImage builds the given Packer template into a Docker image as with BuildDockerImage, runs the given
+// structure tests against the image, and then removes it. This will fail the test if the build or any of the tests
+// fails.
+func BuildAndTestDockerImage(t testing.TestingT, options *Options, tests docker.ImageStructureTests) {
+	require.NoError(t, BuildAndTestDockerImageE(t, options, tests))
+}
+
+// BuildAndTestDockerImageE builds the given Packer template into a Docker image as with BuildDockerImageE, runs the
+// given structure tests against the image, and then removes it. This makes it possible to test the provisioning of a
+// Packer template without deploying anything to the cloud. The failures of the tests are accumulated into a MultiError,
+// along with any failure to remove the image.
+func BuildAndTestDockerImageE(t testing.TestingT, options *Options, tests docker.ImageStructureTests) error {
+	image, err := BuildDockerImageE(t, options)
+	if err != nil {
+		return err
+	}
+
+	if tests.Logger == nil {
+		tests.Logger = options.Logger
+	}
+	var errorsOccurred = new(multierror.Error)
+	errorsOccurred = multierror.Append(errorsOccurred, docker.AssertImageStructureE(t, image.ID, tests))
+	errorsOccurred = multierror.Append(errorsOccurred, image.RemoveE(t, options.Logger))
+	return errorsOccurred.ErrorOrNil()
+}
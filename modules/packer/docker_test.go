@@ -0,0 +1,107 @@
+package packer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/docker"
+	ttesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+func TestResolveDockerImageWithoutDockerArtifacts(t *testing.T) {
+	t.Parallel()
+
+	artifacts := map[string][]Artifact{
+		"amazon-ebs.ubuntu": {{BuildName: "amazon-ebs.ubuntu", BuilderType: "mitchellh.amazonebs", ID: "us-east-1:ami-b481b3de"}},
+	}
+	_, err := resolveDockerImageE(t, &Options{Template: "build.pkr.hcl"}, artifacts, inspectFakeDockerImageE)
+	assert.Equal(t, DockerImageNotFoundError{Template: "build.pkr.hcl"}, err)
+}
+
+// The machine-readable artifact lines reported by the docker builder and post-processors
+const (
+	dockerCommitArtifact = `
+1701705531,docker.ubuntu,artifact,0,builder-id,packer.docker
+1701705531,docker.ubuntu,artifact,0,id,sha256:4c7f0e1d2a
+1701705531,docker.ubuntu,artifact,0,end
+`
+	dockerExportArtifact = `
+1701705531,docker.ubuntu,artifact,0,builder-id,packer.docker
+1701705531,docker.ubuntu,artifact,0,id,/tmp/image.tar
+1701705531,docker.ubuntu,artifact,0,files-count,1
+1701705531,docker.ubuntu,artifact,0,file,0,/tmp/image.tar
+1701705531,docker.ubuntu,artifact,0,end
+`
+	dockerTagArtifact = `
+1701705531,docker.ubuntu,artifact,1,builder-id,packer.post-processor.docker-tag
+1701705531,docker.ubuntu,artifact,1,id,gruntwork/ubuntu:latest
+1701705531,docker.ubuntu,artifact,1,end
+`
+	dockerImportArtifact = `
+1701705531,docker.ubuntu,artifact,1,builder-id,packer.post-processor.docker-import
+1701705531,docker.ubuntu,artifact,1,id,sha256:9b8a7c6d5e
+1701705531,docker.ubuntu,artifact,1,end
+`
+	otherDockerBuildArtifact = `
+1701705531,docker.debian,artifact,0,builder-id,packer.docker
+1701705531,docker.debian,artifact,0,id,sha256:1a2b3c4d5e
+1701705531,docker.debian,artifact,0,end
+`
+)
+
+// fakeDockerImages are the images of the local Docker daemon that inspectFakeDockerImageE looks up, by ID or tag.
+var fakeDockerImages = map[string]*docker.ImageInspect{
+	"sha256:4c7f0e1d2a":       {ID: "sha256:4c7f0e1d2a"},
+	"gruntwork/ubuntu:latest": {ID: "sha256:4c7f0e1d2a", RepoTags: []string{"gruntwork/ubuntu:latest"}},
+	"sha256:9b8a7c6d5e":       {ID: "sha256:9b8a7c6d5e"},
+	"sha256:1a2b3c4d5e":       {ID: "sha256:1a2b3c4d5e"},
+}
+
+func inspectFakeDockerImageE(t ttesting.TestingT, image string) (*docker.ImageInspect, error) {
+	if inspect, ok := fakeDockerImages[image]; ok {
+		return inspect, nil
+	}
+	return nil, docker.ImageNotFoundError{Image: image}
+}
+
+func TestResolveDockerImage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		output        string
+		expectedID    string
+		expectedTags  []string
+		expectedError error
+	}{
+		{"committed image", dockerCommitArtifact, "sha256:4c7f0e1d2a", nil, nil},
+		{"tagged image", dockerCommitArtifact + dockerTagArtifact, "sha256:4c7f0e1d2a", []string{"gruntwork/ubuntu:latest"}, nil},
+		{"imported image", dockerExportArtifact + dockerImportArtifact, "sha256:9b8a7c6d5e", nil, nil},
+		{"exported image", dockerExportArtifact, "", nil, DockerImageNotFoundError{Template: "build.pkr.hcl"}},
+		{
+			"several builds", dockerCommitArtifact + otherDockerBuildArtifact, "", nil,
+			MultipleDockerImagesError{Template: "build.pkr.hcl", Builds: []string{"docker.debian", "docker.ubuntu"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			artifacts := ParseArtifacts(t, testCase.output)
+			image, err := resolveDockerImageE(t, &Options{Template: "build.pkr.hcl"}, artifacts, inspectFakeDockerImageE)
+			if testCase.expectedError != nil {
+				assert.Equal(t, testCase.expectedError, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedID, image.ID)
+			assert.Equal(t, testCase.expectedTags, image.Tags)
+			assert.Equal(t, artifacts["docker.ubuntu"], image.Artifacts)
+		})
+	}
+}
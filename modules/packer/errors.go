@@ -0,0 +1,59 @@
+package packer
+
+import "fmt"
+
+// MalformedMachineReadableOutputError is returned when a line of the Packer -machine-readable output cannot be parsed.
+type MalformedMachineReadableOutputError struct {
+	Line   string
+	Reason string
+}
+
+func (err MalformedMachineReadableOutputError) Error() string {
+	return fmt.Sprintf("Malformed Packer machine-readable output line (%s): %s", err.Reason, err.Line)
+}
+
+// ValidationError is returned when 'packer validate' reports the template as invalid.
+type ValidationError struct {
+	Template string
+	Output   string
+}
+
+func (err ValidationError) Error() string {
+	return fmt.Sprintf("Packer template %s is invalid:\n%s", err.Template, err.Output)
+}
+
+// BuildInterruptedError is returned when a Packer build is interrupted because its context was cancelled or its timeout
+// was exceeded.
+type BuildInterruptedError struct {
+	Template string
+	Cause    error
+}
+
+func (err BuildInterruptedError) Error() string {
+	return fmt.Sprintf("Packer build of template %s was interrupted: %v", err.Template, err.Cause)
+}
+
+func (err BuildInterruptedError) Unwrap() error {
+	return err.Cause
+}
+
+// DockerImageNotFoundError is returned when none of the artifacts of a Packer build references an image in the local
+// Docker daemon.
+type DockerImageNotFoundError struct {
+	Template string
+}
+
+func (err DockerImageNotFoundError) Error() string {
+	return fmt.Sprintf("Packer build of template %s did not produce any Docker image in the local Docker daemon", err.Template)
+}
+
+// MultipleDockerImagesError is returned when several builds of a Packer template produced a Docker image, so the image
+// to use is ambiguous.
+type MultipleDockerImagesError struct {
+	Template string
+	Builds   []string
+}
+
+func (err MultipleDockerImagesError) Error() string {
+	return fmt.Sprintf("Packer builds %v of template %s all produced a Docker image, use Only to select one", err.Builds, err.Template)
+}
@@ -2,11 +2,13 @@
 package packer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,8 +36,17 @@ type Options struct {
 	WorkingDir                 string            // The directory to run packer in
 	Logger                     *logger.Logger    // If set, use a non-default logger
 	DisableTemporaryPluginPath bool              // If set, do not use a temporary directory for Packer plugins.
+	Context                    context.Context   // If set, interrupt the build when the context is done
+	Timeout                    time.Duration     // If set, interrupt the build if it takes longer than this, retries included
+	FailFast                   bool              // If set, BuildArtifactsE cancels the other builds as soon as this build fails
+	// If set, called with the artifacts reported before the build failed or was interrupted, so that the test can delete
+	// them, e.g. with aws.DeleteAmiAndAllSnapshots.
+	CleanupOnFailure func(t testing.TestingT, artifacts map[string][]Artifact)
 }
 
+// When a build is interrupted, Packer is given this long to tear down its builders before it is killed
+const defaultInterruptTimeout = 2 * time.Minute
+
 // BuildArtifacts can take a map of identifierName <-> Options and then parallelize
 // the packer builds. Once all the packer builds have completed a map of identifierName <-> generated identifier
 // is returned. The identifierName can be anything you want, it is only used so that you can
@@ -53,7 +64,8 @@ func BuildArtifacts(t testing.TestingT, artifactNameToOptions map[string]*Option
 // BuildArtifactsE can take a map of identifierName <-> Options and then parallelize
 // the packer builds. Once all the packer builds have completed a map of identifierName <-> generated identifier
 // is returned. If any artifact fails to build, the errors are accumulated and returned
-// as a MultiError. The identifierName can be anything you want, it is only used so that you can
+// as a MultiError. If the Options of the failed build have FailFast set, the other builds are interrupted.
+// The identifierName can be anything you want, it is only used so that you can
 // know which generated artifact is which.
 func BuildArtifactsE(t testing.TestingT, artifactNameToOptions map[string]*Options) (map[string]string, error) {
 	var waitForArtifacts sync.WaitGroup
@@ -61,6 +73,11 @@ func BuildArtifactsE(t testing.TestingT, artifactNameToOptions map[string]*Optio
 
 	var artifactNameToArtifactId = map[string]string{}
 	var errorsOccurred = new(multierror.Error)
+	var resultsLock sync.Mutex
+
+	// Cancelled when a build that has FailFast set fails, to interrupt the sibling builds
+	siblingsCtx, cancelSiblings := context.WithCancel(context.Background())
+	defer cancelSiblings()
 
 	for artifactName, curOptions := range artifactNameToOptions {
 		// The following is necessary to make sure artifactName and curOptions don't
@@ -69,10 +86,28 @@ func BuildArtifactsE(t testing.TestingT, artifactNameToOptions map[string]*Optio
 		curOptions := curOptions
 		go func() {
 			defer waitForArtifacts.Done()
-			artifactId, err := BuildArtifactE(t, curOptions)
 
+			parentCtx := curOptions.Context
+			if parentCtx == nil {
+				parentCtx = context.Background()
+			}
+			buildCtx, cancelBuild := context.WithCancel(parentCtx)
+			defer cancelBuild()
+			stop := context.AfterFunc(siblingsCtx, cancelBuild)
+			defer stop()
+
+			buildOptions := *curOptions
+			buildOptions.Context = buildCtx
+			artifactId, err := BuildArtifactE(t, &buildOptions)
+
+			resultsLock.Lock()
+			defer resultsLock.Unlock()
 			if err != nil {
 				errorsOccurred = multierror.Append(errorsOccurred, err)
+				if curOptions.FailFast && siblingsCtx.Err() == nil {
+					curOptions.Logger.Logf(t, "Build %s failed, interrupting the other builds", artifactName)
+					cancelSiblings()
+				}
 			} else {
 				artifactNameToArtifactId[artifactName] = artifactId
 			}
@@ -95,8 +130,139 @@ func BuildArtifact(t testing.TestingT, options *Options) string {
 
 // BuildArtifactE builds the given Packer template and return the generated Artifact ID.
 func BuildArtifactE(t testing.TestingT, options *Options) (string, error) {
+	output, err := runPackerBuildE(t, options)
+	if err != nil {
+		return "", err
+	}
+
+	return extractArtifactID(output)
+}
+
+// BuildAllArtifacts builds the given Packer template and returns all the generated artifacts, keyed by build name. This
+// will fail the test if there is an error.
+func BuildAllArtifacts(t testing.TestingT, options *Options) map[string][]Artifact {
+	artifacts, err := BuildAllArtifactsE(t, options)
+	require.NoError(t, err)
+	return artifacts
+}
+
+// BuildAllArtifactsE builds the given Packer template and returns all the generated artifacts, keyed by build name
+// (e.g. docker.ubuntu for an HCL2 source, or the builder name for a JSON template). Unlike BuildArtifactE, which only
+// returns the first artifact ID, this returns every artifact of every build of multi-builder templates.
+func BuildAllArtifactsE(t testing.TestingT, options *Options) (map[string][]Artifact, error) {
+	output, err := runPackerBuildE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseArtifactsE(t, output)
+}
+
+// runPackerBuildE runs 'packer init' and 'packer build' for the given Packer template and returns the machine-readable
+// output of the build. The build is interrupted when options.Context is done, options.Timeout is exceeded or the test
+// is about to time out, giving Packer the chance to tear down its builders. If the build fails, the artifacts reported
+// by all the attempts are passed to options.CleanupOnFailure.
+func runPackerBuildE(t testing.TestingT, options *Options) (string, error) {
 	options.Logger.Logf(t, "Running Packer to generate a custom artifact for template %s", options.Template)
 
+	ctx, cancel := newBuildContext(t, options)
+	defer cancel()
+
+	cleanup, err := preparePluginsE(t, options)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	cmd := shell.Command{
+		Command:          "packer",
+		Args:             formatPackerArgs(options),
+		Env:              options.Env,
+		WorkingDir:       options.WorkingDir,
+		Context:          ctx,
+		InterruptTimeout: defaultInterruptTimeout,
+	}
+
+	// Keep the output of every attempt, as failed attempts may have produced artifacts too
+	var outputs []string
+	description := fmt.Sprintf("%s %v", cmd.Command, cmd.Args)
+	output, err := retry.DoWithRetryableErrorsE(t, description, options.RetryableErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+		if ctx.Err() != nil {
+			return "", retry.FatalError{Underlying: BuildInterruptedError{Template: options.Template, Cause: ctx.Err()}}
+		}
+		output, err := shell.RunCommandAndGetOutputE(t, cmd)
+		outputs = append(outputs, output)
+		if err != nil && ctx.Err() != nil {
+			return output, retry.FatalError{Underlying: BuildInterruptedError{Template: options.Template, Cause: ctx.Err()}}
+		}
+		return output, err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			err = BuildInterruptedError{Template: options.Template, Cause: ctx.Err()}
+		}
+		cleanupFailedBuild(t, options, strings.Join(outputs, "\n"))
+	}
+	return output, err
+}
+
+// newBuildContext returns the context of a build, which is done when options.Context is done, after options.Timeout,
+// or shortly before the deadline of the test if any, so that the build can be interrupted before the test binary panics.
+// The build is interrupted defaultInterruptTimeout before the deadline, or halfway to it if less time is left.
+func newBuildContext(t testing.TestingT, options *Options) (context.Context, context.CancelFunc) {
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cancels := []context.CancelFunc{}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		cancels = append(cancels, cancel)
+	}
+	if tt, ok := t.(interface{ Deadline() (time.Time, bool) }); ok {
+		if deadline, hasDeadline := tt.Deadline(); hasDeadline {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline.Add(-interruptMargin(time.Until(deadline))))
+			cancels = append(cancels, cancel)
+		}
+	}
+	return ctx, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// interruptMargin returns how long before the deadline of the test a build must be interrupted, given the time left
+// until that deadline, so that the build is never interrupted before it even starts.
+func interruptMargin(timeLeft time.Duration) time.Duration {
+	if timeLeft < 2*defaultInterruptTimeout {
+		return timeLeft / 2
+	}
+	return defaultInterruptTimeout
+}
+
+// cleanupFailedBuild passes the artifacts reported in the output of a failed build to options.CleanupOnFailure, if set.
+func cleanupFailedBuild(t testing.TestingT, options *Options, output string) {
+	if options.CleanupOnFailure == nil {
+		return
+	}
+	artifacts, err := ParseArtifactsE(t, output)
+	if err != nil {
+		options.Logger.Logf(t, "Could not parse the artifacts of the failed build of template %s: %v", options.Template, err)
+		return
+	}
+	options.Logger.Logf(t, "Cleaning up the artifacts of the failed build of template %s", options.Template)
+	options.CleanupOnFailure(t, artifacts)
+}
+
+// preparePluginsE points Packer to a temporary plugin directory, unless DisableTemporaryPluginPath is set, and runs
+// 'packer init' to install the plugins required by the template. The returned function removes the temporary plugin
+// directory.
+func preparePluginsE(t testing.TestingT, options *Options) (func(), error) {
+	cleanup := func() {}
+
 	// By default, we download packer plugins to a temporary directory rather than use the global plugin path.
 	// This prevents race conditions when multiple tests are running in parallel and each of them attempt
 	// to download the same plugin at the same time to the global path.
@@ -111,31 +277,15 @@ func BuildArtifactE(t testing.TestingT, options *Options) (string, error) {
 			options.Env = make(map[string]string)
 		}
 		options.Env[packerPluginPathEnvVar] = pluginDir
-		defer os.RemoveAll(pluginDir)
-	}
-
-	err := packerInit(t, options)
-	if err != nil {
-		return "", err
+		cleanup = func() { os.RemoveAll(pluginDir) }
 	}
 
-	cmd := shell.Command{
-		Command:    "packer",
-		Args:       formatPackerArgs(options),
-		Env:        options.Env,
-		WorkingDir: options.WorkingDir,
+	if err := packerInit(t, options); err != nil {
+		cleanup()
+		return nil, err
 	}
 
-	description := fmt.Sprintf("%s %v", cmd.Command, cmd.Args)
-	output, err := retry.DoWithRetryableErrorsE(t, description, options.RetryableErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
-		return shell.RunCommandAndGetOutputE(t, cmd)
-	})
-
-	if err != nil {
-		return "", err
-	}
-
-	return extractArtifactID(output)
+	return cleanup, nil
 }
 
 // BuildAmi builds the given Packer template and return the generated AMI ID.
@@ -251,6 +401,13 @@ func packerInit(t testing.TestingT, options *Options) error {
 // packer build [OPTIONS] template
 func formatPackerArgs(options *Options) []string {
 	args := []string{"build", "-machine-readable"}
+	args = append(args, formatPackerVarArgs(options)...)
+	return append(args, options.Template)
+}
+
+// Convert the variables and build filters of the options to the arguments shared by the build and validate commands.
+func formatPackerVarArgs(options *Options) []string {
+	args := []string{}
 
 	for key, value := range options.Vars {
 		args = append(args, "-var", fmt.Sprintf("%s=%s", key, value))
@@ -268,5 +425,5 @@ func formatPackerArgs(options *Options) []string {
 		args = append(args, fmt.Sprintf("-except=%s", options.Except))
 	}
 
-	return append(args, options.Template)
+	return args
 }
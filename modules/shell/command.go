@@ -2,6 +2,7 @@ package shell
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/testing"
@@ -22,6 +24,11 @@ type Command struct {
 	Args       []string          // The args to pass to the command
 	WorkingDir string            // The working directory
 	Env        map[string]string // Additional environment variables to set
+	Stdin      io.Reader         // The input to pass to the command. Defaults to the stdin of this Go program.
+	// If set, the command is sent an interrupt signal when the context is done, to let it shut down gracefully. It is
+	// killed if it is still running InterruptTimeout later, or right away if InterruptTimeout is zero.
+	Context          context.Context
+	InterruptTimeout time.Duration
 	// Use the specified logger for the command's output. Use logger.Discard to not print the output while executing the command.
 	Logger *logger.Logger
 }
@@ -84,6 +91,27 @@ func RunCommandAndGetStdOutE(t testing.TestingT, command Command) (string, error
 	return output.Stdout(), nil
 }
 
+// RunCommandAndGetStdOutErr runs a shell command and returns its stdout and stderr as separate strings. The stdout and
+// stderr of that command will also be logged with Command.Log to make debugging easier. If there are any errors, fail
+// the test.
+func RunCommandAndGetStdOutErr(t testing.TestingT, command Command) (string, string) {
+	stdout, stderr, err := RunCommandAndGetStdOutErrE(t, command)
+	require.NoError(t, err)
+	return stdout, stderr
+}
+
+// RunCommandAndGetStdOutErrE runs a shell command and returns its stdout and stderr as separate strings. The stdout
+// and stderr of that command will also be logged with Command.Log to make debugging easier. Any returned error will be
+// of type ErrWithCmdOutput, containing the output streams and the underlying error.
+func RunCommandAndGetStdOutErrE(t testing.TestingT, command Command) (string, string, error) {
+	output, err := runCommand(t, command)
+	if err != nil {
+		return output.Stdout(), output.Stderr(), &ErrWithCmdOutput{err, output}
+	}
+
+	return output.Stdout(), output.Stderr(), nil
+}
+
 type ErrWithCmdOutput struct {
 	Underlying error
 	Output     *output
@@ -99,9 +127,12 @@ func (e *ErrWithCmdOutput) Error() string {
 func runCommand(t testing.TestingT, command Command) (*output, error) {
 	command.Logger.Logf(t, "Running command %s with args %s", command.Command, command.Args)
 
-	cmd := exec.Command(command.Command, command.Args...)
+	cmd := newExecCommand(command)
 	cmd.Dir = command.WorkingDir
 	cmd.Stdin = os.Stdin
+	if command.Stdin != nil {
+		cmd.Stdin = command.Stdin
+	}
 	cmd.Env = formatEnvVars(command)
 
 	stdout, err := cmd.StdoutPipe()
@@ -127,6 +158,27 @@ func runCommand(t testing.TestingT, command Command) (*output, error) {
 	return output, cmd.Wait()
 }
 
+// newExecCommand returns the exec.Cmd for the given command, which is interrupted when the context of the command, if
+// any, is done.
+func newExecCommand(command Command) *exec.Cmd {
+	if command.Context == nil {
+		return exec.Command(command.Command, command.Args...)
+	}
+
+	cmd := exec.CommandContext(command.Context, command.Command, command.Args...)
+	if command.InterruptTimeout > 0 {
+		cmd.Cancel = func() error {
+			// Interrupts are not supported on Windows, in which case the process is killed
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				return cmd.Process.Kill()
+			}
+			return nil
+		}
+		cmd.WaitDelay = command.InterruptTimeout
+	}
+	return cmd
+}
+
 // This function captures stdout and stderr into the given variables while still printing it to the stdout and stderr
 // of this Go program
 func readStdoutAndStderr(t testing.TestingT, log *logger.Logger, stdout, stderr io.ReadCloser) (*output, error) {
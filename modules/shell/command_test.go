@@ -2,10 +2,12 @@ package shell
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -26,6 +28,40 @@ func TestRunCommandAndGetOutput(t *testing.T) {
 	assert.Equal(t, text, strings.TrimSpace(out))
 }
 
+func TestRunCommandWithStdin(t *testing.T) {
+	t.Parallel()
+
+	text := "Hello, World"
+	cmd := Command{
+		Command: "cat",
+		Stdin:   strings.NewReader(text),
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, text, out)
+}
+
+func TestRunCommandInterruptedByContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	cmd := Command{
+		Command:          "bash",
+		Args:             []string{"-c", "trap 'echo interrupted; exit 1' INT; sleep 30 > /dev/null 2>&1 & wait"},
+		Context:          ctx,
+		InterruptTimeout: 10 * time.Second,
+		Logger:           logger.Discard,
+	}
+
+	start := time.Now()
+	out, err := RunCommandAndGetOutputE(t, cmd)
+	assert.Error(t, err)
+	assert.Equal(t, "interrupted", strings.TrimSpace(out))
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
 func TestRunCommandAndGetOutputOrder(t *testing.T) {
 	t.Parallel()
 
@@ -79,6 +115,20 @@ func TestRunCommandGetExitCode(t *testing.T) {
 	assert.Equal(t, code, 42)
 }
 
+func TestRunCommandAndGetStdOutErr(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "bash",
+		Args:    []string{"-c", "echo out; echo err >&2; echo out2"},
+		Logger:  logger.Discard,
+	}
+
+	stdout, stderr := RunCommandAndGetStdOutErr(t, cmd)
+	assert.Equal(t, "out\nout2", stdout)
+	assert.Equal(t, "err", stderr)
+}
+
 func TestRunCommandAndGetOutputConcurrency(t *testing.T) {
 	t.Parallel()
 
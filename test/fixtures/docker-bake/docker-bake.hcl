@@ -0,0 +1,25 @@
+# Targets used in automated tests for the docker.Bake command.
+variable "TAG" {
+  default = "latest"
+}
+
+group "default" {
+  targets = ["hello", "step1"]
+}
+
+target "hello" {
+  context = "../docker"
+  args = {
+    text = "Hello from bake!"
+  }
+  tags = ["gruntwork-io/test-bake-hello:${TAG}"]
+}
+
+target "step1" {
+  inherits = ["hello"]
+  target   = "step1"
+  args = {
+    text1 = "Hello from bake step 1!"
+  }
+  tags = ["gruntwork-io/test-bake-step1:${TAG}"]
+}
@@ -100,4 +100,9 @@ func TestHelmBasicExampleDeployment(t *testing.T) {
 			return statusCode == 200
 		},
 	)
+
+	// Finally, run the test hooks shipped with the chart (templates/tests) with `helm test`. Each test pod is reported as
+	// a subtest of this test, along with its logs.
+	results := helm.RunChartTests(t, options, releaseName, 2*time.Minute)
+	require.Len(t, results, 1)
 }
@@ -26,11 +26,15 @@ import (
 
 // This file contains examples of how to use terratest to test helm chart template logic by rendering the templates
 // using `helm template`, and then reading in the rendered templates.
-// There are two tests:
+// There are four tests:
 // - TestHelmBasicExampleTemplateRenderedDeployment: An example of how to read in the rendered object and check the
 //   computed values.
 // - TestHelmBasicExampleTemplateRequiredTemplateArgs: An example of how to check that the required args are indeed
 //   required for the template to render.
+// - TestHelmBasicExampleTemplateLintAndValuesSchema: An example of how to lint the chart and check input values against
+//   the values.schema.json of the chart.
+// - TestHelmBasicExampleTemplateValuesMatrix: An example of how to render and check every combination of a set of
+//   input values.
 
 // An example of how to verify the rendered template object of a Helm Chart given various inputs.
 func TestHelmBasicExampleTemplateRenderedDeployment(t *testing.T) {
@@ -130,3 +134,62 @@ func TestHelmBasicExampleTemplateRequiredTemplateArgs(t *testing.T) {
 		})
 	}
 }
+
+// An example of how to lint a Helm Chart and validate input values against its values.schema.json, without a cluster.
+func TestHelmBasicExampleTemplateLintAndValuesSchema(t *testing.T) {
+	t.Parallel()
+
+	// Path to the helm chart we will test
+	helmChartPath, err := filepath.Abs("../examples/helm-basic-example")
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+			"containerImageTag":  "1.15.8",
+		},
+	}
+
+	// The chart lints cleanly with valid values. Lint fails the test listing all the errors otherwise.
+	messages := helm.Lint(t, options, helmChartPath)
+	for _, message := range messages {
+		require.NotEqual(t, helm.LintSeverityError, message.Severity, message.Message)
+	}
+	helm.ValidateValues(t, options, helmChartPath)
+
+	// All the schema violations are reported at once: the missing image tag and the empty image repository.
+	options = &helm.Options{SetValues: map[string]string{"containerImageRepo": ""}}
+	violations, err := helm.ValidateValuesE(t, options, helmChartPath)
+	require.Error(t, err)
+	require.Len(t, violations, 2)
+	for _, violation := range violations {
+		require.Equal(t, "helm-basic-example", violation.Chart)
+	}
+}
+
+// An example of how to render a Helm Chart for every combination of a set of input values and Kubernetes versions.
+func TestHelmBasicExampleTemplateValuesMatrix(t *testing.T) {
+	t.Parallel()
+
+	// Path to the helm chart we will test
+	helmChartPath, err := filepath.Abs("../examples/helm-basic-example")
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+		},
+	}
+	dimensions := []helm.ValuesMatrixDimension{
+		helm.NewValuesDimension("containerImageTag", "1.15.8", "1.25.3"),
+		helm.NewKubeVersionDimension("1.26.0", "1.28.0"),
+	}
+
+	// Each combination is rendered in its own subtest, e.g. ValuesMatrix/containerImageTag=1.15.8,kube-version=1.26.0,
+	// so that a failure points to the combination that breaks the chart.
+	helm.RenderValuesMatrix(t, options, helmChartPath, "helm-basic", dimensions, func(t *testing.T, combination helm.ValuesCombination, manifests *helm.RenderedManifests) {
+		require.Contains(t, manifests.Images(), "nginx:"+combination.Selection["containerImageTag"])
+		_, found := manifests.Get("Deployment", "", "helm-basic-helm-basic-example")
+		require.True(t, found)
+	})
+}
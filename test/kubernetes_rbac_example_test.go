@@ -74,4 +74,14 @@ func TestKubernetesRBACExample(t *testing.T) {
 		Resource:  "pod",
 	}
 	require.True(t, k8s.CanIDo(t, serviceAccountKubectlOptions, namespaceListPodAction))
+
+	// We can also check the whole permission matrix of the service account at once, from the point of view of the
+	// admin, using SubjectAccessReviews. Every mismatch is reported together so that a least-privilege audit shows all
+	// the violations in one run.
+	k8s.AssertRBACMatrix(t, options, k8s.ServiceAccountSubject(namespaceName, serviceAccountName), []k8s.RBACExpectation{
+		{Action: adminListPodAction, Allowed: false},
+		{Action: namespaceListPodAction, Allowed: true},
+		{Action: authv1.ResourceAttributes{Verb: "list", Resource: "namespaces"}, Allowed: false},
+		{Action: authv1.ResourceAttributes{Namespace: namespaceName, Verb: "delete", Resource: "secrets"}, Allowed: true},
+	})
 }
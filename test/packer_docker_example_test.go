@@ -11,6 +11,8 @@ import (
 	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
 	"github.com/gruntwork-io/terratest/modules/packer"
 	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // An example of how to test the Packer template in examples/packer-docker-example completely locally using Terratest
@@ -32,8 +34,32 @@ func TestPackerDockerExampleLocal(t *testing.T) {
 		MaxRetries:         DefaultMaxPackerRetries,
 	}
 
+	// Make sure the template is in the canonical HCL2 format
+	assert.Empty(t, packer.FormatCheck(t, packerOptions))
+
 	// website::tag::2::Build the Docker image using Packer
-	packer.BuildArtifact(t, packerOptions)
+	image := packer.BuildDockerImage(t, packerOptions)
+
+	// Make sure to remove the image at the end of the test
+	defer image.Remove(t, packerOptions.Logger)
+
+	// The docker builder produces the image, which the docker-tag post-processor then tags
+	require.NotEmpty(t, image.Artifacts)
+	assert.Equal(t, "packer.docker", image.Artifacts[0].BuilderType)
+	assert.Contains(t, image.Tags, "gruntwork/packer-docker-example:latest")
+
+	// Check that the provisioning scripts installed the web app and its dependencies
+	docker.AssertImageStructure(t, image.ID, docker.ImageStructureTests{
+		Files: []docker.FileTest{
+			{Path: "/home/ubuntu/app.rb"},
+		},
+		Commands: []docker.CommandTest{
+			{
+				Command:        []string{"ruby", "-e", "require 'sinatra'; puts Sinatra::VERSION"},
+				ExpectedOutput: []string{`^\d+\.\d+`},
+			},
+		},
+	})
 
 	serverPort := 8080
 	expectedServerText := fmt.Sprintf("Hello, %s!", random.UniqueId())
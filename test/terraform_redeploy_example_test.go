@@ -11,7 +11,6 @@ import (
 	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/random"
-	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/assert"
@@ -164,18 +163,28 @@ func validateAsgRedeploy(t *testing.T, workingDir string) {
 	// Setup a TLS configuration to submit with the helper, a blank struct is acceptable
 	tlsConfig := tls.Config{}
 
-	// Check once per second that the ELB returns a proper response to make sure there is no downtime during deployment
-	elbChecks := retry.DoInBackgroundUntilStopped(t, fmt.Sprintf("Check URL %s", url), 1*time.Second, func() {
-		http_helper.HttpGetWithCustomValidation(t, url, &tlsConfig, func(statusCode int, body string) bool {
-			return statusCode == 200 && (body == originalText || body == newText)
-		})
+	// Probe the ELB once per second to record any downtime during deployment
+	elbMonitor := http_helper.StartDowntimeMonitor(t, &http_helper.DowntimeMonitorOptions{
+		Requests: []http_helper.HttpDoOptions{{Method: "GET", Url: url, TlsConfig: &tlsConfig}},
+		Interval: 1 * time.Second,
+		ValidateResponse: func(resp *http_helper.Response) error {
+			if resp.StatusCode != 200 {
+				return fmt.Errorf("got a non-200 response (%d)", resp.StatusCode)
+			}
+			if body := resp.BodyString(); body != originalText && body != newText {
+				return fmt.Errorf("got unexpected body %s", body)
+			}
+			return nil
+		},
 	})
 
 	// Redeploy the cluster
 	terraform.Apply(t, terraformOptions)
 
-	// Stop checking the ELB
-	elbChecks.Done()
+	// Stop probing the ELB, and check that the ELB returned a proper response the entire time, i.e. that there was no
+	// downtime
+	report := elbMonitor.Stop()
+	report.AssertMaxDowntime(t, 0)
 }
 
 // (Deprecated) See the fetchFilesFromAsg method below for a more powerful solution.